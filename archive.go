@@ -0,0 +1,336 @@
+package importer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-jsonnet"
+	"go.uber.org/zap"
+)
+
+const (
+	httpArchivePrefix = "http-archive"
+	// defaultArchiveMaxSize limits the size of a downloaded archive and the
+	// total size of its extracted files (see SetMaxSize).
+	defaultArchiveMaxSize = 100 << 20
+	// defaultArchiveTimeout is the timeout of the client, which will be used
+	// without a given client.
+	defaultArchiveTimeout = time.Minute
+)
+
+// HTTPArchiveImporter imports single files of a tarball (`.tar.gz`, `.tgz` or
+// `.tar`), which will be downloaded via HTTPS, via the prefix
+// `http-archive://`. The path inside the archive follows after `!/`. Each
+// archive will be downloaded only once and serves all imports of its files.
+// Relative imports inside a file of the archive will be resolved inside the
+// same archive (see RelativeImporter).
+// Example:
+//   - import 'http-archive://example.com/bundle-1.2.0.tar.gz!/lib.libsonnet'
+type HTTPArchiveImporter struct {
+	client *http.Client
+	logger *zap.Logger
+	// maxSize limits the size of a downloaded archive and the total size of
+	// its extracted files; 0 disables the limit.
+	maxSize int64
+	// archives stores the regular files per archive URL.
+	archives map[string]map[string][]byte
+	cache    contentsCache
+	// recorder adds the archives and their imported files as remote
+	// vertices to the import graph.
+	recorder *GraphRecorder
+	// remoteCache stores the downloaded archives on disk (see SetCache).
+	remoteCache *RemoteCache
+}
+
+// NewHTTPArchiveImporter returns a HTTPArchiveImporter, which downloads the
+// archives via the given client. Without client a client with a timeout of
+// one minute will be used.
+func NewHTTPArchiveImporter(client *http.Client) *HTTPArchiveImporter {
+	if client == nil {
+		client = &http.Client{Timeout: defaultArchiveTimeout}
+	}
+
+	return &HTTPArchiveImporter{
+		client:   client,
+		logger:   zap.New(nil),
+		maxSize:  defaultArchiveMaxSize,
+		archives: map[string]map[string][]byte{},
+	}
+}
+
+// SetCache stores the downloaded archives inside the given directory, so that
+// repeated builds do not download them again. Archives older than the TTL
+// will be revalidated via their ETag; if this fails, for example offline, the
+// cached archive will be used. An empty directory disables the cache.
+func (h *HTTPArchiveImporter) SetCache(dir string, ttl time.Duration) {
+	h.remoteCache = nil
+	if dir != "" {
+		h.remoteCache = NewRemoteCache(dir, ttl)
+		h.remoteCache.Logger(h.logger)
+	}
+}
+
+// SetMaxSize limits the size of a downloaded archive and the total size of its
+// extracted files to the given number of bytes (default: 100 MiB). Larger
+// archives return an ErrByteLimitExceeded error. A limit of 0 disables the
+// check.
+func (h *HTTPArchiveImporter) SetMaxSize(bytes int64) {
+	h.maxSize = bytes
+}
+
+// ResolveRelative implements the RelativeImporter interface. A plain import
+// inside a file of an archive will be resolved relative to this file inside
+// the same archive.
+func (h *HTTPArchiveImporter) ResolveRelative(importedFrom, importedPath string) (string, bool) {
+	location, ok := strings.CutPrefix(importedFrom, httpArchivePrefix+"://")
+	archive, entry, found := strings.Cut(location, "!/")
+
+	if !ok || !found || path.IsAbs(importedPath) {
+		return "", false
+	}
+
+	return httpArchivePrefix + "://" + archive + "!/" + path.Join(path.Dir(entry), importedPath), true
+}
+
+// SetGraphRecorder implements the GraphContributor interface.
+func (h *HTTPArchiveImporter) SetGraphRecorder(recorder *GraphRecorder) {
+	h.recorder = recorder
+}
+
+// CanHandle returns true for the `http-archive` prefix.
+func (h *HTTPArchiveImporter) CanHandle(prefix string) bool {
+	return prefix == httpArchivePrefix
+}
+
+// Logger can be used to set the zap.Logger for the HTTPArchiveImporter.
+func (h *HTTPArchiveImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		h.logger = logger
+		if h.remoteCache != nil {
+			h.remoteCache.Logger(logger)
+		}
+	}
+}
+
+// Prefixa returns the list of supported prefixa for this importer.
+func (h *HTTPArchiveImporter) Prefixa() []string {
+	return []string{httpArchivePrefix}
+}
+
+// Import implements the go-jsonnet iterface method. It downloads the archive
+// (once) and returns the content of the file behind `!/`.
+func (h *HTTPArchiveImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	logger := h.logger.Named("HTTPArchiveImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+	)
+
+	location, ok := strings.CutPrefix(importedPath, httpArchivePrefix+"://")
+	archive, entry, found := strings.Cut(location, "!/")
+
+	if !ok || !found || archive == "" || entry == "" {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: '%s', expected '%s://<host>/<archive>!/<path>'",
+				ErrMalformedImport, importedPath, httpArchivePrefix)
+	}
+
+	entry = path.Clean(entry)
+	foundAt := httpArchivePrefix + "://" + archive + "!/" + entry
+
+	contents, err := h.cache.load(foundAt, func() (jsonnet.Contents, error) {
+		files, err := h.archive("https://" + archive)
+		if err != nil {
+			return jsonnet.Contents{}, err
+		}
+
+		data, exists := files[entry]
+		if !exists {
+			return jsonnet.Contents{},
+				fmt.Errorf("%w: '%s' inside the archive '%s'", ErrFileNotFound, entry, archive)
+		}
+
+		h.recordImport("https://"+archive, foundAt, logger)
+
+		return jsonnet.MakeContentsRaw(data), nil
+	})
+	if err != nil {
+		return jsonnet.MakeContents(""), "", err
+	}
+
+	logger.Debug("returns", zap.String("foundAt", foundAt))
+
+	return contents, foundAt, nil
+}
+
+// recordImport adds the archive URL and the imported file as remote vertices
+// with an edge between them to the import graph.
+func (h *HTTPArchiveImporter) recordImport(url, foundAt string, logger *zap.Logger) {
+	if h.recorder == nil {
+		return
+	}
+
+	meta := GraphMeta{Kind: SourceRemote, Remote: true}
+	for _, vertex := range []string{url, foundAt} {
+		if err := h.recorder.AddNode(vertex, meta); err != nil {
+			logger.Debug(err.Error())
+		}
+	}
+
+	if err := h.recorder.AddEdge(url, foundAt, meta); err != nil {
+		logger.Debug(err.Error())
+	}
+}
+
+// archive returns the regular files of the archive behind the URL, which will
+// be downloaded at the first call. Network errors and server errors wrap
+// ErrRetryable (see MultiImporter.SetRetry).
+func (h *HTTPArchiveImporter) archive(url string) (map[string][]byte, error) {
+	if files, exists := h.archives[url]; exists {
+		return files, nil
+	}
+
+	body, err := h.remoteCache.Fetch(url, func(etag string) ([]byte, string, bool, error) {
+		return h.download(url, etag)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := untar(body, !strings.HasSuffix(url, ".tar"), h.maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("while extracting the archive '%s': %w", url, err)
+	}
+
+	if h.archives == nil {
+		h.archives = map[string]map[string][]byte{}
+	}
+
+	h.archives[url] = files
+
+	return files, nil
+}
+
+// download downloads the archive behind the URL. A not empty etag will be
+// sent as `If-None-Match` header (see RemoteDownload).
+func (h *HTTPArchiveImporter) download(url, etag string) ([]byte, string, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("while downloading the archive '%s': %w", url, err)
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("%w: while downloading the archive '%s': %w", ErrRetryable, url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, "", true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", false, fmt.Errorf("%w: the archive '%s'", ErrFileNotFound, url)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, "", false, fmt.Errorf("%w: while downloading the archive '%s': %s", ErrRetryable, url, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", false, fmt.Errorf("while downloading the archive '%s': %s", url, resp.Status)
+	}
+
+	body, err := readLimited(resp.Body, h.maxSize)
+	if errors.Is(err, ErrByteLimitExceeded) {
+		return nil, "", false, fmt.Errorf("while downloading the archive '%s': %w", url, err)
+	}
+
+	if err != nil {
+		return nil, "", false, fmt.Errorf("%w: while downloading the archive '%s': %w", ErrRetryable, url, err)
+	}
+
+	return body, resp.Header.Get("ETag"), false, nil
+}
+
+// readLimited reads the whole reader, but not more than maxSize bytes; 0
+// disables the limit.
+func readLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrByteLimitExceeded, maxSize)
+	}
+
+	return data, nil
+}
+
+// untar returns the regular files of the (gzipped) tarball keyed by their
+// cleaned paths. The total size of the extracted files must not exceed
+// maxSize bytes, which protects against decompression bombs; 0 disables the
+// limit.
+func untar(data []byte, gzipped bool, maxSize int64) (map[string][]byte, error) {
+	var reader io.Reader = bytes.NewReader(data)
+
+	if gzipped {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		reader = gz
+	}
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(reader)
+	remaining := maxSize
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		var content []byte
+
+		if maxSize > 0 {
+			content, err = io.ReadAll(io.LimitReader(tr, remaining+1))
+			if err == nil && int64(len(content)) > remaining {
+				err = fmt.Errorf("%w: the extracted files have more than %d bytes", ErrByteLimitExceeded, maxSize)
+			}
+
+			remaining -= int64(len(content))
+		} else {
+			content, err = io.ReadAll(tr)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		files[path.Clean(strings.TrimPrefix(header.Name, "/"))] = content
+	}
+}
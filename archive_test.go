@@ -0,0 +1,347 @@
+package importer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+)
+
+// createTarball returns a tarball with the given files and a directory.
+func createTarball(t *testing.T, files map[string]string, gzipped bool) []byte {
+	t.Helper()
+
+	var (
+		buf bytes.Buffer
+		out io.Writer = &buf
+	)
+
+	gz := gzip.NewWriter(&buf)
+	if gzipped {
+		out = gz
+	}
+
+	tw := tar.NewWriter(out)
+
+	if err := tw.WriteHeader(&tar.Header{Name: "lib/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatalf("tar.WriteHeader() error = %v", err)
+	}
+
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("tar.WriteHeader() error = %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("tar.Write() error = %v", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar.Close() error = %v", err)
+	}
+	if gzipped {
+		if err := gz.Close(); err != nil {
+			t.Fatalf("gzip.Close() error = %v", err)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func TestHTTPArchiveImporter_Import(t *testing.T) {
+	files := map[string]string{
+		"lib/main.libsonnet": "{name: 'main'}",
+		"./lib/b.libsonnet":  "{name: 'b'}",
+	}
+	tarballs := map[string][]byte{
+		"/bundle.tar.gz": createTarball(t, files, true),
+		"/bundle.tar":    createTarball(t, files, false),
+		"/broken.tar.gz": []byte("no tarball"),
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error.tar.gz" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		data, exists := tarballs[r.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         string
+		wantFoundAt  string
+		wantErrType  error
+		wantErr      bool
+	}{
+		{
+			name:         "file of a gzipped tarball",
+			importedPath: "http-archive://" + host + "/bundle.tar.gz!/lib/main.libsonnet",
+			want:         "{name: 'main'}",
+			wantFoundAt:  "http-archive://" + host + "/bundle.tar.gz!/lib/main.libsonnet",
+		},
+		{
+			name:         "cleaned path of a tarball",
+			importedPath: "http-archive://" + host + "/bundle.tar!/lib/./b.libsonnet",
+			want:         "{name: 'b'}",
+			wantFoundAt:  "http-archive://" + host + "/bundle.tar!/lib/b.libsonnet",
+		},
+		{
+			name:         "missing file inside the archive - should return error",
+			importedPath: "http-archive://" + host + "/bundle.tar.gz!/lib/missing.libsonnet",
+			wantErrType:  ErrFileNotFound,
+			wantErr:      true,
+		},
+		{
+			name:         "directory inside the archive - should return error",
+			importedPath: "http-archive://" + host + "/bundle.tar.gz!/lib",
+			wantErrType:  ErrFileNotFound,
+			wantErr:      true,
+		},
+		{
+			name:         "missing archive - should return error",
+			importedPath: "http-archive://" + host + "/missing.tar.gz!/lib/main.libsonnet",
+			wantErrType:  ErrFileNotFound,
+			wantErr:      true,
+		},
+		{
+			name:         "server error - should return retryable error",
+			importedPath: "http-archive://" + host + "/error.tar.gz!/lib/main.libsonnet",
+			wantErrType:  ErrRetryable,
+			wantErr:      true,
+		},
+		{
+			name:         "broken archive - should return error",
+			importedPath: "http-archive://" + host + "/broken.tar.gz!/lib/main.libsonnet",
+			wantErr:      true,
+		},
+		{
+			name:         "missing path inside the archive - should return error",
+			importedPath: "http-archive://" + host + "/bundle.tar.gz",
+			wantErrType:  ErrMalformedImport,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPArchiveImporter(server.Client())
+
+			got, gotFoundAt, err := h.Import("main.jsonnet", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HTTPArchiveImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrType != nil {
+				assert.ErrorIs(t, err, tt.wantErrType)
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+			assert.Equal(t, tt.wantFoundAt, gotFoundAt)
+		})
+	}
+}
+
+func TestHTTPArchiveImporter_DownloadOnce(t *testing.T) {
+	tarball := createTarball(t, map[string]string{
+		"main.libsonnet": "{name: 'main'}",
+		"b.libsonnet":    "{name: 'b'}",
+	}, true)
+	requests := 0
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write(tarball)
+	}))
+	defer server.Close()
+
+	archive := "http-archive://" + strings.TrimPrefix(server.URL, "https://") + "/bundle.tar.gz"
+
+	m := NewMultiImporter(NewHTTPArchiveImporter(server.Client()), NewFallbackFileImporter())
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", `{
+		main: import '`+archive+`!/main.libsonnet',
+		b: import '`+archive+`!/b.libsonnet',
+	}`)
+	if err != nil {
+		t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+		return
+	}
+	assert.JSONEq(t, `{"main": {"name": "main"}, "b": {"name": "b"}}`, got)
+	assert.Equal(t, 1, requests)
+
+	// the archive and its files are remote vertices of the import graph
+	url := strings.Replace(archive, httpArchivePrefix+"://", "https://", 1)
+	for _, file := range []string{"main.libsonnet", "b.libsonnet"} {
+		edge, err := m.importGraph.Edge(url, archive+"!/"+file)
+		assert.NoError(t, err)
+		assert.Equal(t, "true", edge.Properties.Attributes["remote"])
+	}
+}
+
+func TestHTTPArchiveImporter_SetCache(t *testing.T) {
+	tarball := createTarball(t, map[string]string{"main.libsonnet": "{name: 'main'}"}, true)
+	requests, revalidations, offline := 0, 0, false
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case offline:
+			w.WriteHeader(http.StatusBadGateway)
+		case r.Header.Get("If-None-Match") == `"v1"`:
+			revalidations++
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write(tarball)
+		}
+	}))
+	defer server.Close()
+
+	importedPath := "http-archive://" + strings.TrimPrefix(server.URL, "https://") + "/bundle.tar.gz!/main.libsonnet"
+	dir := t.TempDir()
+
+	importWithCache := func(ttl time.Duration) {
+		t.Helper()
+		// a new importer per build; only the disk cache is shared
+		h := NewHTTPArchiveImporter(server.Client())
+		h.SetCache(dir, ttl)
+		got, _, err := h.Import("main.jsonnet", importedPath)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "{name: 'main'}", got.String())
+		}
+	}
+
+	importWithCache(time.Hour)
+	importWithCache(time.Hour)
+	assert.Equal(t, 1, requests, "fresh cache")
+
+	importWithCache(0)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, revalidations, "revalidated via ETag")
+
+	offline = true
+	importWithCache(0)
+	assert.Equal(t, 3, requests, "stale archive while the server fails")
+}
+
+func TestHTTPArchiveImporter_RelativeImports(t *testing.T) {
+	tarball := createTarball(t, map[string]string{
+		"lib/main.libsonnet": "{util: import 'util.libsonnet', top: import '../top.libsonnet', text: importstr 'sub/text.txt'}",
+		"lib/util.libsonnet": "{name: 'util'}",
+		"lib/sub/text.txt":   "hello",
+		"top.libsonnet":      "{name: 'top'}",
+	}, true)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(tarball)
+	}))
+	defer server.Close()
+
+	archive := "http-archive://" + strings.TrimPrefix(server.URL, "https://") + "/bundle.tar.gz"
+
+	m := NewMultiImporter(NewHTTPArchiveImporter(server.Client()), NewFallbackFileImporter())
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", "import '"+archive+"!/lib/main.libsonnet'")
+	if err != nil {
+		t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+		return
+	}
+	assert.JSONEq(t, `{"util": {"name": "util"}, "top": {"name": "top"}, "text": "hello"}`, got)
+
+	// paths outside of the archive are not resolved on the local filesystem
+	h := NewHTTPArchiveImporter(server.Client())
+	resolved, ok := h.ResolveRelative(archive+"!/lib/main.libsonnet", "../../testdata/simple/default.jsonnet")
+	assert.True(t, ok)
+	_, _, err = h.Import(archive+"!/lib/main.libsonnet", resolved)
+	assert.ErrorIs(t, err, ErrFileNotFound)
+
+	_, ok = h.ResolveRelative("main.jsonnet", "util.libsonnet")
+	assert.False(t, ok, "no file of an archive")
+}
+
+func TestHTTPArchiveImporter_SetMaxSize(t *testing.T) {
+	tarball := createTarball(t, map[string]string{
+		"main.libsonnet": "{name: 'main'}",
+		"bomb.txt":       strings.Repeat("a", 100000),
+	}, true)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(tarball)
+	}))
+	defer server.Close()
+
+	importedPath := "http-archive://" + strings.TrimPrefix(server.URL, "https://") + "/bundle.tar.gz!/main.libsonnet"
+
+	tests := []struct {
+		name    string
+		maxSize int64
+		wantErr bool
+	}{
+		{
+			name:    "default limit",
+			maxSize: defaultArchiveMaxSize,
+		},
+		{
+			name:    "disabled limit",
+			maxSize: 0,
+		},
+		{
+			name:    "download too large - should return error",
+			maxSize: 100,
+			wantErr: true,
+		},
+		{
+			name:    "extracted files too large - should return error",
+			maxSize: int64(len(tarball)) + 1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPArchiveImporter(server.Client())
+			h.SetMaxSize(tt.maxSize)
+
+			_, _, err := h.Import("main.jsonnet", importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HTTPArchiveImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrByteLimitExceeded)
+				assert.NotErrorIs(t, err, ErrRetryable)
+			}
+		})
+	}
+}
+
+func TestNewHTTPArchiveImporter_Client(t *testing.T) {
+	h := NewHTTPArchiveImporter(nil)
+	assert.NotSame(t, http.DefaultClient, h.client)
+	assert.Equal(t, defaultArchiveTimeout, h.client.Timeout)
+
+	client := &http.Client{}
+	assert.Same(t, client, NewHTTPArchiveImporter(client).client)
+}
@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+)
+
+// contentsCache stores the contents returned by an importer per foundAt
+// value. go-jsonnet caches the parsed imports per foundAt value and expects
+// the same contents for each import of the same foundAt value. Importers,
+// which convert, decorate or evaluate their contents or read them from a
+// source, which can change, therefore load the contents of a foundAt value
+// only once via the cache. The zero value is an empty cache.
+type contentsCache struct {
+	mu       sync.Mutex
+	contents map[string]jsonnet.Contents
+}
+
+// load returns the cached contents of the foundAt value or calls the load
+// function and caches its result. Errors will not be cached. The lock is not
+// held while loading, since the load function can import other files via the
+// same importer, like the nested evaluations of the EvalImporter.
+func (c *contentsCache) load(foundAt string, load func() (jsonnet.Contents, error)) (jsonnet.Contents, error) {
+	c.mu.Lock()
+	contents, exists := c.contents[foundAt]
+	c.mu.Unlock()
+
+	if exists {
+		return contents, nil
+	}
+
+	contents, err := load()
+	if err != nil {
+		return jsonnet.Contents{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// a concurrent import of the same foundAt value could have been faster
+	if cached, exists := c.contents[foundAt]; exists {
+		return cached, nil
+	}
+
+	if c.contents == nil {
+		c.contents = map[string]jsonnet.Contents{}
+	}
+
+	c.contents[foundAt] = contents
+
+	return contents, nil
+}
+
+// forget removes the contents of the foundAt value, so that the next import
+// loads them again; useful for new VMs after the source has changed.
+func (c *contentsCache) forget(foundAt string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.contents, foundAt)
+}
+
+// reset removes all cached contents.
+func (c *contentsCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.contents = nil
+}
+
+// readFile returns the contents of the file read from fsys together with its
+// foundAt value (see distinctFoundAt). The errors of the filesystem, like for
+// a missing file, will be returned as is.
+func (c *contentsCache) readFile(fsys afero.Fs, file string) (jsonnet.Contents, string, error) {
+	foundAt := distinctFoundAt(file)
+
+	contents, err := c.load(foundAt, func() (jsonnet.Contents, error) {
+		data, err := afero.ReadFile(fsys, file)
+		if err != nil {
+			return jsonnet.Contents{}, err
+		}
+
+		return jsonnet.MakeContentsRaw(data), nil
+	})
+
+	return contents, foundAt, err
+}
+
+// distinctFoundAt returns the foundAt value for a file, which an importer
+// reads under another name than its path, like the LockImporter and the root
+// aliases. It must differ from the foundAt value of a plain import of the same
+// file, otherwise go-jsonnet mixes up the contents of both, but it must keep
+// the directory for relative imports inside the file.
+func distinctFoundAt(file string) string {
+	return filepath.Dir(file) + "/./" + filepath.Base(file)
+}
+
+// schemeFoundAt returns the foundAt value for a file, whose contents an
+// importer converts, like the YAMLImporter, or replaces, like the
+// EvalImporter. It differs from the foundAt values of a plain import and of
+// an `importstr` of the same file. Relative imports inside the contents are
+// not supported.
+func schemeFoundAt(scheme, file string) string {
+	return scheme + "://" + filepath.ToSlash(file)
+}
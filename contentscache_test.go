@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentsCache_load(t *testing.T) {
+	var (
+		c     contentsCache
+		calls int
+		fail  = true
+	)
+
+	load := func() (jsonnet.Contents, error) {
+		calls++
+		if fail {
+			return jsonnet.Contents{}, errors.New("not ready")
+		}
+
+		return jsonnet.MakeContents("{}"), nil
+	}
+
+	_, err := c.load("a.libsonnet", load)
+	assert.ErrorContains(t, err, "not ready")
+
+	// errors will not be cached
+	fail = false
+	first, err := c.load("a.libsonnet", load)
+	assert.NoError(t, err)
+
+	second, err := c.load("a.libsonnet", load)
+	assert.NoError(t, err)
+	assert.Equal(t, first, second, "the same contents for the same foundAt value")
+	assert.Equal(t, 2, calls)
+
+	c.forget("a.libsonnet")
+	_, err = c.load("a.libsonnet", load)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	c.reset()
+	_, err = c.load("a.libsonnet", load)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, calls)
+}
+
+func TestContentsCache_readFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "vendor/lib.libsonnet", []byte("{a: 1}"), 0o644); err != nil {
+		t.Fatalf("afero.WriteFile() error = %v", err)
+	}
+
+	var c contentsCache
+
+	contents, foundAt, err := c.readFile(fs, "vendor/lib.libsonnet")
+	assert.NoError(t, err)
+	assert.Equal(t, "vendor/./lib.libsonnet", foundAt)
+	assert.Equal(t, "{a: 1}", contents.String())
+
+	// the cached contents stay, even if the file changes
+	if err := afero.WriteFile(fs, "vendor/lib.libsonnet", []byte("{a: 2}"), 0o644); err != nil {
+		t.Fatalf("afero.WriteFile() error = %v", err)
+	}
+
+	contents, _, err = c.readFile(fs, "vendor/lib.libsonnet")
+	assert.NoError(t, err)
+	assert.Equal(t, "{a: 1}", contents.String())
+
+	_, _, err = c.readFile(fs, "vendor/missing.libsonnet")
+	assert.ErrorIs(t, err, afero.ErrFileNotFound)
+}
@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"fmt"
+
+	"github.com/google/go-jsonnet"
+	"go.uber.org/zap"
+)
+
+// DecoratingImporter wraps another Importer and rewrites the contents returned
+// by it via a user-supplied function, for example to inject a prelude like
+// `local lib = import 'lib.libsonnet';` into every imported file. All other
+// methods will be delegated to the wrapped importer, so that it can be used
+// at the same place inside the chain of the MultiImporter.
+// Example:
+//   - NewMultiImporter(NewGlobImporter(), NewDecoratingImporter(NewFallbackFileImporter(), addPrelude))
+type DecoratingImporter struct {
+	importer Importer
+	// decorate gets the foundAt value and the content of an import and
+	// returns the new content.
+	decorate func(path, content string) (string, error)
+	logger   *zap.Logger
+	cache    contentsCache
+}
+
+// NewDecoratingImporter returns a DecoratingImporter, which passes the
+// contents of each import of the given importer through the decorate function.
+// The function gets the foundAt value of the import as path.
+func NewDecoratingImporter(importer Importer, decorate func(path, content string) (string, error)) *DecoratingImporter {
+	return &DecoratingImporter{
+		importer: importer,
+		decorate: decorate,
+		logger:   zap.New(nil),
+	}
+}
+
+// Unwrap returns the wrapped importer.
+func (d *DecoratingImporter) Unwrap() Importer {
+	return d.importer
+}
+
+// SetGraphRecorder delegates to the wrapped importer, if it implements the
+// GraphContributor interface.
+func (d *DecoratingImporter) SetGraphRecorder(recorder *GraphRecorder) {
+	if contributor, ok := d.importer.(GraphContributor); ok {
+		contributor.SetGraphRecorder(recorder)
+	}
+}
+
+// CanHandle delegates to the wrapped importer.
+func (d *DecoratingImporter) CanHandle(prefix string) bool {
+	return d.importer.CanHandle(prefix)
+}
+
+// Logger sets the zap.Logger for the DecoratingImporter and the wrapped
+// importer.
+func (d *DecoratingImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		d.logger = logger
+	}
+
+	d.importer.Logger(logger)
+}
+
+// Prefixa returns the prefixa of the wrapped importer.
+func (d *DecoratingImporter) Prefixa() []string {
+	return d.importer.Prefixa()
+}
+
+// Import implements the go-jsonnet iterface method. It imports the path via
+// the wrapped importer and returns the decorated contents.
+func (d *DecoratingImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	logger := d.logger.Named("DecoratingImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+	)
+
+	contents, foundAt, err := d.importer.Import(importedFrom, importedPath)
+	if err != nil || d.decorate == nil {
+		return contents, foundAt, err
+	}
+
+	decorated, err := d.cache.load(foundAt, func() (jsonnet.Contents, error) {
+		content, err := d.decorate(foundAt, contents.String())
+		if err != nil {
+			return jsonnet.Contents{}, fmt.Errorf("while decorating '%s': %w", foundAt, err)
+		}
+
+		return jsonnet.MakeContents(content), nil
+	})
+	if err != nil {
+		return jsonnet.MakeContents(""), "", err
+	}
+
+	logger.Debug("returns", zap.String("foundAt", foundAt))
+
+	return decorated, foundAt, nil
+}
+
+// globImporterOf returns the GlobImporter behind the given importer, which
+// can also be wrapped by a DecoratingImporter.
+func globImporterOf(i Importer) (*GlobImporter, bool) {
+	if d, ok := i.(*DecoratingImporter); ok {
+		return globImporterOf(d.Unwrap())
+	}
+
+	g, ok := i.(*GlobImporter)
+
+	return g, ok
+}
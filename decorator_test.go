@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dominikbraun/graph"
+	"github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoratingImporter_Import(t *testing.T) {
+	markDecorated := func(_, content string) (string, error) {
+		return "(" + content + ") + { decorated: true }", nil
+	}
+
+	tests := []struct {
+		name     string
+		decorate func(path, content string) (string, error)
+		snippet  string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "plain import",
+			decorate: markDecorated,
+			snippet:  "import 'testdata/strict/configs/a.libsonnet'",
+			want:     `{"name": "a", "decorated": true}`,
+		},
+		{
+			name:     "files of a glob import",
+			decorate: markDecorated,
+			snippet:  "import 'glob.stem://testdata/strict/configs/*.libsonnet'",
+			want:     `{"a": {"name": "a", "decorated": true}, "b": {"name": "b", "decorated": true}}`,
+		},
+		{
+			name: "path is the foundAt value",
+			decorate: func(path, _ string) (string, error) {
+				return "'" + path + "'", nil
+			},
+			snippet: "import 'testdata/strict/configs/a.libsonnet'",
+			want:    `"testdata/strict/configs/a.libsonnet"`,
+		},
+		{
+			name:    "without function",
+			snippet: "import 'testdata/strict/configs/a.libsonnet'",
+			want:    `{"name": "a"}`,
+		},
+		{
+			name: "function fails - should return error",
+			decorate: func(_, _ string) (string, error) {
+				return "", errors.New("failed")
+			},
+			snippet: "import 'testdata/strict/configs/a.libsonnet'",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter(NewGlobImporter(), NewDecoratingImporter(NewFallbackFileImporter(), tt.decorate))
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(m)
+
+			got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", tt.snippet)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestDecoratingImporter_ImportCached(t *testing.T) {
+	calls := 0
+	d := NewDecoratingImporter(NewFallbackFileImporter(), func(_, content string) (string, error) {
+		calls++
+		return content, nil
+	})
+
+	first, _, err := d.Import("main.jsonnet", "testdata/strict/configs/a.libsonnet")
+	assert.NoError(t, err)
+
+	// go-jsonnet expects the same contents for the same foundAt value
+	second, _, err := d.Import("other.jsonnet", "testdata/strict/configs/a.libsonnet")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDecoratingImporter_Delegation(t *testing.T) {
+	g := NewGlobImporter()
+	d := NewDecoratingImporter(g, nil)
+
+	assert.ElementsMatch(t, g.Prefixa(), d.Prefixa())
+	assert.True(t, d.CanHandle("glob.stem"))
+	assert.False(t, d.CanHandle(""))
+	assert.Equal(t, g, d.Unwrap())
+
+	recorder := newGraphRecorder(graph.New(graph.StringHash, graph.Directed()), 3)
+	d.SetGraphRecorder(recorder)
+	assert.Equal(t, recorder, g.graphRecorder())
+
+	// the settings of the `config://set` import reach the wrapped GlobImporter
+	m := NewMultiImporter(d, NewFallbackFileImporter())
+	assert.NoError(t, m.Configure(Config{Annotate: true}))
+	assert.True(t, g.annotate)
+}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"go.uber.org/zap"
+)
+
+const evalPrefix = "eval"
+
+// EvalImporter imports the JSON result of evaluating another Jsonnet file via
+// the prefix `eval://` instead of its source, for example to compose
+// pre-rendered results of multi-stage builds. Each file will be evaluated by
+// a nested jsonnet.VM, which uses the importer set via SetImporter - ideally
+// the MultiImporter, which contains the EvalImporter, so that the imports of
+// the nested evaluation pass the same cycle detection and import graph.
+// Example:
+//   - import 'eval://other.jsonnet'
+//
+// Note: each file runs a full evaluation inside its own VM, which does not
+// share the caches of the outer VM. Files imported by the outer and the
+// nested evaluation will therefore be parsed and evaluated twice. The result
+// will be cached per file, so that multiple imports of the same file evaluate
+// it only once.
+type EvalImporter struct {
+	importer  jsonnet.Importer
+	configure func(*jsonnet.VM)
+	logger    *zap.Logger
+	cache     contentsCache
+	// evaluating stores the files of the running nested evaluations; it
+	// stops endless recursions, even if the import cycles are ignored.
+	evaluating map[string]bool
+}
+
+// NewEvalImporter returns an EvalImporter. Without SetImporter, the nested
+// VMs use a new MultiImporter with the default importers.
+func NewEvalImporter() *EvalImporter {
+	return &EvalImporter{
+		logger:     zap.New(nil),
+		evaluating: map[string]bool{},
+	}
+}
+
+// SetImporter sets the importer of the nested VMs. Use the MultiImporter,
+// which contains the EvalImporter, to share its settings and its import graph:
+//
+//	e := NewEvalImporter()
+//	m := NewMultiImporter(e, NewGlobImporter(), NewFallbackFileImporter())
+//	e.SetImporter(m)
+func (e *EvalImporter) SetImporter(importer jsonnet.Importer) {
+	e.importer = importer
+}
+
+// ConfigureVM sets a function, which will be called for each nested VM before
+// the evaluation, for example to inherit the external variables or native
+// functions of the outer VM:
+//
+//	e.ConfigureVM(func(vm *jsonnet.VM) { vm.ExtVar("env", "prod") })
+func (e *EvalImporter) ConfigureVM(fn func(*jsonnet.VM)) {
+	e.configure = fn
+}
+
+// CanHandle returns true for the `eval` prefix.
+func (e *EvalImporter) CanHandle(prefix string) bool {
+	return prefix == evalPrefix
+}
+
+// Logger can be used to set the zap.Logger for the EvalImporter.
+func (e *EvalImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		e.logger = logger
+	}
+}
+
+// Prefixa returns the list of supported prefixa for this importer.
+func (e *EvalImporter) Prefixa() []string {
+	return []string{evalPrefix}
+}
+
+// Import implements the go-jsonnet iterface method. It evaluates the file
+// behind the `eval://` prefix relative to the importing file and returns the
+// resulting JSON.
+func (e *EvalImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	logger := e.logger.Named("EvalImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+	)
+
+	file, ok := strings.CutPrefix(importedPath, evalPrefix+"://")
+	if !ok || file == "" {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: '%s', expected '%s://<file>'", ErrMalformedImport, importedPath, evalPrefix)
+	}
+
+	target := filepath.Clean(filepath.Join(filepath.Dir(importedFrom), filepath.FromSlash(file)))
+	if filepath.IsAbs(file) {
+		target = filepath.Clean(file)
+	}
+
+	foundAt := schemeFoundAt(evalPrefix, target)
+
+	contents, err := e.cache.load(foundAt, func() (jsonnet.Contents, error) {
+		return e.evaluate(importedFrom, file, target)
+	})
+	if err != nil {
+		return jsonnet.MakeContents(""), "", err
+	}
+
+	logger.Debug("returns", zap.String("foundAt", foundAt))
+
+	return contents, foundAt, nil
+}
+
+// evaluate evaluates the file imported from importedFrom inside a nested VM
+// and returns the resulting JSON. The target is the path of the file relative
+// to the cwd.
+func (e *EvalImporter) evaluate(importedFrom, file, target string) (jsonnet.Contents, error) {
+	if e.evaluating[target] {
+		return jsonnet.Contents{},
+			fmt.Errorf("%w detected with evaluating '%s' from '%s'", ErrImportCycle, target, importedFrom)
+	}
+
+	if e.evaluating == nil {
+		e.evaluating = map[string]bool{}
+	}
+
+	e.evaluating[target] = true
+	defer delete(e.evaluating, target)
+
+	importer := e.importer
+	if importer == nil {
+		importer = NewMultiImporter()
+	}
+
+	vm := jsonnet.MakeVM()
+	if e.configure != nil {
+		e.configure(vm)
+	}
+	// the importer must be set last, the configuration must not replace it
+	vm.Importer(importer)
+
+	// importing the file from the importing file, instead of from "" like
+	// vm.EvaluateFile, keeps the cycle detection of the MultiImporter intact
+	node, _, err := vm.ImportAST(importedFrom, file)
+	if err != nil {
+		return jsonnet.Contents{}, fmt.Errorf("while importing '%s' for the evaluation: %w", target, err)
+	}
+
+	result, err := vm.Evaluate(node)
+	if err != nil {
+		return jsonnet.Contents{}, fmt.Errorf("while evaluating '%s': %w", target, err)
+	}
+
+	return jsonnet.MakeContents(result), nil
+}
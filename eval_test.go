@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvalImporter_Import(t *testing.T) {
+	tests := []struct {
+		name               string
+		file               string
+		sharedImporter     bool
+		ignoreImportCycles bool
+		want               string
+		wantErr            string
+	}{
+		{
+			name:           "evaluated result with inherited ext vars",
+			file:           "testdata/eval/main.jsonnet",
+			sharedImporter: true,
+			want:           `{"rendered": {"env": "prod", "lib": {"sum": 2}}, "again": {"env": "prod", "lib": {"sum": 2}}}`,
+		},
+		{
+			name: "default importer of the nested VM",
+			file: "testdata/eval/main.jsonnet",
+			want: `{"rendered": {"env": "prod", "lib": {"sum": 2}}, "again": {"env": "prod", "lib": {"sum": 2}}}`,
+		},
+		{
+			name:           "cycle detected by the shared MultiImporter - should return error",
+			file:           "testdata/eval/cycle_a.jsonnet",
+			sharedImporter: true,
+			wantErr:        ErrImportCycle.Error(),
+		},
+		{
+			name:               "cycle with ignored import cycles - should return error",
+			file:               "testdata/eval/cycle_a.jsonnet",
+			sharedImporter:     true,
+			ignoreImportCycles: true,
+			wantErr:            ErrImportCycle.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEvalImporter()
+			e.ConfigureVM(func(vm *jsonnet.VM) { vm.ExtVar("env", "prod") })
+
+			m := NewMultiImporter(e, NewGlobImporter(), NewFallbackFileImporter())
+			if tt.ignoreImportCycles {
+				m.IgnoreImportCycles()
+			}
+
+			if tt.sharedImporter {
+				e.SetImporter(m)
+			}
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(m)
+
+			got, err := vm.EvaluateFile(tt.file)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvalImporter_ImportMalformed(t *testing.T) {
+	e := NewEvalImporter()
+
+	_, _, err := e.Import("main.jsonnet", "eval://")
+	assert.ErrorIs(t, err, ErrMalformedImport)
+}
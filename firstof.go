@@ -0,0 +1,125 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"go.uber.org/zap"
+)
+
+const (
+	firstOfPrefix = "firstof"
+	// firstOfSeparator separates the targets of a `firstof://` import.
+	firstOfSeparator = "||"
+)
+
+// FirstOfImporter tries a list of `||` separated import paths via the prefix
+// `firstof://` in the given order and returns the first successful import,
+// for example a remote library with a vendored copy as fallback for offline
+// builds. Each target can use any prefix, which will be imported via the
+// importer set with SetImporter - the MultiImporter, which contains the
+// FirstOfImporter.
+// Example:
+//   - import 'firstof://git://repo//lib.libsonnet||vendor/lib.libsonnet'
+type FirstOfImporter struct {
+	importer jsonnet.Importer
+	logger   *zap.Logger
+}
+
+// NewFirstOfImporter returns a FirstOfImporter. The importer of the targets
+// must be set via SetImporter before the first import.
+func NewFirstOfImporter() *FirstOfImporter {
+	return &FirstOfImporter{
+		logger: zap.New(nil),
+	}
+}
+
+// SetImporter sets the importer of the targets. Use the MultiImporter, which
+// contains the FirstOfImporter, to route each target to the right importer.
+// A separate importer would return other contents for files, which are
+// imported by both, which go-jsonnet does not allow:
+//
+//	f := NewFirstOfImporter()
+//	m := NewMultiImporter(f, NewGlobImporter(), NewFallbackFileImporter())
+//	f.SetImporter(m)
+func (f *FirstOfImporter) SetImporter(importer jsonnet.Importer) {
+	f.importer = importer
+}
+
+// CanHandle returns true for the `firstof` prefix.
+func (f *FirstOfImporter) CanHandle(prefix string) bool {
+	return prefix == firstOfPrefix
+}
+
+// AcceptsRawPath implements the RawPathImporter interface, because the
+// targets can contain characters, which are not allowed inside URLs.
+func (f *FirstOfImporter) AcceptsRawPath(prefix string) bool {
+	return f.CanHandle(prefix)
+}
+
+// Logger can be used to set the zap.Logger for the FirstOfImporter.
+func (f *FirstOfImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		f.logger = logger
+	}
+}
+
+// Prefixa returns the list of supported prefixa for this importer.
+func (f *FirstOfImporter) Prefixa() []string {
+	return []string{firstOfPrefix}
+}
+
+// Import implements the go-jsonnet iterface method. It imports the targets
+// behind the `firstof://` prefix from left to right and returns the contents
+// and the foundAt value of the first successful one. If all targets fail, the
+// errors of all targets will be returned.
+func (f *FirstOfImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	logger := f.logger.Named("FirstOfImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+	)
+
+	rest, ok := strings.CutPrefix(importedPath, firstOfPrefix+"://")
+	if !ok || rest == "" {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: '%s', expected '%s://<target>%s<target>'",
+				ErrMalformedImport, importedPath, firstOfPrefix, firstOfSeparator)
+	}
+
+	targets := strings.Split(rest, firstOfSeparator)
+	for i, target := range targets {
+		if targets[i] = strings.TrimSpace(target); targets[i] == "" {
+			return jsonnet.MakeContents(""), "",
+				fmt.Errorf("%w: empty target inside '%s'", ErrMalformedImport, importedPath)
+		}
+	}
+
+	if f.importer == nil {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w for the targets of '%s', use SetImporter", ErrNoImporter, importedPath)
+	}
+
+	errs := make([]error, 0, len(targets))
+
+	for _, target := range targets {
+		contents, foundAt, err := f.importer.Import(importedFrom, target)
+		if err == nil {
+			logger.Debug("returns", zap.String("target", target), zap.String("foundAt", foundAt))
+
+			return contents, foundAt, nil
+		}
+
+		logger.Info("target failed, trying next target",
+			zap.String("target", target),
+			zap.Error(err),
+		)
+
+		errs = append(errs, fmt.Errorf("target '%s': %w", target, err))
+	}
+
+	return jsonnet.MakeContents(""), "",
+		fmt.Errorf("all targets of '%s' failed: %w", importedPath, errors.Join(errs...))
+}
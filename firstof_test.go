@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstOfImporter_Import(t *testing.T) {
+	f := NewFirstOfImporter()
+	m := NewMultiImporter(f, NewGlobImporter(), NewFallbackFileImporter())
+	f.SetImporter(m)
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	got, err := vm.EvaluateFile("testdata/firstof/main.jsonnet")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{
+		"lib": {"name": "vendored"},
+		"first": {"name": "vendored"},
+		"glob": {"name": "vendored", "other": true}
+	}`, got)
+}
+
+func TestFirstOfImporter_ImportErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		importedPath string
+		noImporter   bool
+		wantErr      []error
+	}{
+		{
+			name:         "all targets fail - should return all errors",
+			importedPath: "firstof://missing.libsonnet||glob+://missing/*.libsonnet",
+			wantErr:      []error{ErrFileNotFound, ErrEmptyResult},
+		},
+		{
+			name:         "without targets - should return error",
+			importedPath: "firstof://",
+			wantErr:      []error{ErrMalformedImport},
+		},
+		{
+			name:         "empty target - should return error",
+			importedPath: "firstof://vendor/lib.libsonnet|| ",
+			wantErr:      []error{ErrMalformedImport},
+		},
+		{
+			name:         "without importer - should return error",
+			importedPath: "firstof://vendor/lib.libsonnet",
+			noImporter:   true,
+			wantErr:      []error{ErrNoImporter},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFirstOfImporter()
+			if !tt.noImporter {
+				f.SetImporter(NewMultiImporter(f, NewGlobImporter(), NewFallbackFileImporter()))
+			}
+
+			_, _, err := f.Import("testdata/firstof/main.jsonnet", tt.importedPath)
+			assert.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.ErrorIs(t, err, want)
+			}
+		})
+	}
+}
@@ -1,18 +1,31 @@
 package importer
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	iofs "io/fs"
+	"maps"
 	"net/url"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/dominikbraun/graph"
 	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
 	"github.com/spf13/afero"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/yaml"
 )
 
 type (
@@ -24,6 +37,26 @@ type (
 	//   - `glob.<?>://`, where <?> can be one of [path, file, dir, stem]
 	//   - `glob.<?>+://`, where <?> can be one of [file, dir, stem]
 	//   - `glob+://`
+	//   - `glob.fold://`
+	//   - `glob.auto://`
+	//   - `glob.smart://`, `glob.smart+://`
+	//   - `glob.locals://`
+	//   - `glob.first://`
+	//   - `glob.manifest://`
+	//   - `glob.pairs://`, `glob.kv://`
+	//   - `glob.lazy://`
+	//   - `glob.latest://`
+	//   - `glob.yaml+://`
+	//   - `glob.up+://`
+	//   - `glob.hash://`
+	//   - `glob.sizes://`
+	//   - `glob.yamlstr://`
+	//   - `glob.map://`
+	//   - `glob.both://`
+	//   - `glob.companion://`
+	//   - `glob.bykey://`
+	//   - `glob.set://`
+	//   - `dir://`, `dir+://`
 	//
 	// For `glob.<?>://` all resolved files will stored under its
 	// path, file(name), dir(name), stem (filename without extension). If multiple
@@ -39,6 +72,125 @@ type (
 	//        a: (import 'subfolder/a.libsonnet');
 	//      }
 	//
+	// For `glob.auto://` all resolved files will be stored under its path, but
+	// the import kind (import, importstr or importbin) is chosen per file
+	// based on its extension (see SetKindMap).
+	//
+	// For `glob.set://` the result is an object with the stem, file(name) or
+	// path (selected via `?by=`) of each resolved file as key and `null` as
+	// value. The files will not be imported.
+	//
+	// For `glob.smart://` the files with the extensions .libsonnet, .jsonnet
+	// and .json will be imported via import and all other files via
+	// importstr. The files will be stored under their stem, file(name) or
+	// path selected via `?by=`; `glob.smart+://` merges colliding keys.
+	//
+	// For `glob.locals://` each resolved file will be bound to a local variable
+	// named after its stem (converted into a valid Jsonnet identifier) and
+	// the returned object uses these identifiers as keys.
+	//
+	// For `glob.first://` the import path is a list of '|' separated patterns,
+	// which will be tried from left to right. The files of the first pattern
+	// with results will be merged like for `glob+://`.
+	//
+	// For `glob.fold://` the result is an array of the imports in the same
+	// order, in which `glob+://` would merge them, like
+	// `[(import 'a.libsonnet'), (import 'b.libsonnet')]`. It can be folded
+	// with an own merge function: `std.foldl(myMerge, <array>, {})`.
+	//
+	// For `glob.manifest://` the result is an object with the merged imports
+	// (like for `glob+://`) in the field `result` and the list of resolved
+	// files in the field `sources`.
+	//
+	// For `glob.pairs://` the result is an array of `{key: ..., value: ...}`
+	// objects - one per resolved file in the hierarchical order. The key can
+	// be selected via `?by=stem|file|path` (default: stem). Duplicate keys
+	// appear as multiple pairs; merging is up to the caller.
+	//
+	// For `glob.kv://` the result is the same as for `glob.pairs://`, but
+	// with the field names `k` and `v`, like `[{k: 'host', v: import ...}]`,
+	// to feed it into an object comprehension. For both prefixa the field
+	// names can be changed via `?keyField=<name>&valueField=<name>`.
+	//
+	// For `glob.lazy://` all resolved files will be stored under their stem
+	// like for `glob.stem://`, but each value is a function without
+	// parameters, which returns the import. Only the called functions will
+	// import their file.
+	//
+	// For `glob.latest://` only the most recently modified file will be
+	// imported. Files with the same modification time are ordered like for
+	// `glob+://` and the last one wins.
+	//
+	// For `glob.yaml+://` all resolved YAML files will be merged like for
+	// `glob+://`, but each file will be imported via the `yaml://` prefix,
+	// which must be handled by a YAMLImporter.
+	//
+	// For `glob.up+://` the pattern will be resolved in the directory of the
+	// importing file and in all its parent directories up to the boundary (see
+	// SetUpwardBoundary). The files will be merged like for `glob+://` in the
+	// order from the root to the importing file, so that nearer files win.
+	//
+	// For `glob.hash://` the result is the hex encoded SHA-256 sum of the
+	// concatenated content of the resolved files as string; useful as cache
+	// key. The files will not be imported.
+	//
+	// For `glob.sizes://` the result is an object with the path (or the stem
+	// or file(name) selected via `?by=`) of each resolved file as key and its
+	// size in bytes as value, like `{ 'a.json': 1234 }`. Only the metadata of
+	// the files will be read; the files will not be imported.
+	//
+	// For `glob.yamlstr://` the resolved files will be parsed as YAML (or
+	// JSON) objects and merged like for `glob+://` (the top-level keys of later
+	// files win). The result is the YAML serialization of the merged object as
+	// string. The files will not be evaluated by Jsonnet, therefore only static
+	// data files are supported.
+	//
+	// For `glob.companion://` the result is an object with the directory name
+	// of each resolved file as key and an object as value, which contains the
+	// resolved file and its sibling files given via `?companion=<file>` under
+	// their stems, like `{ auth: { main: import ..., meta: import ... } }`.
+	// The import kind of each file depends on its extension like for
+	// `glob.auto://`. Missing companions return an error or will be left out
+	// via `?missingCompanion=skip`.
+	//
+	// For `glob.bykey://` the result is an object with the value of the field
+	// given via `?field=<name>` (dots select nested fields, like
+	// `metadata.name`) of each resolved file as key and the import of the
+	// file as value. The field will be read in go, therefore only YAML and
+	// JSON files are supported; YAML files will be imported via the `yaml://`
+	// prefix, which requires a YAMLImporter inside the MultiImporter. The
+	// default importers of NewMultiImporter() do not handle it, so that these
+	// imports fail. Missing fields and colliding keys return an error by default,
+	// which can be changed via `?missingField=skip` and
+	// `?collision=last|merge`.
+	//
+	// For `glob.both://` the result is an object with the resolved files
+	// stored under their stem in the field `byStem` (like `glob.stem://`,
+	// the last file wins for colliding stems) and under their path in the
+	// field `byPath` (like `glob.path://`, but without custom keys).
+	//
+	// For `glob.map://` the result is an array with the imports in the sort
+	// order, whereby each import will be passed to the std function given
+	// via `?fn=`, like `[std.prune(import 'a.libsonnet'), ...]`. Jsonnet
+	// evaluates each import in its own scope, therefore only std functions
+	// are possible. Without `?fn=` the result is a function, which takes
+	// the function to apply: `(import 'glob.map://*.libsonnet')(normalize)`.
+	//
+	// Except for `glob.first://`, `glob.up+://` and `dir://` the import path
+	// can also be a bracketed, comma separated list of patterns, like
+	// `glob+://[configs/*.libsonnet, overrides/*.libsonnet]`. The results
+	// will be concatenated in the order of the patterns.
+	//
+	// For `dir://` the import path is a directory instead of a glob pattern.
+	// All files directly inside this directory will be stored under their
+	// file(name) similar to `glob.file://<dir>/*`. Use `dir+://` or a
+	// trailing `**` to also include the files of all sub folders, whereby
+	// colliding file names will be merged similar to `glob.file+://`.
+	//
+	// An extension group like `.@data` inside a pattern will be expanded to
+	// the extensions of this group, like `configs/*.@data` to
+	// `configs/*.{json,yaml,yml,toml}` (see DefineExtGroup).
+	//
 	GlobImporter struct {
 		// JPaths stores extra search paths.
 		JPaths []string
@@ -46,15 +198,204 @@ type (
 		fs     afero.Fs
 		logger *zap.Logger
 
-		importGraph   graph.Graph[string, string]
-		importCounter int
+		// importSettings are the settings of the current import (see parse).
+		importSettings
+
+		// shared is the state, which all imports share; each import works
+		// on its own copy of the GlobImporter (see Resolve).
+		shared *globShared
 
 		// used in the CanHandle() and to store a possible alias.
 		prefixa map[string]string
 		aliases map[string]string
+		// restrictToAliases let the importer only handle its alias prefixa.
+		restrictToAliases bool
 		// excludePattern is used in the GlobImporter to ignore files matching
 		// the given pattern in '.gitIgnore' .
 		excludePattern string
+		// defaultExcludes are the project-wide exclude patterns set via
+		// SetDefaultExclude. They apply to all imports.
+		defaultExcludes []string
+		// ignorePatterns are the exclude patterns loaded from an ignore file
+		// via SetIgnoreFile. They apply to all imports.
+		ignorePatterns []string
+		// perDirConfig applies the local defaults of a `.globconf` file
+		// inside the directory, in which a pattern will be resolved.
+		perDirConfig bool
+		// upwardBoundary is the last directory, which will be searched by the
+		// `glob.up+://` prefix.
+		upwardBoundary string
+		// level decides, which entries the logger writes. It will be set to
+		// the logLevel for a single import and restored afterwards; the
+		// zapcore.InvalidLevel keeps the level of the given logger.
+		level zap.AtomicLevel
+		// jpathPriorities stores the priorities of the JPaths; missing JPaths
+		// have the priority 0 like the cwd.
+		jpathPriorities map[string]int
+		// strictJPaths turns the warning about a missing JPath into an error.
+		strictJPaths bool
+		// contentFilter removes resolved files, if it returns false.
+		contentFilter func(path string, content []byte) bool
+		// globFunc replaces the doublestar library to resolve the patterns
+		// (see SetGlobFunc).
+		globFunc GlobFunc
+		// skipBrokenFiles removes resolved files, which cannot be parsed as
+		// Jsonnet, with a warning instead of failing the evaluation.
+		skipBrokenFiles bool
+		// detectDuplicateContent returns an error, if resolved files have
+		// byte-identical content.
+		detectDuplicateContent bool
+		// warnShadowed logs a warning for resolved files with the same
+		// relative path in different JPaths or the cwd.
+		warnShadowed bool
+		// warnTrivialGlob logs a warning for patterns without any wildcard,
+		// which could be replaced by a plain import.
+		warnTrivialGlob bool
+		// identifierKeys replaces the keys of the object producing prefixa
+		// by valid Jsonnet identifiers.
+		identifierKeys bool
+		// eagerCycleCheck checks the resolved files for import cycles before
+		// go-jsonnet imports them.
+		eagerCycleCheck bool
+		// format selects the layout of the generated Jsonnet code: empty for
+		// the default, "pretty" or "compact".
+		format string
+		// rebaseImports generates absolute import paths instead of paths
+		// relative to the importing file.
+		rebaseImports bool
+		// annotate adds a comment with the source file in front of each
+		// generated import.
+		annotate bool
+		// keyFunc replaces the built-in key derivation of the object
+		// producing prefixa, like `glob.stem://`.
+		keyFunc func(path string) string
+		// kindMap maps file extensions to the import kind, which will be used
+		// by the `glob.auto://` prefix.
+		kindMap map[string]string
+		// extGroups maps the names of extension groups, like "data" for
+		// `.@data`, to their extensions.
+		extGroups map[string][]string
+	}
+
+	// importSettings are the settings of a single import, which parse reads
+	// from the import path. Each import works on its own copy of the
+	// GlobImporter (see Resolve), therefore they never leak into other
+	// imports.
+	importSettings struct {
+		// importExcludePattern is the exclude pattern of the current import
+		// only; set via the `?exclude=` query parameter. It applies in
+		// addition to the excludePattern.
+		importExcludePattern string
+		// group can be used to put the files directly matched by the pattern
+		// ("filesFirst") or the files from deeper sub folders ("dirsFirst")
+		// first. Empty means no grouping.
+		group string
+		// sortMode can be used to sort the files by their raw paths
+		// ("lexical") or by a leading number of the filename ("prefixnum").
+		// Empty or "hierarchical" means hierarchical sort.
+		sortMode string
+		// orderFile is a file with one file name per line, which defines the
+		// order of the resolved files; set via the `?orderFile=` query
+		// parameter.
+		orderFile string
+		// excludeUnlisted removes the resolved files, which are not listed in
+		// the orderFile; set via `?orderUnlisted=exclude`.
+		excludeUnlisted bool
+		// minMatches is the minimum number of resolved files.
+		minMatches int
+		// requireNonEmpty returns an error for resolved files with a size of
+		// zero bytes; set via `?requireNonEmpty=true`.
+		requireNonEmpty bool
+		// depth limits the number of directories matched by the `**` of the
+		// pattern; set via `**{min,max}` inside the pattern.
+		depth *depthRange
+		// patterns stores the patterns of the current import, if the import
+		// path is a bracketed list of patterns like `glob+://[a/*, b/*]`.
+		patterns []string
+		// filesOnly ignores matched directories; used for the `dir://` prefix.
+		filesOnly bool
+		// logLevel is the log level of the current import only; set via the
+		// `?logLevel=` query parameter.
+		logLevel string
+		// mapFn is the std function, which the `glob.map://` prefix applies to
+		// each import; set via the `?fn=` query parameter.
+		mapFn string
+		// pairsBy selects the key ("stem", "file" or "path") used by the
+		// `glob.pairs://`, `glob.smart://` and `glob.set://` prefixa.
+		pairsBy string
+		// companions are the sibling files, which the `glob.companion://`
+		// prefix imports next to each resolved file; set via the
+		// `?companion=` query parameter.
+		companions []string
+		// keyPath is the (dot separated) field, whose value will be used as
+		// key by the `glob.bykey://` prefix; set via `?field=`.
+		keyPath string
+		// skipMissingField leaves out files without the keyPath field
+		// instead of returning an error; set via `?missingField=skip`.
+		skipMissingField bool
+		// collision selects the handling of colliding keys of the
+		// `glob.bykey://` prefix: "error" (default), "last" or "merge".
+		collision string
+		// skipMissingCompanions leaves out missing companions instead of
+		// returning an error; set via `?missingCompanion=skip`.
+		skipMissingCompanions bool
+		// keyField and valueField replace the field names of the pairs of
+		// the `glob.pairs://` and `glob.kv://` prefixa; set via the
+		// `?keyField=` and `?valueField=` query parameters.
+		keyField   string
+		valueField string
+		// keyRegex and keyRepl compute the keys of the object producing
+		// prefixa via a regex replacement on the path of a resolved file; set
+		// via the `?keyRegex=` and `?keyRepl=` query parameters.
+		keyRegex *regexp.Regexp
+		keyRepl  string
+		// lowerKeys lowercases the keys of the object producing prefixa; set
+		// via the `?caseFold=lower` query parameter.
+		lowerKeys bool
+	}
+
+	// globShared is the mutable state of a GlobImporter, which will be shared
+	// by concurrent imports and is therefore guarded by a mutex.
+	globShared struct {
+		mu sync.Mutex
+		// recorder adds the glob imports and their resolved files to the
+		// import graph of the MultiImporter.
+		recorder *GraphRecorder
+		// jpathsChecked avoids repeated warnings about missing JPaths.
+		jpathsChecked bool
+	}
+
+	// GlobFunc resolves the pattern inside the base directory of the given
+	// filesystem and returns the matches relative to the base directory with
+	// forward slashes, like `sub/a.libsonnet` for the base `configs` and the
+	// pattern `**/*.libsonnet`. The base is the static part of the pattern
+	// (see doublestar.SplitPattern) and "." for patterns without it. A
+	// missing base directory is no error, but an empty result.
+	GlobFunc func(fsys afero.Fs, base, pattern string) ([]string, error)
+
+	// depthRange is the inclusive range of directory levels, which the `**`
+	// of a pattern like `**{1,3}/*.libsonnet` can match.
+	depthRange struct {
+		min int
+		max int
+	}
+
+	// dirConfig are the local defaults of a directory loaded from its
+	// `.globconf` file (see PerDirConfig).
+	dirConfig struct {
+		sortMode        string
+		group           string
+		excludePatterns []string
+	}
+
+	// GlobResult is the structured result of a resolved glob import.
+	GlobResult struct {
+		// Prefix is the prefix of the import path, like "glob.stem".
+		Prefix string
+		// Files are the resolved files relative to the importing file.
+		Files []string
+		// Snippet is the generated Jsonnet code, which Import returns.
+		Snippet string
 	}
 
 	// orderedMap takes the glob.<?>:// and glob.<?>+:// results,
@@ -63,7 +404,9 @@ type (
 		items map[string][]string
 		keys  []string
 	}
-	// hierachically sort the resolved files.
+	// hierachically sort the resolved files. The '/' will be replaced by NUL,
+	// so that the content of a directory sorts together and before files
+	// with the same name prefix: `a/b.libsonnet` < `a.libsonnet`.
 	hierachically []string
 )
 
@@ -106,382 +449,2954 @@ func (o *orderedMap) add(key, value string, extend bool) {
 	}
 }
 
-// NewGlobImporter returns a GlobImporter with default prefixa.
+// defaultKindMap returns the default mapping of file extensions to import
+// kinds used by the `glob.auto://` prefix.
+func defaultKindMap() map[string]string {
+	return map[string]string{
+		".jsonnet":   "import",
+		".libsonnet": "import",
+		".json":      "import",
+		".txt":       "importstr",
+		".md":        "importstr",
+		".yaml":      "importstr",
+		".yml":       "importstr",
+		".png":       "importbin",
+		".jpg":       "importbin",
+		".jpeg":      "importbin",
+		".gif":       "importbin",
+		".ico":       "importbin",
+	}
+}
+
+// NewGlobImporter returns a GlobImporter with default prefixa. The given
+// JPaths will be normalized (see SetJPaths).
 func NewGlobImporter(jpaths ...string) *GlobImporter {
 	return &GlobImporter{
 		prefixa: map[string]string{
-			"glob.path":      "",
-			"glob.path+":     "",
-			"glob-str.path":  "",
-			"glob-str.path+": "",
-			"glob.file":      "",
-			"glob.file+":     "",
-			"glob-str.file":  "",
-			"glob-str.file+": "",
-			"glob.dir":       "",
-			"glob.dir+":      "",
-			"glob-str.dir":   "",
-			"glob-str.dir+":  "",
-			"glob.stem":      "",
-			"glob.stem+":     "",
-			"glob-str.stem":  "",
-			"glob-str.stem+": "",
-			"glob+":          "",
-			"glob-str+":      "",
+			"glob.path":         "",
+			"glob.path+":        "",
+			"glob-str.path":     "",
+			"glob-str.path+":    "",
+			"glob.file":         "",
+			"glob.file+":        "",
+			"glob-str.file":     "",
+			"glob-str.file+":    "",
+			"glob.dir":          "",
+			"glob.dir+":         "",
+			"glob-str.dir":      "",
+			"glob-str.dir+":     "",
+			"glob.stem":         "",
+			"glob.stem+":        "",
+			"glob-str.stem":     "",
+			"glob-str.stem+":    "",
+			"glob+":             "",
+			"glob-str+":         "",
+			"glob.auto":         "",
+			"glob.smart":        "",
+			"glob.smart+":       "",
+			"glob.first":        "",
+			"glob-str.first":    "",
+			"glob.manifest":     "",
+			"glob-str.manifest": "",
+			"glob.fold":         "",
+			"glob-str.fold":     "",
+			"glob.pairs":        "",
+			"glob-str.pairs":    "",
+			"glob.kv":           "",
+			"glob-str.kv":       "",
+			"glob.lazy":         "",
+			"glob-str.lazy":     "",
+			"glob.latest":       "",
+			"glob-str.latest":   "",
+			"glob.yaml+":        "",
+			"glob.up+":          "",
+			"glob.hash":         "",
+			"glob.sizes":        "",
+			"glob.companion":    "",
+			"glob.bykey":        "",
+			"glob.yamlstr":      "",
+			"glob.set":          "",
+			"glob.map":          "",
+			"glob-str.map":      "",
+			"glob.both":         "",
+			"glob-str.both":     "",
+			"glob.locals":       "",
+			"glob-str.locals":   "",
+			"dir":               "",
+			"dir+":              "",
 		},
 		aliases:        make(map[string]string),
+		kindMap:        defaultKindMap(),
+		extGroups:      defaultExtGroups(),
 		logger:         zap.New(nil),
-		JPaths:         jpaths,
+		level:          zap.NewAtomicLevelAt(zapcore.InvalidLevel),
+		JPaths:         normalizeJPaths(jpaths),
 		excludePattern: "",
-		importGraph:    graph.New(graph.StringHash, graph.Tree(), graph.Directed(), graph.PreventCycles()),
-		importCounter:  0,
-		fs:             afero.NewOsFs(),
+		shared: &globShared{
+			recorder: newGraphRecorder(
+				graph.New(graph.StringHash, graph.Tree(), graph.Directed(), graph.PreventCycles()), 0,
+			),
+		},
+		fs: afero.NewOsFs(),
+	}
+}
+
+// SetGraphRecorder implements the GraphContributor interface. The MultiImporter
+// sets the recorder for its import graph before each import.
+func (g *GlobImporter) SetGraphRecorder(recorder *GraphRecorder) {
+	if recorder != nil {
+		g.shared.mu.Lock()
+		defer g.shared.mu.Unlock()
+
+		g.shared.recorder = recorder
 	}
 }
 
-func (g *GlobImporter) setImportGraph(importGraph graph.Graph[string, string], importCounter int) {
-	g.importGraph = importGraph
-	g.importCounter = importCounter
+// graphRecorder returns the current GraphRecorder.
+func (g *GlobImporter) graphRecorder() *GraphRecorder {
+	g.shared.mu.Lock()
+	defer g.shared.mu.Unlock()
+
+	return g.shared.recorder
 }
 
+// Exclude sets a glob pattern; matching files will be ignored by all imports.
+// The `?exclude=` query parameter of an import applies in addition.
 func (g *GlobImporter) Exclude(pattern string) {
 	g.excludePattern = pattern
 }
 
-// AddAliasPrefix binds a given alias to a given prefix. This prefix must exist
-// and only one alias per prefix is possible. An alias must have the suffix
-// "://".
-func (g *GlobImporter) AddAliasPrefix(alias, prefix string) error {
-	if _, exists := g.prefixa[prefix]; !exists {
-		return fmt.Errorf("%w '%s'", ErrUnknownPrefix, prefix)
+// ClearExclude removes the exclude pattern set via Exclude().
+func (g *GlobImporter) ClearExclude() {
+	g.excludePattern = ""
+}
+
+// SetDefaultExclude sets project-wide exclude patterns, like
+// `**/*_test.libsonnet`, which apply to all imports in addition to the
+// pattern of Exclude(), the `?exclude=` query parameter and the patterns of
+// the ignore file. Unlike the `?exclude=` query parameter, they persist across
+// imports and will not be removed by ClearExclude(). Calling it without
+// patterns removes the default excludes again. An invalid pattern returns
+// ErrMalformedGlobPattern and keeps the previous default excludes.
+func (g *GlobImporter) SetDefaultExclude(patterns ...string) error {
+	for _, pattern := range patterns {
+		if pattern == "" || !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("%w: '%s' of the default excludes", ErrMalformedGlobPattern, pattern)
+		}
 	}
 
-	g.prefixa[prefix] = alias
-	g.aliases[alias] = prefix
+	g.defaultExcludes = patterns
 
 	return nil
 }
 
-// Logger can be used to set the zap.Logger for the GlobImporter.
-func (g *GlobImporter) Logger(logger *zap.Logger) {
-	if logger != nil {
-		g.logger = logger
+// SetIgnoreFile loads exclude patterns from the given file, like a
+// `.globignore`, with one pattern per line. Empty lines and lines starting
+// with `#` will be skipped. The patterns apply to all imports in addition to
+// the patterns of Exclude() and the `?exclude=` query parameter. An empty file
+// name removes the loaded patterns again.
+func (g *GlobImporter) SetIgnoreFile(file string) error {
+	if file == "" {
+		g.ignorePatterns = nil
+
+		return nil
 	}
-}
 
-// CanHandle implements the interface method of the Importer and returns true,
-// if the path has on of the supported prefixa. Run <Importer>.Prefixa() to get
-// the supported prefixa.
-func (g GlobImporter) CanHandle(path string) bool {
-	for k, v := range g.prefixa {
-		if strings.HasPrefix(path, k) || (strings.HasPrefix(path, v) && len(v) > 0) {
-			return true
-		}
+	data, err := afero.ReadFile(g.fs, file)
+	if err != nil {
+		return fmt.Errorf("while reading the ignore file '%s': %w", file, err)
 	}
 
-	return false
-}
+	patterns := []string{}
 
-// Prefixa returns the list of supported prefixa for this importer.
-func (g GlobImporter) Prefixa() []string {
-	return append(stringKeysFromMap(g.prefixa), stringValuesFromMap(g.prefixa)...)
-}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
 
-// Import implements the go-jsonnet iterface method and converts the resolved
-// paths into readable paths for the original go-jsonnet FileImporter.
-func (g *GlobImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
-	logger := g.logger.Named("GlobImporter")
-	logger.Debug("Import()",
-		zap.String("importedFrom", importedFrom),
-		zap.String("importedPath", importedPath),
-		zap.Strings("jpaths", g.JPaths),
-	)
+		if !doublestar.ValidatePattern(line) {
+			return fmt.Errorf("%w: '%s' in line %d of the ignore file '%s'",
+				ErrMalformedGlobPattern, line, i+1, file)
+		}
 
-	contents := jsonnet.MakeContents("")
+		patterns = append(patterns, line)
+	}
 
-	// Hack-ish !!!:
-	// The resolved glob-imports are still found inside the same file (importedFrom)
-	// But the "foundAt" value is not allowed to be the same for multiple importer runs,
-	// causing different contents.
-	// Related:
-	// - https://github.com/google/go-jsonnet/issues/349
-	// - https://github.com/google/go-jsonnet/issues/374
-	// - https://github.com/google/go-jsonnet/issues/329
-	// So I have to put for example a simple self-reference './' in front of the "importedFrom" path
-	// to fake the foundAt value. (tried multiple things, but even flushing the importerCache of
-	// the VM via running vm.Importer(...) again, couldn't solve this)
-	p := strings.Repeat("./", g.importCounter)
-	foundAt := p + "./" + importedFrom
+	g.ignorePatterns = patterns
 
-	prefix, pattern, err := g.parse(importedPath)
-	if err != nil {
-		return contents, foundAt, err
-	}
-	// this is the path of the import caller
-	cwd, _ := filepath.Split(importedFrom)
-	cwd = filepath.Clean(cwd)
+	return nil
+}
 
-	logger.Debug("parsed parameters from importedPath",
-		zap.String("prefix", prefix),
-		zap.String("pattern", pattern),
-		zap.String("cwd", cwd),
-	)
-	// g.JPaths will be used first, before the cwd - this will give cwd higher
-	// priority at the end.
-	resolvedFiles, err := g.resolveFilesFrom(g.JPaths, cwd, pattern)
-	if err != nil {
-		return contents, foundAt, err
-	}
+// dirConfigFile is the name of the per-directory config file.
+const dirConfigFile = ".globconf"
+
+// PerDirConfig enables or disables the per-directory config files. With it, a
+// `.globconf` file inside the directory, in which a pattern will be resolved
+// (the static part of the pattern relative to the importing file), sets local
+// defaults with one `key=value` per line:
+//   - `sort=<hierarchical|lexical|prefixnum>` and `group=<dirsFirst|filesFirst>`
+//     apply, if the import sets no `?sort=` or `?group=`.
+//   - `exclude=<pattern>` (multiple allowed) is relative to the directory and
+//     applies in addition to all other exclude patterns.
+func (g *GlobImporter) PerDirConfig(enabled bool) {
+	g.perDirConfig = enabled
+}
 
-	logger.Debug("glob library returns", zap.Strings("files", resolvedFiles))
+// loadDirConfig reads the `.globconf` file of the given directory; a missing
+// file returns an empty dirConfig.
+func (g *GlobImporter) loadDirConfig(dir string) (dirConfig, error) {
+	file := filepath.Join(dir, dirConfigFile)
 
-	files := []string{}
-	afiles := allowedFiles(resolvedFiles, importedFrom)
-	basepath, _ := filepath.Split(importedFrom)
+	data, err := afero.ReadFile(g.fs, file)
+	if err != nil {
+		if errors.Is(err, iofs.ErrNotExist) {
+			return dirConfig{}, nil
+		}
 
-	if err := g.importGraph.AddVertex(importedPath,
-		graph.VertexAttribute("shape", "rect"),
-		graph.VertexAttribute("style", "dashed"),
-		graph.VertexAttribute("color", "grey"),
-		graph.VertexAttribute("fontcolor", "grey"),
-	); err != nil {
-		logger.Warn(err.Error())
+		return dirConfig{}, fmt.Errorf("while reading the directory config '%s': %w", file, err)
 	}
 
-	for _, f := range afiles {
-		relf, _ := filepath.Rel(basepath, f)
-		files = append(files, relf)
+	config := dirConfig{}
 
-		if err := g.importGraph.AddVertex(relf,
-			graph.VertexAttribute("shape", "rect"),
-			graph.VertexAttribute("color", "grey"),
-			graph.VertexAttribute("fontcolor", "grey"),
-			graph.VertexAttribute("style", "dashed"),
-		); err != nil {
-			logger.Warn(err.Error())
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
 
-		if err := g.importGraph.AddEdge(importedPath, relf,
-			graph.EdgeAttribute("color", "grey"),
-			graph.EdgeAttribute("style", "dashed"),
-			graph.EdgeWeight(g.importCounter),
-		); err != nil {
-			logger.Warn(err.Error())
+		key, value, _ := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case "sort":
+			switch value {
+			case "hierarchical", "lexical", "prefixnum":
+				config.sortMode = value
+			default:
+				return dirConfig{}, fmt.Errorf("%w: unknown sort '%s' in line %d of the directory config '%s'",
+					ErrUnknownConfig, value, i+1, file)
+			}
+		case "group":
+			switch value {
+			case "dirsFirst", "filesFirst":
+				config.group = value
+			default:
+				return dirConfig{}, fmt.Errorf("%w: unknown group '%s' in line %d of the directory config '%s'",
+					ErrUnknownConfig, value, i+1, file)
+			}
+		case "exclude":
+			if value == "" || !doublestar.ValidatePattern(value) {
+				return dirConfig{}, fmt.Errorf("%w: '%s' in line %d of the directory config '%s'",
+					ErrMalformedGlobPattern, value, i+1, file)
+			}
+
+			config.excludePatterns = append(config.excludePatterns, path.Join(filepath.ToSlash(dir), value))
+		default:
+			return dirConfig{}, fmt.Errorf("%w: '%s' in line %d of the directory config '%s', supported are 'sort', 'group' or 'exclude'",
+				ErrUnknownConfig, key, i+1, file)
 		}
 	}
 
-	joinedImports, err := g.handle(files, prefix)
-	if err != nil {
-		return contents, foundAt, err
-	}
+	return config, nil
+}
 
-	contents = jsonnet.MakeContents(joinedImports)
+// SetFilesystems lets the GlobImporter resolve the glob patterns across a
+// union of the given filesystems. Later filesystems override earlier ones for
+// the same path, which allows for example embedded defaults beneath on-disk
+// overrides: `g.SetFilesystems(embeddedFs, afero.NewOsFs())`.
+// Without any filesystem the OS filesystem will be used (default).
+//
+// The plain imports generated by the GlobImporter are still read by the
+// importer handling them, which reads from the OS filesystem by default. Use
+// FallbackFileImporter.SetFilesystems with the same filesystems to read them
+// from the union as well.
+func (g *GlobImporter) SetFilesystems(fss ...afero.Fs) {
+	g.fs = unionFs(fss...)
+}
 
-	logger.Debug("returns", zap.String("contents", joinedImports), zap.String("foundAt", foundAt))
+// unionFs returns the union of the given filesystems, whereby later
+// filesystems override earlier ones for the same path. Without any filesystem
+// it returns the OS filesystem.
+func unionFs(fss ...afero.Fs) afero.Fs {
+	if len(fss) == 0 {
+		return afero.NewOsFs()
+	}
 
-	return contents, foundAt, nil
+	union := fss[0]
+	for _, layer := range fss[1:] {
+		union = afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(union), layer)
+	}
+
+	return union
 }
 
-// resolveFilesFrom takes a list of paths together with a glob pattern
-// and returns the output of the used doublestar.Glob function.
-func (g *GlobImporter) resolveFilesFrom(searchPaths []string, cwd, pattern string) ([]string, error) {
-	executeGlob := func(dir, pattern string) (matches []string, err error) {
-		pathPattern := filepath.Join(dir, pattern)
-		pathPattern = filepath.Clean(pathPattern)
-		pathPattern = filepath.ToSlash(pathPattern)
-		base, file := doublestar.SplitPattern(pathPattern)
+// depthRangePattern matches the depth range behind a `**` inside a glob
+// pattern, like `**{1,3}`.
+var depthRangePattern = regexp.MustCompile(`\*\*\{(\d+),(\d+)\}`)
+
+// parseDepthRange removes the depth range from the import path and returns
+// the import path with a plain `**` together with the parsed range. The range
+// is nil, if the import path contains none. Only one depth range per import is
+// supported, and its pattern must not contain other `**`, since the levels of
+// multiple `**` cannot be told apart.
+func parseDepthRange(importedPath string) (string, *depthRange, error) {
+	found := depthRangePattern.FindAllStringSubmatch(importedPath, -1)
+	if len(found) == 0 {
+		return importedPath, nil, nil
+	}
 
-		fs, err := afero.NewIOFS(g.fs).Sub(base)
-		if err != nil {
-			return
-		}
+	if len(found) > 1 {
+		return "", nil,
+			fmt.Errorf("%w: only one depth range is supported inside the import '%s'",
+				ErrMalformedGlobPattern, importedPath)
+	}
 
-		if matches, err = doublestar.Glob(fs, file, doublestar.WithNoFollow(), doublestar.WithFailOnIOErrors()); err != nil {
-			return
-		}
+	minDepth, errMin := strconv.Atoi(found[0][1])
+	maxDepth, errMax := strconv.Atoi(found[0][2])
 
-		for i := range matches {
-			matches[i] = filepath.FromSlash(path.Join(base, matches[i]))
-		}
+	if errMin != nil || errMax != nil || minDepth > maxDepth {
+		return "", nil,
+			fmt.Errorf("%w: invalid depth range '%s' inside the import '%s', expected '**{min,max}' with min <= max",
+				ErrMalformedGlobPattern, found[0][0], importedPath)
+	}
 
-		return
+	withoutRange := depthRangePattern.ReplaceAllLiteralString(importedPath, "**")
+
+	_, rest, _ := strings.Cut(withoutRange, "://")
+	if pattern, _, _ := strings.Cut(rest, "?"); strings.Count(pattern, "**") != 1 {
+		return "", nil,
+			fmt.Errorf("%w: a depth range requires a pattern with a single '**' inside the import '%s'",
+				ErrMalformedGlobPattern, importedPath)
 	}
 
-	resolvedFiles := []string{}
+	return withoutRange, &depthRange{min: minDepth, max: maxDepth}, nil
+}
 
-	for _, p := range searchPaths {
-		matches, err := executeGlob(p, pattern)
-		if err != nil {
-			return []string{}, err
-		}
+// extGroupPattern matches the reference of an extension group inside a glob
+// pattern, like `.@data`.
+var extGroupPattern = regexp.MustCompile(`\.@([A-Za-z][A-Za-z0-9_-]*)`)
 
-		resolvedFiles = append(resolvedFiles, matches...)
+// defaultExtGroups returns the built-in extension groups.
+func defaultExtGroups() map[string][]string {
+	return map[string][]string{
+		"data":    {"json", "yaml", "yml", "toml"},
+		"jsonnet": {"jsonnet", "libsonnet"},
+		"text":    {"txt", "md"},
 	}
-	// sort the JPaths results first
-	sort.Sort(hierachically(resolvedFiles))
+}
 
-	// CWD must be last in resolvedFiles
-	matches, err := executeGlob(cwd, pattern)
-	if err != nil {
-		return []string{}, err
+// DefineExtGroup adds or replaces the extension group with the given name,
+// which can be referenced inside a pattern via `.@<name>`, like
+// `glob+://configs/*.@data`. The extensions can be given with or without the
+// leading dot. Without extensions the group will be removed.
+func (g *GlobImporter) DefineExtGroup(name string, exts []string) {
+	if g.extGroups == nil {
+		g.extGroups = map[string][]string{}
 	}
 
-	sort.Sort(hierachically(matches))
-	resolvedFiles = append(resolvedFiles, matches...)
+	if len(exts) == 0 {
+		delete(g.extGroups, name)
 
-	if len(resolvedFiles) == 0 {
-		return []string{},
-			fmt.Errorf("%w for the glob pattern '%s'", ErrEmptyResult, pattern)
+		return
 	}
-	// handle excludes
-	if len(g.excludePattern) > 0 {
-		return g.removeExcludesFrom(resolvedFiles, pattern)
+
+	group := []string{}
+	seen := map[string]bool{}
+
+	for _, ext := range exts {
+		ext = strings.TrimPrefix(ext, ".")
+		if ext == "" || seen[ext] {
+			continue
+		}
+
+		seen[ext] = true
+		group = append(group, ext)
 	}
 
-	return resolvedFiles, nil
+	g.extGroups[name] = group
 }
 
-func (g *GlobImporter) removeExcludesFrom(files []string, pattern string) ([]string, error) {
-	keep := []string{}
+// expandExtGroups replaces each `.@<name>` inside the pattern with the
+// extensions of the group as alternatives, like `.{json,yaml}`.
+func (g GlobImporter) expandExtGroups(pattern string) (string, error) {
+	var err error
 
-	for _, file := range files {
-		match, err := doublestar.PathMatch(g.excludePattern, file)
-		if err != nil {
-			return []string{}, fmt.Errorf("while remove excluded file %s ,error: %w", file, err)
+	expanded := extGroupPattern.ReplaceAllStringFunc(pattern, func(ref string) string {
+		name := ref[len(".@"):]
+
+		exts, exists := g.extGroups[name]
+		if !exists {
+			if err == nil {
+				err = fmt.Errorf("%w: '@%s' inside the pattern '%s'", ErrUnknownExtGroup, name, pattern)
+			}
+
+			return ref
 		}
 
-		if !match {
-			keep = append(keep, file)
+		if len(exts) == 1 {
+			return "." + exts[0]
 		}
-	}
 
-	if len(keep) == 0 {
-		return []string{},
-			fmt.Errorf(
-				"%w, exclude pattern '%s' removed all matches for the glob pattern '%s'",
-				ErrEmptyResult, g.excludePattern, pattern)
-	}
+		return ".{" + strings.Join(exts, ",") + "}"
+	})
 
-	return keep, nil
+	return expanded, err
 }
 
-func (g *GlobImporter) parse(importedPath string) (string, string, error) {
-	parsedURL, err := url.Parse(importedPath)
-	if err != nil {
-		return "", "",
-			fmt.Errorf("%w: cannot parse import '%s', error: %w",
-				ErrMalformedGlobPattern, importedPath, err)
+// SetKindMap replaces the mapping of file extensions (like ".json") to import
+// kinds ("import", "importstr" or "importbin") used by the `glob.auto://`
+// prefix. Files with an extension not found in the map will be imported via
+// "importstr".
+func (g *GlobImporter) SetKindMap(kinds map[string]string) error {
+	kindMap := make(map[string]string, len(kinds))
+
+	for ext, kind := range kinds {
+		switch kind {
+		case "import", "importstr", "importbin":
+			kindMap[strings.ToLower(ext)] = kind
+		default:
+			return fmt.Errorf("%w '%s' for extension '%s'", ErrUnknownImportKind, kind, ext)
+		}
 	}
 
-	prefix := parsedURL.Scheme
-	pattern := strings.Join([]string{parsedURL.Host, parsedURL.Path}, "/")
+	g.kindMap = kindMap
 
-	query, err := url.ParseQuery(parsedURL.RawQuery)
-	if err != nil {
-		return "", "",
-			fmt.Errorf("%w: cannot parse the query inside the import '%s', error: %w",
-				ErrMalformedGlobPattern, importedPath, err)
+	return nil
+}
+
+// importKindFor returns the import kind for the given file based on its
+// extension.
+func (g GlobImporter) importKindFor(file string) string {
+	if kind, exists := g.kindMap[strings.ToLower(filepath.Ext(file))]; exists {
+		return kind
 	}
 
-	if excludePattern, exists := query["exclude"]; exists {
-		g.excludePattern = excludePattern[0]
+	return "importstr"
+}
+
+// smartKindFor returns the import kind used by the `glob.smart://` prefix:
+// "import" for Jsonnet and JSON files and "importstr" for all other files.
+func smartKindFor(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".libsonnet", ".jsonnet", ".json":
+		return "import"
+	default:
+		return "importstr"
 	}
+}
 
-	return prefix, pattern, nil
+// SetJPaths replaces the JPaths with the given ones. The JPaths will be
+// normalized: each path will be cleaned via filepath.Clean and duplicates, like
+// `vendor` and `./vendor`, will be removed, while the order is preserved.
+// Priorities set via SetJPathsWithPriority will be dropped.
+func (g *GlobImporter) SetJPaths(jpaths ...string) {
+	g.JPaths = normalizeJPaths(jpaths)
+	g.jpathPriorities = nil
 }
 
-// allowedFiles removes ignoreFile from a given list of files and
-// converts the rest via filepath.FromSlash().
-// Used to remove self reference of a file to avoid endless loops.
-func allowedFiles(files []string, ignoreFile string) []string {
-	allowedFiles := []string{}
+// normalizeJPaths cleans the given paths and removes duplicates, while the
+// order of the first occurrences is preserved.
+func normalizeJPaths(jpaths []string) []string {
+	if len(jpaths) == 0 {
+		return jpaths
+	}
 
-	for _, file := range files {
-		if file == ignoreFile {
+	normalized := make([]string, 0, len(jpaths))
+	seen := map[string]bool{}
+
+	for _, jpath := range jpaths {
+		jpath = filepath.Clean(jpath)
+		if seen[jpath] {
 			continue
 		}
 
-		importPath := filepath.FromSlash(file)
-		allowedFiles = append(allowedFiles, importPath)
+		seen[jpath] = true
+		normalized = append(normalized, jpath)
 	}
 
-	return allowedFiles
+	return normalized
 }
 
-// handle runs the logic behind the different glob prefixa and returns based on
-// the prefix the import string.
-func (g GlobImporter) handle(files []string, prefix string) (string, error) {
-	resolvedFiles := newOrderedMap()
+// SetJPathsWithPriority replaces the JPaths with the given ones together with
+// a priority. Matches of JPaths with a higher priority come later in the
+// resolved files and therefore win in merges. The cwd of the caller has the
+// priority 0, but comes after JPaths with the same priority. Within a priority
+// the files are sorted in lexicographical and hierarchical order.
+// Example: `{"vendor": 1, "base": -1}` results in base < cwd < vendor.
+// The JPaths will be normalized like in SetJPaths; for duplicates the highest
+// priority wins.
+func (g *GlobImporter) SetJPathsWithPriority(jpaths map[string]int) {
+	priorities := make(map[string]int, len(jpaths))
+
+	for jpath, priority := range jpaths {
+		jpath = filepath.Clean(jpath)
+		if current, exists := priorities[jpath]; !exists || priority > current {
+			priorities[jpath] = priority
+		}
+	}
 
-	// handle import or importstr
-	importKind := "import"
+	g.JPaths = stringKeysFromMap(priorities)
+	sort.Strings(g.JPaths)
+	g.jpathPriorities = priorities
+}
 
-	if strings.HasPrefix(prefix, "glob-str") {
-		prefix = strings.Replace(prefix, "glob-str", "glob", 1)
-		importKind += "str"
-	}
+// StrictJPaths turns the warning about a not existing JPath (or a JPath, which
+// is not a directory) into an error.
+func (g *GlobImporter) StrictJPaths(strict bool) {
+	g.strictJPaths = strict
+}
 
-	// handle alias prefix
-	if p, exists := g.aliases[prefix]; exists {
-		prefix = p
+// checkJPaths logs a warning for every JPath, which does not exist or is not
+// a directory. In strict mode an error will be returned instead. Without
+// strict mode the check runs only once.
+func (g *GlobImporter) checkJPaths(logger *zap.Logger) error {
+	g.shared.mu.Lock()
+	checked := g.shared.jpathsChecked
+	g.shared.jpathsChecked = true
+	g.shared.mu.Unlock()
+
+	if checked && !g.strictJPaths {
+		return nil
 	}
 
-	switch prefix {
-	case "glob+":
-		imports := make([]string, 0, len(files))
+	for _, jpath := range g.JPaths {
+		info, err := g.fs.Stat(jpath)
 
-		for _, f := range files {
-			i := fmt.Sprintf("(%s '%s')", importKind, f)
-			imports = append(imports, i)
+		switch {
+		case err != nil:
+			err = fmt.Errorf("%w '%s', error: %w", ErrMissingJPath, jpath, err)
+		case !info.IsDir():
+			err = fmt.Errorf("%w '%s', error: not a directory", ErrMissingJPath, jpath)
+		default:
+			continue
 		}
 
-		return strings.Join(imports, "+"), nil
-	case "glob.path", "glob.path+":
-		imports := make([]string, 0, len(files))
-
-		for _, f := range files {
-			imports = append(imports, fmt.Sprintf("'%s': (%s '%s'),", f, importKind, f))
+		if g.strictJPaths {
+			return err
 		}
 
-		return fmt.Sprintf("{\n%s\n}", strings.Join(imports, "\n")), nil
-	case "glob.stem", "glob.stem+":
-		for _, f := range files {
-			i := fmt.Sprintf("(%s '%s')", importKind, f)
-			_, filename := filepath.Split(f)
-			stem, _, _ := strings.Cut(filename, ".")
-			resolvedFiles.add(stem, i, strings.HasSuffix(prefix, "+"))
-		}
-	case "glob.file", "glob.file+":
-		for _, f := range files {
-			i := fmt.Sprintf("(%s '%s')", importKind, f)
-			_, filename := filepath.Split(f)
-			resolvedFiles.add(filename, i, strings.HasSuffix(prefix, "+"))
-		}
-	case "glob.dir", "glob.dir+":
-		for _, f := range files {
-			i := fmt.Sprintf("(%s '%s')", importKind, f)
-			dir, _ := filepath.Split(f)
-			resolvedFiles.add(dir, i, strings.HasSuffix(prefix, "+"))
-		}
-	default:
-		return "", fmt.Errorf("%w: %s", ErrUnknownPrefix, prefix)
+		logger.Warn(err.Error())
 	}
 
-	return createGlobDotImportsFrom(resolvedFiles), nil
+	return nil
 }
 
-// createGlobDotImportsFrom transforms the orderedMap of resolvedFiles
-// into the format `{ '<?>': import '...' }`.
-func createGlobDotImportsFrom(resolvedFiles *orderedMap) string {
-	var out strings.Builder
+// AddAliasPrefix binds a given alias to a given prefix. This prefix must exist
+// and only one alias per prefix is possible. An alias must have the suffix
+// "://". The prefix can also be another alias, like `web` for `team` for
+// `glob.stem+`, which will be expanded transitively. An alias, which would
+// end up in a loop of aliases, returns an ErrMalformedAlias error.
+func (g *GlobImporter) AddAliasPrefix(alias, prefix string) error {
+	if _, isAlias := g.aliases[prefix]; isAlias {
+		if g.aliasChainContains(prefix, alias) {
+			return fmt.Errorf("%w: '%s' for '%s' creates a loop of aliases", ErrMalformedAlias, alias, prefix)
+		}
 
-	out.WriteString("{\n")
+		g.aliases[alias] = prefix
 
-	for _, k := range resolvedFiles.keys {
-		fmt.Fprintf(&out, "'%s': %s,\n", k, strings.Join(resolvedFiles.items[k], "+"))
+		return nil
 	}
 
-	out.WriteString("}")
+	if _, exists := g.prefixa[prefix]; !exists {
+		return fmt.Errorf("%w '%s'", ErrUnknownPrefix, prefix)
+	}
+
+	g.prefixa[prefix] = alias
+	g.aliases[alias] = prefix
+
+	return nil
+}
+
+// aliasChainContains returns true, if the given alias is part of the chain of
+// aliases starting at prefix.
+func (g GlobImporter) aliasChainContains(prefix, alias string) bool {
+	seen := map[string]bool{}
+
+	for next := prefix; !seen[next]; {
+		if next == alias {
+			return true
+		}
+
+		seen[next] = true
+
+		p, isAlias := g.aliases[next]
+		if !isAlias {
+			return false
+		}
+
+		next = p
+	}
+
+	return false
+}
+
+// ResolveAlias returns the prefix behind the given alias (see AddAliasPrefix).
+// Chained aliases will be expanded until the built-in prefix. The second
+// return value is false, if the alias is not registered.
+func (g *GlobImporter) ResolveAlias(alias string) (string, bool) {
+	if _, exists := g.aliases[alias]; !exists {
+		return "", false
+	}
+
+	prefix, err := g.expandAlias(alias)
+
+	return prefix, err == nil
+}
+
+// ListAliases returns a copy of all registered aliases together with the
+// prefix they are bound to. For chained aliases this is the next alias of the
+// chain; use ResolveAlias to get the built-in prefix.
+func (g *GlobImporter) ListAliases() map[string]string {
+	return maps.Clone(g.aliases)
+}
+
+// SetGlobFunc replaces the doublestar library, which resolves the patterns,
+// by the given function, for example to match against a database of paths.
+// The excludes, the sorting and all other settings apply to its matches as
+// usual. For the `dir://` and `dir+://` prefixa directories will be removed
+// from its matches. Use nil to get back the doublestar library (default).
+func (g *GlobImporter) SetGlobFunc(fn GlobFunc) {
+	g.globFunc = fn
+}
+
+// SetContentFilter sets a predicate, which gets the path and the content of
+// each resolved file. Files for which the predicate returns false will be
+// removed. Use nil to disable the filter (default).
+// Note: each candidate will be read from the filesystem, therefore use exclude
+// patterns first to reduce the number of candidates.
+func (g *GlobImporter) SetContentFilter(fn func(path string, content []byte) bool) {
+	g.contentFilter = fn
+}
+
+// DetectDuplicateContent enables or disables the check for resolved files
+// with byte-identical content, which returns ErrDuplicateContent.
+// Note: each resolved file will be read and hashed, which is expensive for
+// large matches.
+func (g *GlobImporter) DetectDuplicateContent(enabled bool) {
+	g.detectDuplicateContent = enabled
+}
+
+// WarnShadowed enables or disables a warning for each resolved file, which
+// has the same path relative to its JPath (or the cwd) as a file coming later
+// in the merge order. The warning names the shadowed file and the one, which
+// wins, to make active overrides visible.
+func (g *GlobImporter) WarnShadowed(enabled bool) {
+	g.warnShadowed = enabled
+}
+
+// WarnTrivialGlob enables or disables a warning for each glob import, whose
+// pattern contains no wildcard characters, like `glob+://config.libsonnet`.
+// Such a pattern matches at most a single file and behaves like a plain
+// import wrapped by the GlobImporter, therefore the warning suggests a plain
+// import instead. The `glob.up+://` prefix, which expects literal file names,
+// will not be checked.
+func (g *GlobImporter) WarnTrivialGlob(enabled bool) {
+	g.warnTrivialGlob = enabled
+}
+
+// IdentifierKeys enables or disables the replacement of the keys of the
+// object producing prefixa, like `glob.path://`, by valid Jsonnet identifiers:
+// each character, which is not a letter, a digit or `_`, becomes a `_`, like
+// `a_b_libsonnet` for `a/b.libsonnet`. This allows the dot access in Jsonnet.
+// Keys, which become the same identifier, return an ErrDuplicateKey error;
+// keywords and empty keys an ErrInvalidIdentifier error.
+func (g *GlobImporter) IdentifierKeys(enabled bool) {
+	g.identifierKeys = enabled
+}
+
+// EagerCycleCheck enables or disables the check for import cycles at the glob
+// boundary. The edges from the importing file to each resolved file will be
+// simulated inside the import graph and each resolved file, which contains the
+// same glob import, will be expanded once more. A cycle returns ErrImportCycle
+// with the offending file, before go-jsonnet evaluates any resolved file.
+// Note: the resolved files will be read for the expansion.
+func (g *GlobImporter) EagerCycleCheck(enabled bool) {
+	g.eagerCycleCheck = enabled
+}
+
+// SkipBrokenFiles enables or disables the removal of resolved files, which
+// cannot be parsed as Jsonnet. Skipped files will be logged as warning.
+// Only files imported via `import` will be checked; runtime errors inside
+// these files, like `error 'msg'`, still fail the evaluation.
+func (g *GlobImporter) SkipBrokenFiles(enabled bool) {
+	g.skipBrokenFiles = enabled
+}
+
+// NativeFunctions returns the native functions of the GlobImporter, which can
+// be registered via vm.NativeFunction() to glob at evaluation time:
+//   - `std.native('glob')(base, pattern)` returns the files matching the
+//     pattern relative to the base directory (and the JPaths) as array of
+//     paths relative to the base directory, sorted like the glob imports.
+//     No matches return an empty array.
+func (g *GlobImporter) NativeFunctions() []*jsonnet.NativeFunction {
+	return []*jsonnet.NativeFunction{
+		{
+			Name:   "glob",
+			Params: ast.Identifiers{"base", "pattern"},
+			Func: func(args []interface{}) (interface{}, error) {
+				base, ok := args[0].(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: the base of std.native('glob') must be a string, got %T",
+						ErrMalformedGlobPattern, args[0])
+				}
+
+				pattern, ok := args[1].(string)
+				if !ok || pattern == "" {
+					return nil, fmt.Errorf("%w: the pattern of std.native('glob') must be a non-empty string, got %v",
+						ErrMalformedGlobPattern, args[1])
+				}
+
+				return g.nativeGlob(base, pattern)
+			},
+		},
+	}
+}
+
+// nativeGlob resolves the pattern for the native function `glob`. The
+// settings of the last import, like the `?exclude=` query parameter, will not
+// be used.
+func (g *GlobImporter) nativeGlob(base, pattern string) ([]interface{}, error) {
+	// the resolution changes settings temporarily, like for the per
+	// directory config
+	n := *g
+
+	cwd := filepath.Clean(filepath.FromSlash(base))
+
+	resolvedFiles, err := n.resolveFilesFrom(n.JPaths, cwd, pattern)
+	if err != nil && !errors.Is(err, ErrEmptyResult) {
+		return nil, fmt.Errorf("in std.native('glob')('%s', '%s'), error: %w", base, pattern, err)
+	}
+
+	files := make([]interface{}, 0, len(resolvedFiles))
+
+	for _, f := range resolvedFiles {
+		relf, err := filepath.Rel(cwd, f)
+		if err != nil {
+			return nil, fmt.Errorf("in std.native('glob')('%s', '%s'), error: %w", base, pattern, err)
+		}
+
+		files = append(files, filepath.ToSlash(relf))
+	}
+
+	return files, nil
+}
+
+// ResolvePage resolves the glob pattern relative to the current working
+// directory (and the JPaths) like Resolve, but returns only the window of
+// the sorted files starting at offset with at most limit files together with
+// the total number of files. A limit of 0 returns all files after the offset.
+// The Jsonnet snippet will not be generated. The settings of the last import,
+// like the `?exclude=` query parameter, will not be used.
+// Example: `files, total, err := g.ResolvePage("models/**/*.libsonnet", 0, 50)`
+func (g *GlobImporter) ResolvePage(pattern string, offset, limit int) ([]string, int, error) {
+	if offset < 0 || limit < 0 {
+		return []string{}, 0,
+			fmt.Errorf("%w: offset (%d) and limit (%d) must not be negative", ErrInvalidPage, offset, limit)
+	}
+
+	n := *g
+
+	resolvedFiles, err := n.resolveFilesFrom(n.JPaths, ".", pattern)
+	if err != nil {
+		return []string{}, 0, err
+	}
+
+	total := len(resolvedFiles)
+	if offset >= total {
+		return []string{}, total,
+			fmt.Errorf("%w: offset %d is out of range for %d files of the glob pattern '%s'",
+				ErrInvalidPage, offset, total, pattern)
+	}
+
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+
+	return resolvedFiles[offset:end], total, nil
+}
+
+// RebaseImports enables or disables absolute import paths inside the
+// generated Jsonnet code. By default the paths are relative to the importing
+// file. In both cases go-jsonnet resolves the relative imports inside a
+// resolved file relative to the location of this file.
+func (g *GlobImporter) RebaseImports(enabled bool) {
+	g.rebaseImports = enabled
+}
+
+// Annotate enables or disables comments like `// from libs/host.libsonnet` in
+// front of each generated import to trace the source of merged values.
+func (g *GlobImporter) Annotate(enabled bool) {
+	g.annotate = enabled
+}
+
+// SetFormat selects the layout of the generated Jsonnet code, which can be
+// inspected via Resolve: "pretty" indents the entries of objects and arrays,
+// "compact" puts everything on a single line and an empty string restores
+// the default. The format does not change the evaluated result.
+func (g *GlobImporter) SetFormat(format string) error {
+	switch format {
+	case "", "pretty", "compact":
+		g.format = format
+	default:
+		return fmt.Errorf("%w: globFormat=%s, supported are 'pretty' or 'compact'", ErrUnknownConfig, format)
+	}
+
+	return nil
+}
+
+// SetKeyFunc sets a custom function to compute the keys of the object
+// producing prefixa (like `glob.stem://` or `glob.file://`) from the path of
+// a resolved file. Colliding keys will be handled like colliding built-in keys.
+// The keys can contain any characters, like quotes, since they will be
+// escaped inside the generated code. Use nil to get back the built-in key
+// derivation.
+func (g *GlobImporter) SetKeyFunc(fn func(path string) string) {
+	g.keyFunc = fn
+}
+
+// keyFor returns either the key computed by the `?keyRegex=` of the current
+// import, the key computed by the custom key function for the given file or the
+// given built-in key. With `?caseFold=lower`, the returned key is lowercased.
+// The key is the raw value, which can contain quotes, for example via the
+// `?keyRepl=` of the import; the generated code must quote it via
+// jsonnetString.
+func (g GlobImporter) keyFor(file, builtin string) string {
+	key := builtin
+
+	switch {
+	case g.keyRegex != nil:
+		key = g.keyRegex.ReplaceAllString(file, g.keyRepl)
+	case g.keyFunc != nil:
+		key = g.keyFunc(file)
+	}
+
+	if g.lowerKeys {
+		// only the key, the import path must keep the real case of the file
+		key = strings.ToLower(key)
+	}
+
+	return key
+}
+
+// RestrictToAliases let the GlobImporter only handle its alias prefixa (see
+// AddAliasPrefix) and no longer the built-in `glob.*` prefixa. This allows
+// multiple GlobImporters, for example with different JPaths, inside a single
+// MultiImporter.
+func (g *GlobImporter) RestrictToAliases() {
+	g.restrictToAliases = true
+}
+
+// Logger can be used to set the zap.Logger for the GlobImporter.
+func (g *GlobImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		g.logger = logger
+	}
+}
+
+// CanHandle implements the interface method of the Importer and returns true,
+// if the path has on of the supported prefixa. Run <Importer>.Prefixa() to get
+// the supported prefixa.
+func (g GlobImporter) CanHandle(path string) bool {
+	if _, exists := g.aliases[path]; exists || g.restrictToAliases {
+		return exists
+	}
+
+	for k, v := range g.prefixa {
+		// the short `dir` prefixa must not claim schemes like `directory`
+		if !strings.HasPrefix(k, "glob") {
+			if path == k {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(path, k) || (strings.HasPrefix(path, v) && len(v) > 0) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AcceptsRawPath implements the RawPathImporter interface. The patterns can
+// contain characters, which are not allowed inside URLs, like the `|` of
+// `glob.first://`; parse validates them instead.
+func (g GlobImporter) AcceptsRawPath(prefix string) bool {
+	return g.CanHandle(prefix)
+}
+
+// Prefixa returns the list of supported prefixa for this importer.
+func (g GlobImporter) Prefixa() []string {
+	if g.restrictToAliases {
+		return stringKeysFromMap(g.aliases)
+	}
+
+	prefixa := append(stringKeysFromMap(g.prefixa), stringValuesFromMap(g.prefixa)...)
+
+	// chained aliases are not stored in the prefixa
+	for alias, prefix := range g.aliases {
+		if _, builtin := g.prefixa[prefix]; !builtin {
+			prefixa = append(prefixa, alias)
+		}
+	}
+
+	return prefixa
+}
+
+// Import implements the go-jsonnet iterface method and converts the resolved
+// paths into readable paths for the original go-jsonnet FileImporter.
+func (g *GlobImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	contents := jsonnet.MakeContents("")
+
+	// Hack-ish !!!:
+	// The resolved glob-imports are still found inside the same file (importedFrom)
+	// But the "foundAt" value is not allowed to be the same for multiple importer runs,
+	// causing different contents.
+	// Related:
+	// - https://github.com/google/go-jsonnet/issues/349
+	// - https://github.com/google/go-jsonnet/issues/374
+	// - https://github.com/google/go-jsonnet/issues/329
+	// So I have to put for example a simple self-reference './' in front of the "importedFrom" path
+	// to fake the foundAt value. (tried multiple things, but even flushing the importerCache of
+	// the VM via running vm.Importer(...) again, couldn't solve this)
+	p := strings.Repeat("./", g.graphRecorder().Weight())
+	foundAt := p + "./" + importedFrom
+
+	result, err := g.Resolve(importedFrom, importedPath)
+	if err != nil {
+		return contents, foundAt, err
+	}
+
+	contents = jsonnet.MakeContents(result.Snippet)
+
+	return contents, foundAt, nil
+}
+
+// Resolve runs the same steps as Import, but returns the structured result
+// instead of the go-jsonnet contents. It can be used to inspect the resolved
+// files of an import path without running a jsonnet VM.
+//
+// Each call works on its own copy of the GlobImporter, so that the settings of
+// the import path, like `?exclude=`, stay local to this import. Therefore
+// concurrent imports are safe, as long as the GlobImporter will not be
+// configured at the same time.
+func (g *GlobImporter) Resolve(importedFrom, importedPath string) (GlobResult, error) {
+	n := *g
+
+	return n.resolve(importedFrom, importedPath)
+}
+
+// resolve is the implementation of Resolve, which sets the importSettings of
+// the GlobImporter.
+func (g *GlobImporter) resolve(importedFrom, importedPath string) (GlobResult, error) {
+	recorder := g.graphRecorder()
+
+	prefix, pattern, settings, err := g.parse(importedPath)
+	if err != nil {
+		return GlobResult{}, err
+	}
+
+	g.importSettings = settings
+
+	if g.logLevel != "" {
+		level, exists := logLevels[g.logLevel]
+		if !exists {
+			return GlobResult{}, fmt.Errorf("in importedPath: '%s', error: %w: logLevel=%s, supported are 'logLevel=debug' or 'logLevel=info'",
+				importedPath, ErrUnknownConfig, g.logLevel)
+		}
+		// the log level is scoped to this import only
+		defer g.level.SetLevel(g.level.Level())
+
+		g.level.SetLevel(level)
+	}
+
+	logger := withAtomicLevel(g.logger, g.level).Named("GlobImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+		zap.Strings("jpaths", g.JPaths),
+	)
+	if err := g.checkJPaths(logger); err != nil {
+		return GlobResult{}, err
+	}
+	// this is the path of the import caller
+	cwd, _ := filepath.Split(importedFrom)
+	cwd = filepath.Clean(cwd)
+
+	logger.Debug("parsed parameters from importedPath",
+		zap.String("prefix", prefix),
+		zap.String("pattern", pattern),
+		zap.String("cwd", cwd),
+	)
+	// the prefix without alias and without the importstr variant; the alias
+	// loops were already rejected by parse
+	basePrefix, _, _ := g.importKindOf(prefix)
+
+	if g.warnTrivialGlob {
+		g.logTrivialPatterns(basePrefix, pattern, importedPath, logger)
+	}
+
+	resolvedFiles, err := g.resolveByPrefix(basePrefix, cwd, pattern)
+	if err != nil {
+		return GlobResult{}, err
+	}
+
+	if g.skipBrokenFiles {
+		if resolvedFiles, err = g.removeBrokenFiles(resolvedFiles, prefix, pattern, logger); err != nil {
+			return GlobResult{}, err
+		}
+	}
+
+	if basePrefix == "glob.latest" {
+		if resolvedFiles, err = g.latestFileOf(resolvedFiles); err != nil {
+			return GlobResult{}, err
+		}
+	}
+
+	logger.Debug("glob library returns", zap.Strings("files", resolvedFiles))
+
+	// avoid the Stat calls, if the summary will not be logged
+	if logger.Core().Enabled(zap.InfoLevel) {
+		logger.Info("glob summary",
+			zap.Int("matched", len(resolvedFiles)),
+			zap.Int64("totalBytes", g.totalBytesOf(resolvedFiles)),
+			zap.String("pattern", path.Clean(pattern)),
+		)
+	}
+
+	files := []string{}
+	afiles := allowedFiles(resolvedFiles, importedFrom)
+	basepath, _ := filepath.Split(importedFrom)
+
+	if g.eagerCycleCheck {
+		if err := g.findEagerImportCycle(importedFrom, importedPath, basePrefix, pattern, resolvedFiles); err != nil {
+			return GlobResult{}, err
+		}
+	}
+
+	globMeta := GraphMeta{Kind: SourceGlob}
+	if err := recorder.AddNode(importedPath, globMeta); err != nil {
+		logger.Warn(err.Error())
+	}
+
+	for _, f := range afiles {
+		relf, _ := filepath.Rel(basepath, f)
+		if g.rebaseImports {
+			// absolute paths do not depend on the location of the importing file
+			if relf, err = filepath.Abs(f); err != nil {
+				return GlobResult{}, fmt.Errorf("while rebasing the import of file %s, error: %w", f, err)
+			}
+		}
+		// go-jsonnet expects forward slashes on all OSes
+		relf = filepath.ToSlash(relf)
+		files = append(files, relf)
+
+		vertex := recorder.fileVertex(basepath, relf)
+		if err := recorder.AddNode(vertex, globMeta); err != nil {
+			logger.Warn(err.Error())
+		}
+
+		if err := recorder.AddEdge(importedPath, vertex, globMeta); err != nil {
+			logger.Warn(err.Error())
+		}
+	}
+
+	var snippet string
+
+	// the files of glob.hash, glob.sizes, glob.yamlstr and glob.bykey will be read here
+	// instead of being imported by go-jsonnet; glob.companion checks the
+	// existence of the companions
+	switch basePrefix {
+	case "glob.hash":
+		snippet, err = g.hashOf(basepath, afiles)
+	case "glob.sizes":
+		snippet, err = g.sizesOf(afiles, files)
+	case "glob.yamlstr":
+		snippet, err = g.yamlStringOf(afiles)
+	case "glob.companion":
+		snippet, err = g.companionsOf(afiles, files)
+	case "glob.bykey":
+		snippet, err = g.byFieldOf(afiles, files)
+	default:
+		snippet, err = g.handle(files, prefix)
+	}
+
+	if err != nil {
+		return GlobResult{}, err
+	}
+
+	logger.Debug("returns", zap.String("contents", snippet))
+
+	return GlobResult{Prefix: prefix, Files: files, Snippet: snippet}, nil
+}
+
+// resolveByPrefix resolves the pattern relative to the cwd depending on the
+// (base) prefix.
+func (g *GlobImporter) resolveByPrefix(basePrefix, cwd, pattern string) ([]string, error) {
+	switch basePrefix {
+	case "glob.up+":
+		return g.resolveUpwardFilesFrom(cwd, pattern)
+	case "glob.first":
+		return g.resolveFirstFilesFrom(g.JPaths, cwd, splitAlternatives(pattern))
+	default:
+		if len(g.patterns) > 0 {
+			return g.resolveListFilesFrom(g.JPaths, cwd, g.patterns)
+		}
+		// g.JPaths will be used first, before the cwd - this will give cwd higher
+		// priority at the end.
+		return g.resolveFirstFilesFrom(g.JPaths, cwd, []string{pattern})
+	}
+}
+
+// findEagerImportCycle simulates the edges, which the MultiImporter adds for
+// the imports of the resolved files, and returns ErrImportCycle, if one of
+// them creates a cycle inside the import graph. Afterwards each resolved file,
+// which contains the same glob import, will be expanded once more: if the
+// expansion contains the importing file, the continuous import ends in a
+// cycle too.
+func (g *GlobImporter) findEagerImportCycle(importedFrom, importedPath, basePrefix, pattern string, resolvedFiles []string) error {
+	caller := filepath.Clean(importedFrom)
+	basepath, _ := filepath.Split(importedFrom)
+
+	for _, f := range allowedFiles(resolvedFiles, importedFrom) {
+		relf, _ := filepath.Rel(basepath, f)
+		relf = filepath.ToSlash(relf)
+
+		recorder := g.graphRecorder()
+		if recorder.createsCycle(caller, relf) || recorder.createsCycle(caller, f) {
+			return fmt.Errorf("%w detected with adding %s to %s via the glob import '%s'",
+				ErrImportCycle, relf, caller, importedPath)
+		}
+
+		content, err := afero.ReadFile(g.fs, f)
+		if err != nil {
+			return fmt.Errorf("while reading file %s for the cycle check, error: %w", f, err)
+		}
+
+		if !strings.Contains(string(content), importedPath) {
+			continue
+		}
+
+		cwd, _ := filepath.Split(f)
+
+		expanded, err := g.resolveByPrefix(basePrefix, filepath.Clean(cwd), pattern)
+		if err != nil {
+			// an empty expansion cannot import the caller
+			continue
+		}
+
+		for _, e := range allowedFiles(expanded, f) {
+			if e == caller {
+				return fmt.Errorf("%w detected: %s imports '%s' again, which resolves to %s",
+					ErrImportCycle, f, importedPath, caller)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveFilesFrom takes a list of paths together with a glob pattern
+// and returns the output of the used doublestar.Glob function or the GlobFunc
+// set via SetGlobFunc.
+func (g *GlobImporter) resolveFilesFrom(searchPaths []string, cwd, pattern string) ([]string, error) {
+	local := dirConfig{}
+
+	if g.perDirConfig {
+		dir, _ := doublestar.SplitPattern(filepath.ToSlash(filepath.Join(cwd, pattern)))
+
+		var err error
+		if local, err = g.loadDirConfig(filepath.FromSlash(dir)); err != nil {
+			return []string{}, err
+		}
+		// the settings of the import win over the ones of the directory
+		defer func(sortMode, group string) { g.sortMode, g.group = sortMode, group }(g.sortMode, g.group)
+
+		if g.sortMode == "" {
+			g.sortMode = local.sortMode
+		}
+
+		if g.group == "" {
+			g.group = local.group
+		}
+	}
+
+	// shallow stores the matches, which are not inside deeper sub folders
+	// than the pattern itself.
+	shallow := map[string]bool{}
+	// roots stores the JPath (or the cwd), in which a match was found.
+	roots := map[string]string{}
+	executeGlob := func(dir, pattern string) (matches []string, err error) {
+		pathPattern := filepath.Join(dir, pattern)
+		pathPattern = filepath.Clean(pathPattern)
+		pathPattern = filepath.ToSlash(pathPattern)
+		base, file := doublestar.SplitPattern(pathPattern)
+
+		glob := g.doublestarGlob
+		if g.globFunc != nil {
+			glob = g.customGlob
+		}
+
+		if matches, err = glob(base, file); err != nil {
+			return
+		}
+		// patterns with multiple '**' can match the same file more than once,
+		// like 'configs/configs/a.libsonnet' for '**/configs/**/*.libsonnet'
+		if strings.Count(file, "**") > 1 {
+			matches = uniqueFiles(matches)
+		}
+
+		depth := strings.Count(strings.ReplaceAll(file, "**/", ""), "/")
+		// the levels of a depth range are the directories matched by the
+		// single '**' of the pattern
+		inRange := func(match string) bool {
+			if g.depth == nil || strings.Count(file, "**") != 1 {
+				return true
+			}
+
+			levels := strings.Count(match, "/") - depth
+
+			return levels >= g.depth.min && levels <= g.depth.max
+		}
+
+		filtered := matches[:0]
+
+		for _, match := range matches {
+			if !inRange(match) {
+				continue
+			}
+
+			isShallow := strings.Count(match, "/") <= depth
+			match = filepath.FromSlash(path.Join(base, match))
+			shallow[match] = isShallow
+			roots[match] = dir
+			filtered = append(filtered, match)
+		}
+
+		return filtered, nil
+	}
+
+	resolvedFiles := []string{}
+
+	// matches of search paths with a higher priority come later; the cwd has
+	// the priority 0, but comes after the search paths with the same priority
+	for _, priority := range g.prioritiesOf(searchPaths) {
+		jpathFiles := []string{}
+
+		for _, p := range searchPaths {
+			if g.jpathPriorities[p] != priority {
+				continue
+			}
+
+			matches, err := executeGlob(p, pattern)
+			if err != nil {
+				return []string{}, err
+			}
+
+			jpathFiles = append(jpathFiles, matches...)
+		}
+		// sort the JPaths results first
+		g.sortFiles(jpathFiles)
+		resolvedFiles = append(resolvedFiles, groupFiles(jpathFiles, shallow, g.group)...)
+
+		if priority != 0 {
+			continue
+		}
+		// CWD must be last in resolvedFiles of the same priority
+		matches, err := executeGlob(cwd, pattern)
+		if err != nil {
+			return []string{}, err
+		}
+
+		g.sortFiles(matches)
+		resolvedFiles = append(resolvedFiles, groupFiles(matches, shallow, g.group)...)
+	}
+
+	if len(resolvedFiles) == 0 {
+		return []string{},
+			fmt.Errorf("%w for the glob pattern '%s'", ErrEmptyResult, pattern)
+	}
+	// handle excludes; the one of the import, the default ones and the ones
+	// of the ignore file come on top of the baseline
+	excludePatterns := append([]string{g.excludePattern, g.importExcludePattern}, g.defaultExcludes...)
+	excludePatterns = append(excludePatterns, g.ignorePatterns...)
+	excludePatterns = append(excludePatterns, local.excludePatterns...)
+	for _, excludePattern := range excludePatterns {
+		if len(excludePattern) == 0 {
+			continue
+		}
+
+		var err error
+		if resolvedFiles, err = g.removeExcludesFrom(resolvedFiles, pattern, excludePattern); err != nil {
+			return []string{}, err
+		}
+	}
+
+	if g.requireNonEmpty {
+		if err := g.findEmptyFiles(resolvedFiles); err != nil {
+			return []string{}, err
+		}
+	}
+
+	// the content filter runs after the excludes to read less files
+	if g.contentFilter != nil {
+		var err error
+		if resolvedFiles, err = g.filterByContent(resolvedFiles, pattern); err != nil {
+			return []string{}, err
+		}
+	}
+
+	if g.detectDuplicateContent {
+		if err := g.findDuplicateContent(resolvedFiles); err != nil {
+			return []string{}, err
+		}
+	}
+
+	if g.orderFile != "" {
+		var err error
+		if resolvedFiles, err = g.orderByFile(resolvedFiles, roots, cwd, pattern); err != nil {
+			return []string{}, err
+		}
+	}
+
+	if g.warnShadowed {
+		g.logShadowedFiles(resolvedFiles, roots)
+	}
+
+	if len(resolvedFiles) < g.minMatches {
+		return []string{},
+			fmt.Errorf("%w for the glob pattern '%s': got %d, but required are at least %d",
+				ErrTooFewMatches, pattern, len(resolvedFiles), g.minMatches)
+	}
+
+	return resolvedFiles, nil
+}
+
+// doublestarGlob resolves the pattern inside the base directory via the
+// doublestar library.
+func (g *GlobImporter) doublestarGlob(base, pattern string) ([]string, error) {
+	var fs iofs.FS = afero.NewIOFS(g.fs)
+	// the Sub of afero.IOFS does not support "." for all filesystems
+	if base != "." {
+		var err error
+		if fs, err = afero.NewIOFS(g.fs).Sub(base); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []doublestar.GlobOption{doublestar.WithNoFollow(), doublestar.WithFailOnIOErrors()}
+	if g.filesOnly {
+		opts = append(opts, doublestar.WithFilesOnly())
+	}
+
+	return doublestar.Glob(fs, pattern, opts...)
+}
+
+// customGlob resolves the pattern inside the base directory via the GlobFunc
+// set by SetGlobFunc. Directories will be removed for the `dir://` and
+// `dir+://` prefixa.
+func (g *GlobImporter) customGlob(base, pattern string) ([]string, error) {
+	matches, err := g.globFunc(g.fs, base, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("while resolving the pattern '%s' inside '%s' via the glob function, error: %w",
+			pattern, base, err)
+	}
+
+	if !g.filesOnly {
+		return matches, nil
+	}
+
+	files := make([]string, 0, len(matches))
+
+	for _, match := range matches {
+		info, err := g.fs.Stat(filepath.FromSlash(path.Join(base, match)))
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		files = append(files, match)
+	}
+
+	return files, nil
+}
+
+// orderByFile reorders the files by the orderFile, which will be read from the
+// base directory of the pattern inside the cwd. Each line of the orderFile is
+// a path relative to that base directory; empty lines and lines starting with
+// `#` will be skipped. Files with the same relative path in different JPaths
+// keep their order. Unlisted files will be appended in their current order or
+// removed, if excludeUnlisted is set. Listed paths without match will be
+// ignored.
+func (g *GlobImporter) orderByFile(files []string, roots map[string]string, cwd, pattern string) ([]string, error) {
+	base, _ := doublestar.SplitPattern(filepath.ToSlash(pattern))
+	file := filepath.Join(cwd, filepath.FromSlash(base), filepath.FromSlash(g.orderFile))
+
+	data, err := afero.ReadFile(g.fs, file)
+	if err != nil {
+		return []string{}, fmt.Errorf("while reading the order file '%s': %w", file, err)
+	}
+
+	byPath := map[string][]string{}
+
+	for _, f := range files {
+		rel, err := filepath.Rel(filepath.Join(roots[f], filepath.FromSlash(base)), f)
+		if err != nil {
+			rel = f
+		}
+
+		byPath[filepath.ToSlash(rel)] = append(byPath[filepath.ToSlash(rel)], f)
+	}
+
+	ordered := []string{}
+	// placed stores the files, which are already ordered
+	placed := map[string]bool{}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, f := range byPath[path.Clean(line)] {
+			if !placed[f] {
+				placed[f] = true
+				ordered = append(ordered, f)
+			}
+		}
+	}
+
+	if !g.excludeUnlisted {
+		for _, f := range files {
+			if !placed[f] {
+				ordered = append(ordered, f)
+			}
+		}
+	}
+
+	if len(ordered) == 0 {
+		return []string{},
+			fmt.Errorf("%w, the order file '%s' lists none of the matches for the glob pattern '%s'",
+				ErrEmptyResult, file, pattern)
+	}
+
+	return ordered, nil
+}
+
+// uniqueFiles removes repeated files and keeps the order of their first
+// occurrence.
+func uniqueFiles(files []string) []string {
+	seen := make(map[string]bool, len(files))
+	unique := files[:0]
+
+	for _, f := range files {
+		if !seen[f] {
+			seen[f] = true
+			unique = append(unique, f)
+		}
+	}
+
+	return unique
+}
+
+// sortFiles sorts the files hierarchically. With the sort mode "lexical" the
+// raw paths will be compared byte by byte instead, which means
+// `a.libsonnet` < `a/b.libsonnet`. With the sort mode "prefixnum" the files
+// will be sorted afterwards by the leading number of their filename, like
+// `00-base.libsonnet` or `10-overrides.libsonnet`. Files without such a number
+// come last and keep the hierarchical order.
+func (g *GlobImporter) sortFiles(files []string) {
+	if g.sortMode == "lexical" {
+		sort.Strings(files)
+
+		return
+	}
+
+	sort.Sort(hierachically(files))
+
+	if g.sortMode != "prefixnum" {
+		return
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		ni, iok := leadingNumber(files[i])
+		nj, jok := leadingNumber(files[j])
+
+		if iok && jok {
+			return ni < nj
+		}
+
+		return iok && !jok
+	})
+}
+
+// leadingNumber returns the leading integer of the filename of the given file.
+func leadingNumber(file string) (int, bool) {
+	name := filepath.Base(file)
+
+	end := 0
+	for end < len(name) && name[end] >= '0' && name[end] <= '9' {
+		end++
+	}
+
+	n, err := strconv.Atoi(name[:end])
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// prioritiesOf returns the sorted list of all priorities of the given search
+// paths together with the priority 0 of the cwd.
+func (g *GlobImporter) prioritiesOf(searchPaths []string) []int {
+	priorities := []int{0}
+	seen := map[int]bool{0: true}
+
+	for _, p := range searchPaths {
+		if priority := g.jpathPriorities[p]; !seen[priority] {
+			seen[priority] = true
+			priorities = append(priorities, priority)
+		}
+	}
+
+	sort.Ints(priorities)
+
+	return priorities
+}
+
+// SetUpwardBoundary sets the last directory (like the project root), which
+// will be searched by the `glob.up+://` prefix. Without a boundary the search
+// stops at the current working directory for relative paths or at the
+// filesystem root for absolute paths.
+func (g *GlobImporter) SetUpwardBoundary(dir string) {
+	g.upwardBoundary = ""
+	if dir != "" {
+		g.upwardBoundary = filepath.Clean(dir)
+	}
+}
+
+// resolveUpwardFilesFrom resolves the pattern in the cwd and all its parent
+// directories up to the boundary. The files are ordered from the root to the
+// cwd, so that the files of nearer directories come later.
+func (g *GlobImporter) resolveUpwardFilesFrom(cwd, pattern string) ([]string, error) {
+	dirs := []string{}
+
+	for dir := filepath.Clean(cwd); ; dir = filepath.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+
+		if dir == g.upwardBoundary || dir == "." || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	// minMatches counts for all directories together
+	minMatches := g.minMatches
+	g.minMatches = 0
+
+	defer func() { g.minMatches = minMatches }()
+
+	resolvedFiles := []string{}
+
+	for _, dir := range dirs {
+		files, err := g.resolveFilesFrom([]string{}, dir, pattern)
+		if err != nil && !errors.Is(err, ErrEmptyResult) {
+			return []string{}, err
+		}
+
+		resolvedFiles = append(resolvedFiles, files...)
+	}
+
+	if len(resolvedFiles) == 0 {
+		return []string{},
+			fmt.Errorf("%w for the glob pattern '%s' in '%s' and its parent directories",
+				ErrEmptyResult, pattern, cwd)
+	}
+
+	if len(resolvedFiles) < minMatches {
+		return []string{},
+			fmt.Errorf("%w for the glob pattern '%s': got %d, but required are at least %d",
+				ErrTooFewMatches, pattern, len(resolvedFiles), minMatches)
+	}
+
+	return resolvedFiles, nil
+}
+
+// resolveFirstFilesFrom tries the given patterns in order and returns the
+// files of the first pattern, which resolves to at least one file (or to at
+// least minMatches files). If all patterns fail, the error of the last one will
+// be returned.
+func (g *GlobImporter) resolveFirstFilesFrom(searchPaths []string, cwd string, patterns []string) ([]string, error) {
+	var err error
+
+	for _, pattern := range patterns {
+		var resolvedFiles []string
+
+		resolvedFiles, err = g.resolveFilesFrom(searchPaths, cwd, pattern)
+		if err == nil {
+			return resolvedFiles, nil
+		}
+
+		if !errors.Is(err, ErrEmptyResult) && !errors.Is(err, ErrTooFewMatches) {
+			return []string{}, err
+		}
+	}
+
+	if len(patterns) > 1 {
+		return []string{},
+			fmt.Errorf("%w for all alternatives '%s', last error: %w",
+				ErrEmptyResult, strings.Join(patterns, " | "), err)
+	}
+
+	return []string{}, err
+}
+
+// resolveListFilesFrom resolves each of the given patterns and concatenates
+// the results in the order of the patterns. Each pattern must match at least
+// one file, whereby minMatches counts for all patterns together.
+func (g *GlobImporter) resolveListFilesFrom(searchPaths []string, cwd string, patterns []string) ([]string, error) {
+	minMatches := g.minMatches
+	g.minMatches = 0
+
+	defer func() { g.minMatches = minMatches }()
+
+	resolvedFiles := []string{}
+
+	for _, pattern := range patterns {
+		files, err := g.resolveFilesFrom(searchPaths, cwd, pattern)
+		if err != nil {
+			return []string{}, err
+		}
+
+		resolvedFiles = append(resolvedFiles, files...)
+	}
+
+	if len(resolvedFiles) < minMatches {
+		return []string{},
+			fmt.Errorf("%w for the glob patterns '%s': got %d, but required are at least %d",
+				ErrTooFewMatches, strings.Join(patterns, ", "), len(resolvedFiles), minMatches)
+	}
+
+	return resolvedFiles, nil
+}
+
+// splitPatternList splits a bracketed, comma separated list of patterns like
+// `[configs/*.libsonnet, overrides/*.{json,libsonnet}]`. Commas inside braces
+// or brackets of a pattern do not split.
+func splitPatternList(list string) ([]string, error) {
+	inner, ok := strings.CutSuffix(strings.TrimPrefix(list, "["), "]")
+	if !ok {
+		return []string{}, fmt.Errorf("%w: the list of patterns '%s' must end with ']'", ErrMalformedGlobPattern, list)
+	}
+
+	parts := []string{}
+	depth, start := 0, 0
+
+	for i, r := range inner {
+		switch r {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, inner[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	parts = append(parts, inner[start:])
+	patterns := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		pattern := strings.TrimSpace(part)
+		if pattern == "" || !doublestar.ValidatePattern(pattern) {
+			return []string{}, fmt.Errorf("%w: invalid pattern '%s' in the list of patterns '%s'",
+				ErrMalformedGlobPattern, pattern, list)
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+// splitAlternatives splits the pattern of the `glob.first://` prefix on '|'
+// into the alternative patterns.
+func splitAlternatives(pattern string) []string {
+	alternatives := []string{}
+
+	for _, alternative := range strings.Split(pattern, "|") {
+		if alternative = strings.TrimSpace(alternative); alternative != "" {
+			alternatives = append(alternatives, alternative)
+		}
+	}
+
+	return alternatives
+}
+
+// groupFiles puts either the shallow files ("filesFirst") or the files from
+// deeper sub folders ("dirsFirst") first. The order inside the groups will be
+// kept.
+func groupFiles(files []string, shallow map[string]bool, group string) []string {
+	if group == "" {
+		return files
+	}
+
+	first, second := []string{}, []string{}
+
+	for _, f := range files {
+		if shallow[f] == (group == "filesFirst") {
+			first = append(first, f)
+		} else {
+			second = append(second, f)
+		}
+	}
+
+	return append(first, second...)
+}
+
+// filterByContent removes all files, which are rejected by the content filter.
+func (g *GlobImporter) filterByContent(files []string, pattern string) ([]string, error) {
+	keep := []string{}
+
+	for _, file := range files {
+		content, err := afero.ReadFile(g.fs, file)
+		if err != nil {
+			return []string{}, fmt.Errorf("while reading file %s for the content filter, error: %w", file, err)
+		}
+
+		if g.contentFilter(file, content) {
+			keep = append(keep, file)
+		}
+	}
+
+	if len(keep) == 0 {
+		return []string{},
+			fmt.Errorf("%w, content filter removed all matches for the glob pattern '%s'",
+				ErrEmptyResult, pattern)
+	}
+
+	return keep, nil
+}
+
+// findEmptyFiles returns ErrEmptyFile listing all files with a size of zero
+// bytes. Directories will be ignored.
+func (g *GlobImporter) findEmptyFiles(files []string) error {
+	empty := []string{}
+
+	for _, file := range files {
+		info, err := g.fs.Stat(file)
+		if err != nil {
+			return fmt.Errorf("while reading the size of file %s, error: %w", file, err)
+		}
+
+		if !info.IsDir() && info.Size() == 0 {
+			empty = append(empty, fmt.Sprintf("'%s'", file))
+		}
+	}
+
+	if len(empty) > 0 {
+		return fmt.Errorf("%w: %s", ErrEmptyFile, strings.Join(empty, ", "))
+	}
+
+	return nil
+}
+
+// findDuplicateContent returns ErrDuplicateContent listing all pairs of files
+// with byte-identical content. Each duplicate is paired with the first file
+// having the same content. A file found more than once, like via a JPath
+// equal to the cwd, is not its own duplicate and will be read only once.
+func (g *GlobImporter) findDuplicateContent(files []string) error {
+	first := map[[sha256.Size]byte]string{}
+	duplicates := []string{}
+	seen := map[string]bool{}
+
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			abs = filepath.Clean(file)
+		}
+
+		if seen[abs] {
+			continue
+		}
+
+		seen[abs] = true
+
+		content, err := afero.ReadFile(g.fs, file)
+		if err != nil {
+			return fmt.Errorf("while reading file %s for the duplicate detection, error: %w", file, err)
+		}
+
+		sum := sha256.Sum256(content)
+		if other, exists := first[sum]; exists {
+			duplicates = append(duplicates, fmt.Sprintf("'%s' and '%s'", other, file))
+
+			continue
+		}
+
+		first[sum] = file
+	}
+
+	if len(duplicates) > 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateContent, strings.Join(duplicates, ", "))
+	}
+
+	return nil
+}
+
+// logTrivialPatterns logs a warning for each pattern of the import, which
+// contains no wildcard characters and therefore matches at most one file.
+func (g *GlobImporter) logTrivialPatterns(basePrefix, pattern, importedPath string, logger *zap.Logger) {
+	patterns := []string{pattern}
+
+	switch {
+	case basePrefix == "glob.up+", basePrefix == "dir", basePrefix == "dir+":
+		// literal names are expected or the pattern will be extended
+		return
+	case basePrefix == "glob.first":
+		patterns = strings.Split(pattern, "|")
+	case len(g.patterns) > 0:
+		patterns = g.patterns
+	}
+
+	for _, p := range patterns {
+		if strings.ContainsAny(p, "*?[{") || strings.Contains(p, ".@") {
+			continue
+		}
+
+		logger.Warn("trivial glob pattern",
+			zap.String("pattern", path.Clean(strings.TrimSpace(p))),
+			zap.String("importedPath", importedPath),
+			zap.String("hint", "the pattern contains no wildcard, use a plain import instead"),
+		)
+	}
+}
+
+// logShadowedFiles logs a warning for each file, which has the same path
+// relative to its root (JPath or cwd) as a file coming later in the given
+// files. The later file wins, because it will be merged last.
+func (g *GlobImporter) logShadowedFiles(files []string, roots map[string]string) {
+	logger := withAtomicLevel(g.logger, g.level).Named("GlobImporter")
+	winners := map[string]string{}
+
+	for _, file := range files {
+		if rel, err := filepath.Rel(roots[file], file); err == nil {
+			winners[rel] = file
+		}
+	}
+
+	for _, file := range files {
+		rel, err := filepath.Rel(roots[file], file)
+		if err != nil || winners[rel] == file {
+			continue
+		}
+
+		logger.Warn("shadowed file",
+			zap.String("path", filepath.ToSlash(rel)),
+			zap.String("shadowed", file),
+			zap.String("shadowedBy", winners[rel]),
+		)
+	}
+}
+
+// hashOf returns the hex encoded SHA-256 sum of the given files as Jsonnet
+// string. For each file the hash gets its path relative to the given base
+// path with forward slashes, a NUL byte, the length of its content in bytes
+// as decimal number, another NUL byte and finally the content, so that
+// renaming a file or moving bytes from one file into the next changes the sum.
+func (g *GlobImporter) hashOf(basepath string, files []string) (string, error) {
+	hash := sha256.New()
+
+	for _, file := range files {
+		content, err := afero.ReadFile(g.fs, file)
+		if err != nil {
+			return "", fmt.Errorf("while reading file %s for the hash, error: %w", file, err)
+		}
+
+		rel, err := filepath.Rel(basepath, file)
+		if err != nil {
+			return "", fmt.Errorf("while hashing the path of file %s, error: %w", file, err)
+		}
+
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(rel), len(content))
+		hash.Write(content)
+	}
+
+	return fmt.Sprintf("'%s'", hex.EncodeToString(hash.Sum(nil))), nil
+}
+
+// sizesOf returns an object with the key of each file (see keyBy) and its size
+// in bytes as Jsonnet object. The sizes will be read from the given files and
+// the keys from the given import paths, which must have the same order. For
+// colliding keys the last file wins.
+func (g *GlobImporter) sizesOf(files, importPaths []string) (string, error) {
+	sizes := newOrderedMap()
+
+	for i, file := range files {
+		info, err := g.fs.Stat(file)
+		if err != nil {
+			return "", fmt.Errorf("while reading the size of file %s, error: %w", file, err)
+		}
+
+		f := importPaths[i]
+		sizes.add(g.keyFor(f, g.keyBy(f)), strconv.FormatInt(info.Size(), 10), false)
+	}
+
+	return g.createGlobDotImportsFrom(sizes)
+}
+
+// companionsOf returns an object with the directory name of each file (see
+// keyFor) as key and an object with the imports of the file and its
+// companions as value. The existence of the companions will be checked next
+// to the given files, while the import paths will be derived from the given
+// import paths, which must have the same order.
+func (g *GlobImporter) companionsOf(files, importPaths []string) (string, error) {
+	services := newOrderedMap()
+
+	for i, file := range files {
+		f := importPaths[i]
+		dir := path.Dir(f)
+		_, filename := path.Split(f)
+		stem, _, _ := strings.Cut(filename, ".")
+
+		entries := newOrderedMap()
+		entries.add(stem, g.importExpr(g.importKindFor(f), f), false)
+
+		for _, companion := range g.companions {
+			exists, err := afero.Exists(g.fs, filepath.Join(filepath.Dir(file), filepath.FromSlash(companion)))
+			if err != nil {
+				return "", fmt.Errorf("while checking the companion %s of file %s, error: %w", companion, file, err)
+			}
+
+			if !exists {
+				if g.skipMissingCompanions {
+					continue
+				}
+
+				return "", fmt.Errorf("%w: companion %s of file %s", ErrFileNotFound, companion, file)
+			}
+
+			cf := path.Join(dir, companion)
+			_, cfilename := path.Split(cf)
+			cstem, _, _ := strings.Cut(cfilename, ".")
+			entries.add(cstem, g.importExpr(g.importKindFor(cf), cf), false)
+		}
+
+		companions, err := g.createGlobDotImportsFrom(entries)
+		if err != nil {
+			return "", err
+		}
+
+		services.add(g.keyFor(f, path.Base(dir)), companions, false)
+	}
+
+	return g.createGlobDotImportsFrom(services)
+}
+
+// byFieldOf returns an object with the value of the keyPath field of each file
+// as key and the import of the file as value. The fields will be read from the
+// given files, while the import paths will be taken from the given import
+// paths, which must have the same order.
+func (g *GlobImporter) byFieldOf(files, importPaths []string) (string, error) {
+	keyed := newOrderedMap()
+	keyedFrom := map[string]string{}
+
+	for i, file := range files {
+		content, err := afero.ReadFile(g.fs, file)
+		if err != nil {
+			return "", fmt.Errorf("while reading file %s for the field '%s', error: %w", file, g.keyPath, err)
+		}
+
+		object := map[string]interface{}{}
+		if err := yaml.Unmarshal(content, &object); err != nil {
+			return "", fmt.Errorf("while parsing file %s as YAML object, error: %w", file, err)
+		}
+
+		key, found, err := fieldOf(object, g.keyPath)
+		if err != nil {
+			return "", fmt.Errorf("%w of file %s", err, file)
+		}
+
+		if !found {
+			if g.skipMissingField {
+				continue
+			}
+
+			return "", fmt.Errorf("%w: '%s' in file %s", ErrMissingField, g.keyPath, file)
+		}
+
+		if other, exists := keyedFrom[key]; exists && g.collision == "error" {
+			return "", fmt.Errorf("%w: the files %s and %s have the same value '%s' in the field '%s'",
+				ErrDuplicateKey, other, file, key, g.keyPath)
+		}
+
+		keyedFrom[key] = file
+
+		f := importPaths[i]
+		if ext := strings.ToLower(path.Ext(f)); ext == ".yaml" || ext == ".yml" {
+			f = yamlPrefix + "://" + f
+		}
+
+		keyed.add(key, g.importExpr("import", f), g.collision == "merge")
+	}
+
+	return g.createGlobDotImportsFrom(keyed)
+}
+
+// fieldOf returns the value of the dot separated field path inside the given
+// object as string. The second return value is false, if the field does not
+// exist. Values, which are no strings, numbers or booleans, return an error.
+func fieldOf(object map[string]interface{}, fieldPath string) (string, bool, error) {
+	var value interface{} = object
+
+	for _, field := range strings.Split(fieldPath, ".") {
+		nested, isObject := value.(map[string]interface{})
+		if !isObject {
+			return "", false, nil
+		}
+
+		if value, isObject = nested[field]; !isObject {
+			return "", false, nil
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true, nil
+	case float64:
+		// without an exponent, like `1234567` instead of `1.234567e+06`
+		return strconv.FormatFloat(v, 'f', -1, 64), true, nil
+	case bool:
+		return strconv.FormatBool(v), true, nil
+	default:
+		return "", false, fmt.Errorf("%w: '%s' has no string, number or boolean value", ErrMissingField, fieldPath)
+	}
+}
+
+// yamlStringOf parses the given files as YAML (or JSON) objects, merges their
+// top-level keys in the given order and returns the YAML serialization of the
+// result as Jsonnet string.
+func (g *GlobImporter) yamlStringOf(files []string) (string, error) {
+	merged := map[string]interface{}{}
+
+	for _, file := range files {
+		content, err := afero.ReadFile(g.fs, file)
+		if err != nil {
+			return "", fmt.Errorf("while reading file %s for the YAML serialization, error: %w", file, err)
+		}
+
+		object := map[string]interface{}{}
+		if err := yaml.Unmarshal(content, &object); err != nil {
+			return "", fmt.Errorf("while parsing file %s as YAML object, error: %w", file, err)
+		}
+
+		for key, value := range object {
+			merged[key] = value
+		}
+	}
+
+	serialized, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", fmt.Errorf("while serializing the merged files as YAML, error: %w", err)
+	}
+	// a JSON string is a valid Jsonnet string
+	literal, err := json.Marshal(string(serialized))
+	if err != nil {
+		return "", fmt.Errorf("while serializing the merged files as YAML, error: %w", err)
+	}
+
+	return string(literal), nil
+}
+
+// totalBytesOf returns the sum of the sizes of the given files. Files, which
+// cannot be found, will be ignored.
+func (g *GlobImporter) totalBytesOf(files []string) int64 {
+	var total int64
+
+	for _, file := range files {
+		if info, err := g.fs.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+
+	return total
+}
+
+// latestFileOf returns the most recently modified file of the given files. For
+// files with the same modification time the last one wins.
+func (g *GlobImporter) latestFileOf(files []string) ([]string, error) {
+	var (
+		latest   string
+		latestAt time.Time
+	)
+
+	for _, file := range files {
+		info, err := g.fs.Stat(file)
+		if err != nil {
+			return []string{}, fmt.Errorf("while reading the modification time of file %s, error: %w", file, err)
+		}
+
+		if latest == "" || !info.ModTime().Before(latestAt) {
+			latest, latestAt = file, info.ModTime()
+		}
+	}
+
+	return []string{latest}, nil
+}
+
+// removeBrokenFiles removes all files, which will be imported via `import`
+// and cannot be parsed as Jsonnet.
+func (g *GlobImporter) removeBrokenFiles(files []string, prefix, pattern string, logger *zap.Logger) ([]string, error) {
+	basePrefix, importKind, err := g.importKindOf(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if importKind == "importstr" {
+		return files, nil
+	}
+
+	// the prefixa with an import kind per file only parse the imported files
+	kindFor := func(string) string { return "import" }
+
+	switch basePrefix {
+	case "glob.auto":
+		kindFor = g.importKindFor
+	case "glob.smart", "glob.smart+":
+		kindFor = smartKindFor
+	case "glob.set", "glob.sizes", "glob.yamlstr", "glob.bykey":
+		// the files will not be imported or are no Jsonnet files
+		return files, nil
+	}
+
+	keep := []string{}
+
+	for _, file := range files {
+		if kindFor(file) != "import" {
+			keep = append(keep, file)
+
+			continue
+		}
+
+		content, err := afero.ReadFile(g.fs, file)
+		if err != nil {
+			return []string{}, fmt.Errorf("while reading file %s for the broken files check, error: %w", file, err)
+		}
+
+		if _, err := jsonnet.SnippetToAST(file, string(content)); err != nil {
+			logger.Warn("skip broken file", zap.String("file", file), zap.Error(err))
+
+			continue
+		}
+
+		keep = append(keep, file)
+	}
+
+	if len(keep) == 0 {
+		return []string{},
+			fmt.Errorf("%w, all matches for the glob pattern '%s' are broken", ErrEmptyResult, pattern)
+	}
+
+	return keep, nil
+}
+
+func (g *GlobImporter) removeExcludesFrom(files []string, pattern, excludePattern string) ([]string, error) {
+	keep := []string{}
+
+	for _, file := range files {
+		match, err := doublestar.PathMatch(excludePattern, file)
+		if err != nil {
+			return []string{}, fmt.Errorf("while remove excluded file %s ,error: %w", file, err)
+		}
+
+		if !match {
+			keep = append(keep, file)
+		}
+	}
+
+	if len(keep) == 0 && excludeShadows(excludePattern, pattern) {
+		return []string{},
+			fmt.Errorf(
+				"%w: %w, exclude pattern '%s' equals or contains the glob pattern '%s'",
+				ErrEmptyResult, ErrExcludeShadows, excludePattern, pattern)
+	}
+
+	if len(keep) == 0 {
+		return []string{},
+			fmt.Errorf(
+				"%w, exclude pattern '%s' removed all matches for the glob pattern '%s'",
+				ErrEmptyResult, excludePattern, pattern)
+	}
+
+	return keep, nil
+}
+
+// excludeShadows returns true, if the exclude pattern removes every file,
+// which the glob pattern can match: the normalized patterns are equal or the
+// exclude pattern matches every path, like `**`.
+func excludeShadows(excludePattern, pattern string) bool {
+	exclude := path.Clean(filepath.ToSlash(excludePattern))
+	include := path.Clean(filepath.ToSlash(pattern))
+
+	switch exclude {
+	case include, "**", "**/*":
+		return true
+	}
+
+	return false
+}
+
+// parse returns the prefix, the pattern and the settings of the given import
+// path. It does not change the GlobImporter.
+func (g *GlobImporter) parse(importedPath string) (string, string, importSettings, error) {
+	var (
+		prefix, pattern, rawQuery string
+		settings                  importSettings
+	)
+
+	// a depth range like `**{1,3}` is not valid inside the host part of an
+	// URL and will therefore be removed before parsing the import
+	withoutRange, depth, err := parseDepthRange(importedPath)
+	if err != nil {
+		return "", "", importSettings{}, err
+	}
+
+	settings.depth = depth
+
+	scheme, rest, found := strings.Cut(withoutRange, "://")
+
+	basePrefix, _, err := g.importKindOf(scheme)
+	if err != nil {
+		return "", "", importSettings{}, fmt.Errorf("%w inside the import '%s'", err, importedPath)
+	}
+
+	switch {
+	case found && basePrefix == "glob.first":
+		// the alternatives can contain characters, which are not allowed
+		// inside the host part of an URL
+		prefix = scheme
+		pattern, rawQuery, _ = strings.Cut(rest, "?")
+	case found && strings.HasPrefix(rest, "["):
+		// same for the brackets of a list of patterns
+		prefix = scheme
+		pattern, rawQuery, _ = strings.Cut(rest, "?")
+
+		switch basePrefix {
+		case "glob.up+", "dir", "dir+":
+			return "", "", importSettings{},
+				fmt.Errorf("%w: a list of patterns is not supported by the prefix '%s' inside the import '%s'",
+					ErrMalformedGlobPattern, prefix, importedPath)
+		}
+
+		patterns, err := splitPatternList(pattern)
+		if err != nil {
+			return "", "", importSettings{}, fmt.Errorf("%w inside the import '%s'", err, importedPath)
+		}
+
+		settings.patterns = patterns
+	default:
+		parsedURL, err := url.Parse(withoutRange)
+		if err != nil {
+			return "", "", importSettings{},
+				fmt.Errorf("%w: cannot parse import '%s', error: %w",
+					ErrMalformedGlobPattern, importedPath, err)
+		}
+
+		host := parsedURL.Host
+		// an extension group inside the first path element, like
+		// `glob+://*.@data`, will be parsed as user info
+		if user := parsedURL.User; user != nil {
+			info := user.Username()
+			if password, set := user.Password(); set {
+				info += ":" + password
+			}
+
+			host = info + "@" + host
+		}
+
+		prefix = parsedURL.Scheme
+		pattern = strings.Join([]string{host, parsedURL.Path}, "/")
+		rawQuery = parsedURL.RawQuery
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", "", importSettings{},
+			fmt.Errorf("%w: cannot parse the query inside the import '%s', error: %w",
+				ErrMalformedGlobPattern, importedPath, err)
+	}
+
+	if pattern, err = g.expandExtGroups(pattern); err != nil {
+		return "", "", importSettings{}, err
+	}
+
+	for i := range settings.patterns {
+		if settings.patterns[i], err = g.expandExtGroups(settings.patterns[i]); err != nil {
+			return "", "", importSettings{}, err
+		}
+	}
+
+	settings.importExcludePattern = query.Get("exclude")
+	settings.logLevel = query.Get("logLevel")
+
+	switch g.resolveAlias(prefix) {
+	case "dir":
+		settings.filesOnly = true
+		pattern = path.Join(pattern, "*")
+	case "dir+":
+		settings.filesOnly = true
+		if !strings.HasSuffix(path.Clean(pattern), "**") {
+			pattern = path.Join(pattern, "**")
+		}
+
+		pattern = path.Join(pattern, "*")
+	}
+
+	if minMatches := query.Get("minMatches"); minMatches != "" {
+		if settings.minMatches, err = strconv.Atoi(minMatches); err != nil || settings.minMatches < 0 {
+			return "", "", importSettings{},
+				fmt.Errorf("%w: minMatches must be a positive number inside the import '%s'",
+					ErrMalformedGlobPattern, importedPath)
+		}
+	}
+
+	if requireNonEmpty := query.Get("requireNonEmpty"); requireNonEmpty != "" {
+		if settings.requireNonEmpty, err = strconv.ParseBool(requireNonEmpty); err != nil {
+			return "", "", importSettings{},
+				fmt.Errorf("%w: requireNonEmpty must be 'true' or 'false' inside the import '%s'",
+					ErrMalformedGlobPattern, importedPath)
+		}
+	}
+
+	sortMode := query.Get("sort")
+	switch sortMode {
+	case "", "hierarchical", "lexical", "prefixnum":
+		settings.sortMode = sortMode
+	default:
+		return "", "", importSettings{},
+			fmt.Errorf("%w: unknown sort '%s' inside the import '%s', supported are 'hierarchical', 'lexical' or 'prefixnum'",
+				ErrMalformedGlobPattern, sortMode, importedPath)
+	}
+
+	settings.orderFile = query.Get("orderFile")
+
+	orderUnlisted := query.Get("orderUnlisted")
+	switch orderUnlisted {
+	case "", "append", "exclude":
+		settings.excludeUnlisted = orderUnlisted == "exclude"
+	default:
+		return "", "", importSettings{},
+			fmt.Errorf("%w: unknown orderUnlisted '%s' inside the import '%s', supported are 'append' or 'exclude'",
+				ErrMalformedGlobPattern, orderUnlisted, importedPath)
+	}
+
+	group := query.Get("group")
+	switch group {
+	case "", "dirsFirst", "filesFirst":
+		settings.group = group
+	default:
+		return "", "", importSettings{},
+			fmt.Errorf("%w: unknown group '%s' inside the import '%s', supported are 'dirsFirst' or 'filesFirst'",
+				ErrMalformedGlobPattern, group, importedPath)
+	}
+
+	settings.mapFn = query.Get("fn")
+	if settings.mapFn != "" && !isStdFunction(settings.mapFn) {
+		return "", "", importSettings{},
+			fmt.Errorf("%w: fn='%s' inside the import '%s' must be a std function like 'std.prune', "+
+				"use (import '%s://...')(<function>) for other functions",
+				ErrInvalidIdentifier, settings.mapFn, importedPath, prefix)
+	}
+
+	settings.keyField, settings.valueField = query.Get("keyField"), query.Get("valueField")
+	for param, field := range map[string]string{"keyField": settings.keyField, "valueField": settings.valueField} {
+		if id, err := toIdentifier(field); field != "" && (err != nil || id != field) {
+			return "", "", importSettings{},
+				fmt.Errorf("%w: %s='%s' inside the import '%s' must be a valid Jsonnet identifier",
+					ErrInvalidIdentifier, param, field, importedPath)
+		}
+	}
+
+	if settings.keyField != "" && settings.keyField == settings.valueField {
+		return "", "", importSettings{},
+			fmt.Errorf("%w: keyField and valueField must differ inside the import '%s'",
+				ErrInvalidIdentifier, importedPath)
+	}
+
+	settings.companions = query["companion"]
+	if g.resolveAlias(prefix) == "glob.companion" && len(settings.companions) == 0 {
+		return "", "", importSettings{},
+			fmt.Errorf("%w: the import '%s' requires at least one companion=<file>",
+				ErrMalformedGlobPattern, importedPath)
+	}
+
+	settings.keyPath = query.Get("field")
+	if g.resolveAlias(prefix) == "glob.bykey" && settings.keyPath == "" {
+		return "", "", importSettings{},
+			fmt.Errorf("%w: the import '%s' requires a field=<name>",
+				ErrMalformedGlobPattern, importedPath)
+	}
+
+	missingField := query.Get("missingField")
+	switch missingField {
+	case "", "error", "skip":
+		settings.skipMissingField = missingField == "skip"
+	default:
+		return "", "", importSettings{},
+			fmt.Errorf("%w: unknown missingField '%s' inside the import '%s', supported are 'error' or 'skip'",
+				ErrMalformedGlobPattern, missingField, importedPath)
+	}
+
+	collision := query.Get("collision")
+	switch collision {
+	case "", "error", "last", "merge":
+		settings.collision = collision
+		if collision == "" {
+			settings.collision = "error"
+		}
+	default:
+		return "", "", importSettings{},
+			fmt.Errorf("%w: unknown collision '%s' inside the import '%s', supported are 'error', 'last' or 'merge'",
+				ErrMalformedGlobPattern, collision, importedPath)
+	}
+
+	missingCompanion := query.Get("missingCompanion")
+	switch missingCompanion {
+	case "", "error", "skip":
+		settings.skipMissingCompanions = missingCompanion == "skip"
+	default:
+		return "", "", importSettings{},
+			fmt.Errorf("%w: unknown missingCompanion '%s' inside the import '%s', supported are 'error' or 'skip'",
+				ErrMalformedGlobPattern, missingCompanion, importedPath)
+	}
+
+	caseFold := query.Get("caseFold")
+	switch caseFold {
+	case "", "lower":
+		settings.lowerKeys = caseFold == "lower"
+	default:
+		return "", "", importSettings{},
+			fmt.Errorf("%w: unknown caseFold '%s' inside the import '%s', supported is 'lower'",
+				ErrMalformedGlobPattern, caseFold, importedPath)
+	}
+
+	settings.keyRepl = query.Get("keyRepl")
+
+	if keyRegex := query.Get("keyRegex"); keyRegex != "" {
+		if settings.keyRegex, err = regexp.Compile(keyRegex); err != nil {
+			return "", "", importSettings{},
+				fmt.Errorf("%w: invalid keyRegex='%s' inside the import '%s', error: %w",
+					ErrMalformedGlobPattern, keyRegex, importedPath, err)
+		}
+	} else if query.Has("keyRepl") {
+		return "", "", importSettings{},
+			fmt.Errorf("%w: keyRepl inside the import '%s' requires a keyRegex",
+				ErrMalformedGlobPattern, importedPath)
+	}
+
+	by := query.Get("by")
+	switch by {
+	case "":
+		settings.pairsBy = "stem"
+		if g.resolveAlias(prefix) == "glob.sizes" {
+			settings.pairsBy = "path"
+		}
+	case "stem", "file", "path":
+		settings.pairsBy = by
+	default:
+		return "", "", importSettings{},
+			fmt.Errorf("%w: unknown key selector by='%s' inside the import '%s', supported are 'stem', 'file' or 'path'",
+				ErrMalformedGlobPattern, by, importedPath)
+	}
+
+	return prefix, pattern, settings, nil
+}
+
+// resolveAlias returns the prefix behind the given alias or the given prefix
+// itself, if it is not an alias or part of a loop of aliases.
+func (g GlobImporter) resolveAlias(prefix string) string {
+	if p, err := g.expandAlias(prefix); err == nil {
+		return p
+	}
+
+	return prefix
+}
+
+// importKindOf expands the aliases of the given prefix and returns it without
+// the importstr variant together with the import kind, like `glob+` and
+// `importstr` for `glob-str+` or for an alias of it. The importstr variant of
+// an alias for a `glob` prefix, like `glob-str` for the alias `glob`, is
+// supported as well.
+func (g GlobImporter) importKindOf(prefix string) (string, string, error) {
+	if _, isAlias := g.aliases[prefix]; isAlias {
+		var err error
+		if prefix, err = g.expandAlias(prefix); err != nil {
+			return "", "", err
+		}
+	}
+
+	kind := "import"
+
+	if strings.HasPrefix(prefix, "glob-str") {
+		prefix = strings.Replace(prefix, "glob-str", "glob", 1)
+		kind = "importstr"
+	}
+
+	prefix, err := g.expandAlias(prefix)
+	if err != nil {
+		return "", "", err
+	}
+
+	return prefix, kind, nil
+}
+
+// expandAlias follows the chain of aliases starting at the given prefix until
+// a prefix, which is not an alias. A loop of aliases returns an
+// ErrMalformedAlias error.
+func (g GlobImporter) expandAlias(prefix string) (string, error) {
+	seen := map[string]bool{}
+
+	for {
+		p, exists := g.aliases[prefix]
+		if !exists {
+			return prefix, nil
+		}
+
+		if seen[prefix] {
+			return "", fmt.Errorf("%w: loop of aliases at '%s'", ErrMalformedAlias, prefix)
+		}
+
+		seen[prefix] = true
+		prefix = p
+	}
+}
+
+// allowedFiles removes ignoreFile from a given list of files and
+// converts the rest via filepath.FromSlash().
+// Used to remove self reference of a file to avoid endless loops.
+func allowedFiles(files []string, ignoreFile string) []string {
+	allowedFiles := []string{}
+
+	for _, file := range files {
+		if file == ignoreFile {
+			continue
+		}
+
+		importPath := filepath.FromSlash(file)
+		allowedFiles = append(allowedFiles, importPath)
+	}
+
+	return allowedFiles
+}
+
+// handle runs the logic behind the different glob prefixa and returns based on
+// the prefix the import string.
+func (g GlobImporter) handle(files []string, prefix string) (string, error) {
+	resolvedFiles := newOrderedMap()
+
+	// the generated import paths must be identical on all OSes
+	slashedFiles := make([]string, 0, len(files))
+	for _, f := range files {
+		slashedFiles = append(slashedFiles, filepath.ToSlash(f))
+	}
+
+	files = slashedFiles
+
+	// handle import or importstr
+	prefix, importKind, err := g.importKindOf(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	switch prefix {
+	case "glob+", "glob.first", "glob.latest", "glob.up+":
+		imports := make([]string, 0, len(files))
+
+		for _, f := range files {
+			i := g.importExpr(importKind, f)
+			imports = append(imports, i)
+		}
+
+		return g.joinImports(imports), nil
+	case "glob.fold":
+		// the same order as for glob+, but without merging
+		imports := make([]string, 0, len(files))
+
+		for _, f := range files {
+			imports = append(imports, g.importExpr(importKind, f))
+		}
+
+		return g.block("[", "]", imports), nil
+	case "glob.yaml+":
+		imports := make([]string, 0, len(files))
+
+		for _, f := range files {
+			imports = append(imports, g.importExpr(importKind, yamlPrefix+"://"+f))
+		}
+
+		return g.joinImports(imports), nil
+	case "glob.manifest":
+		imports := make([]string, 0, len(files))
+		sources := make([]string, 0, len(files))
+
+		for _, f := range files {
+			imports = append(imports, g.importExpr(importKind, f))
+			sources = append(sources, fmt.Sprintf("'%s'", f))
+		}
+
+		return g.block("{", "}", []string{
+			"result: " + g.joinImports(imports),
+			fmt.Sprintf("sources: [%s]", strings.Join(sources, ", ")),
+		}), nil
+	case "glob.pairs":
+		return g.createGlobPairsFrom(files, importKind, "key", "value"), nil
+	case "glob.kv":
+		return g.createGlobPairsFrom(files, importKind, "k", "v"), nil
+	case "glob.set":
+		for _, f := range files {
+			// the values are null on purpose; only the keys are of interest
+			resolvedFiles.add(g.keyFor(f, g.keyBy(f)), "null", false)
+		}
+	case "glob.map":
+		return g.createGlobMapFrom(files, importKind), nil
+	case "glob.both":
+		byStem, byPath := newOrderedMap(), newOrderedMap()
+
+		for _, f := range files {
+			i := g.importExpr(importKind, f)
+			_, filename := path.Split(f)
+			stem, _, _ := strings.Cut(filename, ".")
+			byStem.add(g.keyFor(f, stem), i, false)
+			// the paths stay untouched by keyFunc, keyRegex and caseFold
+			byPath.add(f, i, false)
+		}
+
+		stems, err := g.createGlobDotImportsFrom(byStem)
+		if err != nil {
+			return "", err
+		}
+
+		paths, err := g.createGlobDotImportsFrom(byPath)
+		if err != nil {
+			return "", err
+		}
+
+		return g.block("{", "}", []string{"byStem: " + stems, "byPath: " + paths}), nil
+	case "glob.path", "glob.path+":
+		for _, f := range files {
+			i := g.importExpr(importKind, f)
+			resolvedFiles.add(g.keyFor(f, f), i, strings.HasSuffix(prefix, "+"))
+		}
+	case "glob.auto":
+		for _, f := range files {
+			i := g.importExpr(g.importKindFor(f), f)
+			resolvedFiles.add(g.keyFor(f, f), i, false)
+		}
+	case "glob.smart", "glob.smart+":
+		for _, f := range files {
+			i := g.importExpr(smartKindFor(f), f)
+			resolvedFiles.add(g.keyFor(f, g.keyBy(f)), i, strings.HasSuffix(prefix, "+"))
+		}
+	case "glob.locals":
+		return g.createGlobLocalsImportsFrom(files, importKind)
+	case "glob.lazy":
+		for _, f := range files {
+			i := fmt.Sprintf("function() %s", g.importExpr(importKind, f))
+			_, filename := path.Split(f)
+			stem, _, _ := strings.Cut(filename, ".")
+			resolvedFiles.add(g.keyFor(f, stem), i, false)
+		}
+	case "glob.stem", "glob.stem+":
+		for _, f := range files {
+			i := g.importExpr(importKind, f)
+			_, filename := path.Split(f)
+			stem, _, _ := strings.Cut(filename, ".")
+			resolvedFiles.add(g.keyFor(f, stem), i, strings.HasSuffix(prefix, "+"))
+		}
+	case "glob.file", "glob.file+", "dir", "dir+":
+		for _, f := range files {
+			i := g.importExpr(importKind, f)
+			_, filename := path.Split(f)
+			resolvedFiles.add(g.keyFor(f, filename), i, strings.HasSuffix(prefix, "+"))
+		}
+	case "glob.dir", "glob.dir+":
+		for _, f := range files {
+			i := g.importExpr(importKind, f)
+			dir, _ := path.Split(f)
+			resolvedFiles.add(g.keyFor(f, dir), i, strings.HasSuffix(prefix, "+"))
+		}
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownPrefix, prefix)
+	}
+
+	return g.createGlobDotImportsFrom(resolvedFiles)
+}
+
+// importExpr returns the import expression `(<importKind> '<file>')`. With
+// annotations enabled, a comment with the source file will be added on its
+// own line in front of the expression.
+func (g GlobImporter) importExpr(importKind, file string) string {
+	if g.annotate && g.format == "compact" {
+		return fmt.Sprintf("/* from %s */ (%s '%s')", file, importKind, file)
+	}
+
+	if g.annotate {
+		return fmt.Sprintf("\n// from %s\n(%s '%s')", file, importKind, file)
+	}
+
+	return fmt.Sprintf("(%s '%s')", importKind, file)
+}
+
+// jsonnetString returns the given value as single quoted Jsonnet string
+// literal. Quotes, backslashes and control characters will be escaped, so
+// that keys read from files or derived via the query cannot break out of the
+// generated code.
+func jsonnetString(value string) string {
+	var out strings.Builder
+
+	out.WriteByte('\'')
+
+	for _, r := range value {
+		switch r {
+		case '\'':
+			out.WriteString(`\'`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&out, `\u%04x`, r)
+
+				continue
+			}
+
+			out.WriteRune(r)
+		}
+	}
+
+	out.WriteByte('\'')
+
+	return out.String()
+}
+
+// joinImports merges the import expressions via '+'.
+func (g GlobImporter) joinImports(imports []string) string {
+	if g.format == "pretty" {
+		return strings.Join(imports, " + ")
+	}
+
+	return strings.Join(imports, "+")
+}
+
+// block returns the entries between the opening and closing bracket in the
+// layout selected via SetFormat. By default each entry is on its own line.
+func (g GlobImporter) block(opening, closing string, entries []string) string {
+	if g.format == "compact" {
+		return opening + strings.Join(entries, ", ") + closing
+	}
+
+	indent := ""
+	if g.format == "pretty" {
+		indent = "  "
+	}
+
+	var out strings.Builder
+
+	out.WriteString(opening + "\n")
+
+	for _, entry := range entries {
+		fmt.Fprintf(&out, "%s%s,\n", indent, entry)
+	}
+
+	out.WriteString(closing)
 
 	return out.String()
 }
+
+// createGlobDotImportsFrom transforms the orderedMap of resolvedFiles
+// into the format `{ '<?>': import '...' }`.
+func (g GlobImporter) createGlobDotImportsFrom(resolvedFiles *orderedMap) (string, error) {
+	entries := make([]string, 0, len(resolvedFiles.keys))
+	// identifiers maps each identifier to its original key
+	identifiers := map[string]string{}
+
+	for _, k := range resolvedFiles.keys {
+		key := k
+
+		if g.identifierKeys {
+			id, err := toIdentifier(k)
+			if err != nil {
+				return "", fmt.Errorf("while converting the key '%s' into an identifier, error: %w", k, err)
+			}
+
+			if other, exists := identifiers[id]; exists {
+				return "", fmt.Errorf("%w: the keys '%s' and '%s' become the same identifier '%s'",
+					ErrDuplicateKey, other, k, id)
+			}
+
+			identifiers[id] = k
+			key = id
+		}
+
+		entries = append(entries, fmt.Sprintf("%s: %s", jsonnetString(key), g.joinImports(resolvedFiles.items[k])))
+	}
+
+	return g.block("{", "}", entries), nil
+}
+
+// createGlobPairsFrom transforms the files into the format
+// `[{<keyField>: '<key>', <valueField>: import '...'}]`, whereby the key
+// depends on pairsBy. The given field names will be used, if the import sets
+// no `?keyField=` or `?valueField=`.
+func (g GlobImporter) createGlobPairsFrom(files []string, importKind, keyField, valueField string) string {
+	if g.keyField != "" {
+		keyField = g.keyField
+	}
+
+	if g.valueField != "" {
+		valueField = g.valueField
+	}
+
+	entries := make([]string, 0, len(files))
+
+	for _, f := range files {
+		entries = append(entries, fmt.Sprintf("{%s: %s, %s: %s}",
+			keyField, jsonnetString(g.keyFor(f, g.keyBy(f))), valueField, g.importExpr(importKind, f)))
+	}
+
+	return g.block("[", "]", entries)
+}
+
+// keyBy returns the stem, the filename or the path of the file selected via
+// the `?by=` query parameter (pairsBy).
+func (g GlobImporter) keyBy(file string) string {
+	_, filename := path.Split(file)
+
+	switch g.pairsBy {
+	case "file":
+		return filename
+	case "path":
+		return file
+	default:
+		stem, _, _ := strings.Cut(filename, ".")
+
+		return stem
+	}
+}
+
+// createGlobMapFrom transforms the files into the format
+// `[ <fn>(import '...') ]`, whereby <fn> is either the mapFn or the parameter
+// of the returned function, if no mapFn was given.
+func (g GlobImporter) createGlobMapFrom(files []string, importKind string) string {
+	fn := g.mapFn
+	if fn == "" {
+		fn = "fn"
+	}
+
+	entries := make([]string, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, fmt.Sprintf("%s(%s)", fn, g.importExpr(importKind, f)))
+	}
+
+	if g.mapFn == "" {
+		return "function(fn) " + g.block("[", "]", entries)
+	}
+
+	return g.block("[", "]", entries)
+}
+
+// createGlobLocalsImportsFrom binds each file to a local variable named after
+// its stem (or the key of the custom key function) and returns the format `local <id> = import '...'; { <id>: <id> }`.
+// Stems, which cannot be converted into a valid identifier or which end up in
+// the same identifier, will cause an error.
+func (g GlobImporter) createGlobLocalsImportsFrom(files []string, importKind string) (string, error) {
+	var locals strings.Builder
+
+	fields := make([]string, 0, len(files))
+	seen := map[string]string{}
+
+	// the locals are separated like the entries of the object
+	separator := "\n"
+	if g.format == "compact" {
+		separator = " "
+	}
+
+	for _, f := range files {
+		_, filename := path.Split(f)
+		stem, _, _ := strings.Cut(filename, ".")
+
+		id, err := toIdentifier(g.keyFor(f, stem))
+		if err != nil {
+			return "", fmt.Errorf("for file '%s': %w", f, err)
+		}
+
+		if other, exists := seen[id]; exists {
+			return "", fmt.Errorf("%w: '%s' and '%s' both result in '%s'", ErrDuplicateKey, other, f, id)
+		}
+
+		seen[id] = f
+
+		fmt.Fprintf(&locals, "local %s = %s;%s", id, g.importExpr(importKind, f), separator)
+		fields = append(fields, fmt.Sprintf("%s: %s", id, id))
+	}
+
+	return locals.String() + g.block("{", "}", fields), nil
+}
+
+// jsonnetKeywords cannot be used as identifiers.
+var jsonnetKeywords = map[string]bool{
+	"assert": true, "else": true, "error": true, "false": true, "for": true,
+	"function": true, "if": true, "import": true, "importstr": true,
+	"importbin": true, "in": true, "local": true, "null": true,
+	"tailstrict": true, "then": true, "self": true, "super": true, "true": true,
+}
+
+// isStdFunction reports whether the name looks like a function of the std
+// library, like `std.prune`.
+func isStdFunction(name string) bool {
+	fn, found := strings.CutPrefix(name, "std.")
+	if !found {
+		return false
+	}
+
+	id, err := toIdentifier(fn)
+
+	return err == nil && id == fn
+}
+
+// toIdentifier converts a name into a valid Jsonnet identifier by replacing
+// all unsupported characters with '_' and by adding a '_' in front of a
+// leading digit. Empty names and keywords return an error.
+func toIdentifier(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%w: empty name", ErrInvalidIdentifier)
+	}
+
+	if jsonnetKeywords[name] {
+		return "", fmt.Errorf("%w: '%s' is a keyword", ErrInvalidIdentifier, name)
+	}
+
+	id := []rune(name)
+	for i, r := range id {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+		if !isLetter && !(r >= '0' && r <= '9') {
+			id[i] = '_'
+		}
+	}
+
+	if id[0] >= '0' && id[0] <= '9' {
+		return "_" + string(id), nil
+	}
+
+	return string(id), nil
+}
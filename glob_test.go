@@ -1,21 +1,39 @@
 package importer
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-jsonnet"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestGlobImporter_resolveFilesFrom(t *testing.T) {
 	type fields struct {
-		excludePattern string
-		testFolders    []string
-		testFiles      map[string]string
+		excludePattern  string
+		group           string
+		sortMode        string
+		minMatches      int
+		jpathPriorities map[string]int
+		contentFilter   func(path string, content []byte) bool
+		detectDuplicate bool
+		testFolders     []string
+		testFiles       map[string]string
 	}
 	type args struct {
 		searchPaths []string
@@ -134,11 +152,304 @@ func TestGlobImporter_resolveFilesFrom(t *testing.T) {
 			want:    []string{"vendor/models/b.jsonnet", "models/a.jsonnet"},
 			wantErr: false,
 		},
+		{
+			name: "jpath priorities - higher priority comes later, cwd has priority 0",
+			fields: fields{
+				jpathPriorities: map[string]int{"vendor": 1, "base": -1, "lib": 0},
+				testFolders:     []string{"vendor/models", "base/models", "lib/models", "models"},
+				testFiles: map[string]string{
+					"models/a.jsonnet":        "{a: 1}",
+					"vendor/models/a.jsonnet": "{a: 2}",
+					"base/models/a.jsonnet":   "{a: 3}",
+					"lib/models/a.jsonnet":    "{a: 4}",
+				},
+			},
+			args: args{
+				searchPaths: []string{"vendor", "base", "lib"},
+				cwd:         ".",
+				pattern:     "models/*.jsonnet",
+			},
+			want: []string{
+				"base/models/a.jsonnet", "lib/models/a.jsonnet", "models/a.jsonnet", "vendor/models/a.jsonnet",
+			},
+			wantErr: false,
+		},
+		{
+			name: "content filter removes files without marker",
+			fields: fields{
+				contentFilter: func(_ string, content []byte) bool {
+					return strings.Contains(string(content), "kind:")
+				},
+				testFolders: []string{"vendor"},
+				testFiles: map[string]string{
+					"vendor/a.libsonnet": "{kind: 'a'}",
+					"vendor/b.libsonnet": "{b: 2}",
+				},
+			},
+			args: args{
+				searchPaths: []string{"vendor"},
+				pattern:     "*.libsonnet",
+			},
+			want:    []string{"vendor/a.libsonnet"},
+			wantErr: false,
+		},
+		{
+			name: "content filter removes all files - should return empty result error",
+			fields: fields{
+				contentFilter: func(_ string, _ []byte) bool { return false },
+				testFolders:   []string{"vendor"},
+				testFiles: map[string]string{
+					"vendor/a.libsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				searchPaths: []string{"vendor"},
+				pattern:     "*.libsonnet",
+			},
+			want:    []string{},
+			wantErr: true,
+		},
+		{
+			name: "detect duplicate content without duplicates",
+			fields: fields{
+				detectDuplicate: true,
+				testFolders:     []string{"vendor"},
+				testFiles: map[string]string{
+					"vendor/a.libsonnet": "{a: 1}",
+					"vendor/b.libsonnet": "{b: 2}",
+				},
+			},
+			args: args{
+				searchPaths: []string{"vendor"},
+				pattern:     "*.libsonnet",
+			},
+			want:    []string{"vendor/a.libsonnet", "vendor/b.libsonnet"},
+			wantErr: false,
+		},
+		{
+			name: "detect duplicate content with a jpath equal to the cwd",
+			fields: fields{
+				detectDuplicate: true,
+				testFolders:     []string{"vendor"},
+				testFiles: map[string]string{
+					"vendor/a.libsonnet": "{a: 1}",
+					"vendor/b.libsonnet": "{b: 2}",
+				},
+			},
+			args: args{
+				searchPaths: []string{"vendor"},
+				cwd:         "vendor/",
+				pattern:     "*.libsonnet",
+			},
+			want: []string{
+				"vendor/a.libsonnet", "vendor/b.libsonnet",
+				"vendor/a.libsonnet", "vendor/b.libsonnet",
+			},
+			wantErr: false,
+		},
+		{
+			name: "detect duplicate content - should return error",
+			fields: fields{
+				detectDuplicate: true,
+				testFolders:     []string{"vendor/sub"},
+				testFiles: map[string]string{
+					"vendor/a.libsonnet":     "{a: 1}",
+					"vendor/sub/c.libsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				searchPaths: []string{"vendor"},
+				pattern:     "**/*.libsonnet",
+			},
+			want:    []string{},
+			wantErr: true,
+		},
+		{
+			name: "sort hierarchical - directory content before files with the same name prefix",
+			fields: fields{
+				sortMode:    "hierarchical",
+				testFolders: []string{"libs/a"},
+				testFiles: map[string]string{
+					"libs/a.libsonnet":   "{a: 1}",
+					"libs/a/b.libsonnet": "{b: 2}",
+				},
+			},
+			args: args{
+				cwd:     ".",
+				pattern: "libs/**/*.libsonnet",
+			},
+			want:    []string{"libs/a/b.libsonnet", "libs/a.libsonnet"},
+			wantErr: false,
+		},
+		{
+			name: "sort lexical - raw paths byte by byte",
+			fields: fields{
+				sortMode:    "lexical",
+				testFolders: []string{"libs/a"},
+				testFiles: map[string]string{
+					"libs/a.libsonnet":   "{a: 1}",
+					"libs/a/b.libsonnet": "{b: 2}",
+				},
+			},
+			args: args{
+				cwd:     ".",
+				pattern: "libs/**/*.libsonnet",
+			},
+			want:    []string{"libs/a.libsonnet", "libs/a/b.libsonnet"},
+			wantErr: false,
+		},
+		{
+			name: "sort prefixnum - unpadded numbers and files without number",
+			fields: fields{
+				sortMode:    "prefixnum",
+				testFolders: []string{"conf.d"},
+				testFiles: map[string]string{
+					"conf.d/10-overrides.libsonnet": "{a: 3}",
+					"conf.d/2-defaults.libsonnet":   "{a: 2}",
+					"conf.d/00-base.libsonnet":      "{a: 1}",
+					"conf.d/local.libsonnet":        "{a: 4}",
+				},
+			},
+			args: args{
+				cwd:     ".",
+				pattern: "conf.d/*.libsonnet",
+			},
+			want: []string{
+				"conf.d/00-base.libsonnet", "conf.d/2-defaults.libsonnet",
+				"conf.d/10-overrides.libsonnet", "conf.d/local.libsonnet",
+			},
+			wantErr: false,
+		},
+		{
+			name: "minMatches reached",
+			fields: fields{
+				minMatches:  2,
+				testFolders: []string{"vendor"},
+				testFiles: map[string]string{
+					"vendor/a.libsonnet": "{a: 1}",
+					"vendor/b.libsonnet": "{b: 2}",
+				},
+			},
+			args: args{
+				searchPaths: []string{"vendor"},
+				pattern:     "*.libsonnet",
+			},
+			want:    []string{"vendor/a.libsonnet", "vendor/b.libsonnet"},
+			wantErr: false,
+		},
+		{
+			name: "minMatches not reached after exclude - should return error",
+			fields: fields{
+				excludePattern: "**/b.libsonnet",
+				minMatches:     2,
+				testFolders:    []string{"vendor"},
+				testFiles: map[string]string{
+					"vendor/a.libsonnet": "{a: 1}",
+					"vendor/b.libsonnet": "{b: 2}",
+				},
+			},
+			args: args{
+				searchPaths: []string{"vendor"},
+				pattern:     "*.libsonnet",
+			},
+			want:    []string{},
+			wantErr: true,
+		},
+		{
+			name: "group filesFirst - files directly matched by the pattern are first",
+			fields: fields{
+				group:       "filesFirst",
+				testFolders: []string{"models/a"},
+				testFiles: map[string]string{
+					"models/a/x.jsonnet": "{a: 1}",
+					"models/b.jsonnet":   "{b: 2}",
+					"models/c.jsonnet":   "{c: 3}",
+				},
+			},
+			args: args{
+				cwd:     ".",
+				pattern: "models/**/*.jsonnet",
+			},
+			want:    []string{"models/b.jsonnet", "models/c.jsonnet", "models/a/x.jsonnet"},
+			wantErr: false,
+		},
+		{
+			name: "group dirsFirst - files from sub folders are first",
+			fields: fields{
+				group:       "dirsFirst",
+				testFolders: []string{"models/a", "models/d"},
+				testFiles: map[string]string{
+					"models/a/x.jsonnet": "{a: 1}",
+					"models/b.jsonnet":   "{b: 2}",
+					"models/d/y.jsonnet": "{d: 3}",
+				},
+			},
+			args: args{
+				cwd:     ".",
+				pattern: "models/**/*.jsonnet",
+			},
+			want:    []string{"models/a/x.jsonnet", "models/d/y.jsonnet", "models/b.jsonnet"},
+			wantErr: false,
+		},
+		{
+			name: "two double stars - matches at any depth around the static segment",
+			fields: fields{
+				testFiles: map[string]string{
+					"configs/a.libsonnet":              "{}",
+					"configs/x/b.libsonnet":            "{}",
+					"team/configs/c.libsonnet":         "{}",
+					"team/sub/configs/y/d.libsonnet":   "{}",
+					"team/configs.libsonnet":           "{}",
+					"team/other/e.libsonnet":           "{}",
+					"configs/configs/f.libsonnet":      "{}",
+					"team/configs/z/configs/g.jsonnet": "{}",
+				},
+			},
+			args: args{
+				cwd:     ".",
+				pattern: "**/configs/**/*.libsonnet",
+			},
+			want: []string{
+				"configs/a.libsonnet",
+				"configs/configs/f.libsonnet",
+				"configs/x/b.libsonnet",
+				"team/configs/c.libsonnet",
+				"team/sub/configs/y/d.libsonnet",
+			},
+			wantErr: false,
+		},
+		{
+			name: "two double stars in a jpath - no duplicates for nested static segments",
+			fields: fields{
+				testFiles: map[string]string{
+					"vendor/configs/configs/a.libsonnet":     "{}",
+					"vendor/lib/configs/sub/b.libsonnet":     "{}",
+					"vendor/lib/configs/configs/c.libsonnet": "{}",
+				},
+			},
+			args: args{
+				searchPaths: []string{"vendor"},
+				cwd:         "app",
+				pattern:     "**/configs/**/*.libsonnet",
+			},
+			want: []string{
+				"vendor/configs/configs/a.libsonnet",
+				"vendor/lib/configs/configs/c.libsonnet",
+				"vendor/lib/configs/sub/b.libsonnet",
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewGlobImporter()
 			g.excludePattern = tt.fields.excludePattern
+			g.group = tt.fields.group
+			g.sortMode = tt.fields.sortMode
+			g.minMatches = tt.fields.minMatches
+			g.jpathPriorities = tt.fields.jpathPriorities
+			g.SetContentFilter(tt.fields.contentFilter)
+			g.DetectDuplicateContent(tt.fields.detectDuplicate)
 
 			fs := afero.NewMemMapFs()
 			for _, tF := range tt.fields.testFolders {
@@ -165,6 +476,44 @@ func TestGlobImporter_resolveFilesFrom(t *testing.T) {
 	}
 }
 
+func TestGlobImporter_resolveFilesFromDeterministic(t *testing.T) {
+	files := []string{
+		"team/sub/configs/y/d.libsonnet",
+		"configs/x/b.libsonnet",
+		"team/configs/c.libsonnet",
+		"configs/configs/f.libsonnet",
+		"configs/a.libsonnet",
+	}
+	want := []string{}
+
+	// the result must not depend on the order, in which the files were created
+	for run := 0; run < len(files); run++ {
+		fs := afero.NewMemMapFs()
+		for i := range files {
+			if err := afero.WriteFile(fs, files[(i+run)%len(files)], []byte("{}"), 0o644); err != nil {
+				t.Errorf("afero.WriteFile() error = %v", err)
+				return
+			}
+		}
+
+		g := NewGlobImporter()
+		g.fs = fs
+
+		got, err := g.resolveFilesFrom([]string{}, ".", "**/configs/**/*.libsonnet")
+		if err != nil {
+			t.Errorf("GlobImporter.resolveFilesFrom() error = %v", err)
+			return
+		}
+
+		if run == 0 {
+			want = got
+			continue
+		}
+		assert.Equal(t, want, got)
+	}
+	assert.Len(t, want, len(files))
+}
+
 func TestGlobImporter_Import(t *testing.T) {
 	lvl := zap.NewAtomicLevel()
 	cfg := zap.NewDevelopmentEncoderConfig()
@@ -286,6 +635,22 @@ func TestGlobImporter_Import(t *testing.T) {
 			want:        jsonnet.MakeContents("(import 'a.jsonnet')+(import 'a.jsonnet')"),
 			wantFoundAt: "./",
 		},
+		{
+			name:   "duplicate jpaths are normalized - imports only once",
+			jpaths: []string{"vendor", "./vendor", "vendor/"},
+			fields: fields{
+				testFolders: []string{"vendor"},
+				testFiles: map[string]string{
+					"vendor/a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob+://*.jsonnet",
+			},
+			want:        jsonnet.MakeContents("(import 'vendor/a.jsonnet')"),
+			wantFoundAt: "./",
+		},
 		{
 			name:   "two jpath set and contents are merged",
 			jpaths: []string{"vendor/a", "vendor/b"},
@@ -305,103 +670,4101 @@ func TestGlobImporter_Import(t *testing.T) {
 			),
 			wantFoundAt: "./",
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			g := NewGlobImporter(tt.jpaths...)
-			g.Logger(logger)
-
-			fs := afero.NewMemMapFs()
-			for _, tF := range tt.fields.testFolders {
-				if err := fs.MkdirAll(tF, 0o755); err != nil {
-					t.Errorf("GlobImporter.Import() error = %v", err)
-					return
-				}
-			}
-			for file, cnt := range tt.fields.testFiles {
-				if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
-					t.Errorf("GlobImporter.Import() error = %v", err)
-					return
-				}
-			}
-			g.fs = fs
-
-			got, gotFoundAt, err := g.Import(tt.args.importedFrom, tt.args.importedPath)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GlobImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-
-			assert.Equal(t, tt.want, got)
-			assert.Equal(t, tt.wantFoundAt, gotFoundAt)
-		})
-	}
-}
-
-func TestGlobImporter_handle(t *testing.T) {
-	type fields struct {
-		aliases map[string]string
-	}
-	type args struct {
-		files  []string
-		prefix string
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    string
-		wantErr bool
-	}{
 		{
-			name: "glob-str+",
+			name:   "dir prefix - only files directly inside the directory",
+			jpaths: []string{},
+			fields: fields{
+				testFolders: []string{"config/sub"},
+				testFiles: map[string]string{
+					"config/a.json":     "{a: 1}",
+					"config/sub/a.json": "{a: 2}",
+				},
+			},
 			args: args{
-				files:  []string{"a.jsonnet", "b.jsonnet"},
-				prefix: "glob-str+",
+				importedFrom: "",
+				importedPath: "dir://config/",
 			},
-			want:    `(importstr 'a.jsonnet')+(importstr 'b.jsonnet')`,
-			wantErr: false,
+			want:        jsonnet.MakeContents("{\n'a.json': (import 'config/a.json'),\n}"),
+			wantFoundAt: "./",
 		},
 		{
-			name: "glob+",
+			name:   "dir+ prefix - files of sub folders are merged",
+			jpaths: []string{},
+			fields: fields{
+				testFolders: []string{"config/sub"},
+				testFiles: map[string]string{
+					"config/a.json":     "{a: 1}",
+					"config/sub/a.json": "{a: 2}",
+					"config/sub/b.json": "{b: 2}",
+				},
+			},
 			args: args{
-				files:  []string{"a.jsonnet", "b.jsonnet"},
-				prefix: "glob+",
+				importedFrom: "",
+				importedPath: "dir+://config",
 			},
-			want:    `(import 'a.jsonnet')+(import 'b.jsonnet')`,
+			want: jsonnet.MakeContents(
+				"{\n'a.json': (import 'config/a.json')+(import 'config/sub/a.json'),\n'b.json': (import 'config/sub/b.json'),\n}",
+			),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.first - first alternative with results wins",
+			jpaths: []string{},
+			fields: fields{
+				testFolders: []string{"base", "defaults"},
+				testFiles: map[string]string{
+					"base/config.libsonnet":     "{a: 1}",
+					"defaults/a.libsonnet":      "{a: 2}",
+					"defaults/config.libsonnet": "{a: 3}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.first://prod/config.libsonnet | base/config.libsonnet | defaults/*.libsonnet",
+			},
+			want:        jsonnet.MakeContents("(import 'base/config.libsonnet')"),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.first - all alternatives are empty - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.first://prod/*.libsonnet|base/*.libsonnet",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+		{
+			name:   "malformed minMatches - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob+://*.jsonnet?minMatches=-1",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+		{
+			name:   "unknown group - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob+://*.jsonnet?group=unknown",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+		{
+			name:   "unknown sort - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob+://*.jsonnet?sort=unknown",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+		{
+			name:   "glob.pairs by file",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.pairs://*.jsonnet?by=file",
+			},
+			want:        jsonnet.MakeContents("[\n{key: 'a.jsonnet', value: (import 'a.jsonnet')},\n]"),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.kv with custom fields",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.kv://*.jsonnet?keyField=name&valueField=config",
+			},
+			want:        jsonnet.MakeContents("[\n{name: 'a', config: (import 'a.jsonnet')},\n]"),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.kv with invalid field name - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.kv://*.jsonnet?keyField=my-key",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+		{
+			name:   "glob.kv with identical field names - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.kv://*.jsonnet?keyField=x&valueField=x",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+		{
+			name:   "glob.path with keyRegex",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"k8s/app.yaml": "a: 1",
+					"k8s/db.yaml":  "b: 2",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: `glob.path://k8s/*.yaml?keyRegex=^k8s/(.*)\.yaml$&keyRepl=$1`,
+			},
+			want:        jsonnet.MakeContents("{\n'app': (import 'k8s/app.yaml'),\n'db': (import 'k8s/db.yaml'),\n}"),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.path with quotes in the keyRepl",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"k8s/app.yaml": "a: 1",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: `glob.path://k8s/*.yaml?keyRegex=^k8s/(.*)\.yaml$&keyRepl=${1}':error'x`,
+			},
+			want:        jsonnet.MakeContents("{\n'app\\':error\\'x': (import 'k8s/app.yaml'),\n}"),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.pairs with quotes in the keyRepl",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"k8s/app.yaml": "a: 1",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: `glob.pairs://k8s/*.yaml?keyRegex=^k8s/(.*)\.yaml$&keyRepl=${1}'`,
+			},
+			want:        jsonnet.MakeContents("[\n{key: 'app\\'', value: (import 'k8s/app.yaml')},\n]"),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.path+ with colliding keys of the keyRegex",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"k8s/app.yaml":     "a: 1",
+					"k8s/sub/app.yaml": "b: 2",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.path+://k8s/**/*.yaml?keyRegex=^.*/&keyRepl=",
+			},
+			want:        jsonnet.MakeContents("{\n'app.yaml': (import 'k8s/app.yaml')+(import 'k8s/sub/app.yaml'),\n}"),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.path with invalid keyRegex - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"k8s/app.yaml": "a: 1",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.path://k8s/*.yaml?keyRegex=(.*",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+		{
+			name:   "glob.path with keyRepl but without keyRegex - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"k8s/app.yaml": "a: 1",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.path://k8s/*.yaml?keyRepl=$1",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+		{
+			name:   "glob.stem with caseFold=lower keeps the import paths",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"hosts/Host.libsonnet": "{a: 1}",
+					"hosts/db.libsonnet":   "{b: 2}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.stem://hosts/*.libsonnet?caseFold=lower",
+			},
+			want:        jsonnet.MakeContents("{\n'host': (import 'hosts/Host.libsonnet'),\n'db': (import 'hosts/db.libsonnet'),\n}"),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.stem+ with colliding keys of caseFold=lower",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a/Host.libsonnet": "{a: 1}",
+					"b/host.libsonnet": "{b: 2}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.stem+://*/*.libsonnet?caseFold=lower",
+			},
+			want:        jsonnet.MakeContents("{\n'host': (import 'a/Host.libsonnet')+(import 'b/host.libsonnet'),\n}"),
+			wantFoundAt: "./",
+		},
+		{
+			name:   "glob.stem with unknown caseFold - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"Host.libsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.stem://*.libsonnet?caseFold=upper",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+		{
+			name:   "glob.pairs with unknown key selector - should return error",
+			jpaths: []string{},
+			fields: fields{
+				testFiles: map[string]string{
+					"a.jsonnet": "{a: 1}",
+				},
+			},
+			args: args{
+				importedFrom: "",
+				importedPath: "glob.pairs://*.jsonnet?by=dir",
+			},
+			want:        jsonnet.MakeContents(""),
+			wantFoundAt: "./",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter(tt.jpaths...)
+			g.Logger(logger)
+
+			fs := afero.NewMemMapFs()
+			for _, tF := range tt.fields.testFolders {
+				if err := fs.MkdirAll(tF, 0o755); err != nil {
+					t.Errorf("GlobImporter.Import() error = %v", err)
+					return
+				}
+			}
+			for file, cnt := range tt.fields.testFiles {
+				if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+					t.Errorf("GlobImporter.Import() error = %v", err)
+					return
+				}
+			}
+			g.fs = fs
+
+			got, gotFoundAt, err := g.Import(tt.args.importedFrom, tt.args.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantFoundAt, gotFoundAt)
+		})
+	}
+}
+
+func TestGlobImporter_Resolve(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"models/a.libsonnet":     "{a: 1}",
+		"models/sub/b.libsonnet": "{b: 2}",
+		"main.jsonnet":           "{}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedFrom string
+		importedPath string
+		want         GlobResult
+		wantErr      bool
+	}{
+		{
+			name:         "glob.stem",
+			importedFrom: "main.jsonnet",
+			importedPath: "glob.stem://models/**/*.libsonnet",
+			want: GlobResult{
+				Prefix:  "glob.stem",
+				Files:   []string{"models/a.libsonnet", "models/sub/b.libsonnet"},
+				Snippet: "{\n'a': (import 'models/a.libsonnet'),\n'b': (import 'models/sub/b.libsonnet'),\n}",
+			},
+		},
+		{
+			name:         "relative to the importing file",
+			importedFrom: "models/main.jsonnet",
+			importedPath: "glob+://sub/*.libsonnet",
+			want: GlobResult{
+				Prefix:  "glob+",
+				Files:   []string{"sub/b.libsonnet"},
+				Snippet: "(import 'sub/b.libsonnet')",
+			},
+		},
+		{
+			name:         "empty result - should return error",
+			importedFrom: "main.jsonnet",
+			importedPath: "glob+://missing/*.libsonnet",
+			want:         GlobResult{},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, err := g.Resolve(tt.importedFrom, tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGlobImporter_SetFilesystems(t *testing.T) {
+	newFs := func(testFiles map[string]string) afero.Fs {
+		fs := afero.NewMemMapFs()
+		for file, cnt := range testFiles {
+			if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+				t.Fatalf("afero.WriteFile() error = %v", err)
+			}
+		}
+		return fs
+	}
+	embedded := newFs(map[string]string{
+		"config/a.libsonnet": "{a: 'embedded'}",
+		"config/b.libsonnet": "{b: 'embedded'}",
+	})
+	disk := newFs(map[string]string{
+		"config/b.libsonnet": "{b: 'disk'}",
+		"config/c.libsonnet": "{c: 'disk'}",
+	})
+
+	tests := []struct {
+		name        string
+		fss         []afero.Fs
+		want        []string
+		wantContent string
+	}{
+		{
+			name:        "single filesystem",
+			fss:         []afero.Fs{embedded},
+			want:        []string{"config/a.libsonnet", "config/b.libsonnet"},
+			wantContent: "{b: 'embedded'}",
+		},
+		{
+			name:        "union - later filesystem overrides the same path",
+			fss:         []afero.Fs{embedded, disk},
+			want:        []string{"config/a.libsonnet", "config/b.libsonnet", "config/c.libsonnet"},
+			wantContent: "{b: 'disk'}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.SetFilesystems(tt.fss...)
+
+			got, err := g.resolveFilesFrom([]string{}, ".", "config/*.libsonnet")
+			if err != nil {
+				t.Errorf("GlobImporter.resolveFilesFrom() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+
+			content, err := afero.ReadFile(g.fs, "config/b.libsonnet")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantContent, string(content))
+		})
+	}
+}
+
+func TestGlobImporter_SetFilesystemsEvaluate(t *testing.T) {
+	embedded := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"config/a.libsonnet": "{a: 'embedded'}",
+		"config/b.libsonnet": "{b: 'embedded'}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(embedded, file, []byte(cnt), 0o644); err != nil {
+			t.Fatalf("afero.WriteFile() error = %v", err)
+		}
+	}
+
+	g := NewGlobImporter()
+	g.SetFilesystems(embedded)
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(NewMultiImporter(g, NewFallbackFileImporter()))
+
+	// the generated imports are read from the OS filesystem by default
+	_, err := vm.EvaluateAnonymousSnippet("main.jsonnet", "import 'glob+://config/*.libsonnet'")
+	assert.ErrorContains(t, err, "config/a.libsonnet")
+
+	f := NewFallbackFileImporter()
+	f.SetFilesystems(embedded)
+
+	vm = jsonnet.MakeVM()
+	vm.Importer(NewMultiImporter(g, f))
+
+	got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", "import 'glob+://config/*.libsonnet'")
+	if err != nil {
+		t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+		return
+	}
+	assert.JSONEq(t, `{"a": "embedded", "b": "embedded"}`, got)
+}
+
+func TestGlobImporter_SkipBrokenFiles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.libsonnet":      "{a: 1}",
+		"configs/broken.libsonnet": "{a: ",
+		"configs/c.libsonnet":      "{c: 3}",
+		"only-broken/x.libsonnet":  "local",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name            string
+		skipBrokenFiles bool
+		importedPath    string
+		want            jsonnet.Contents
+		wantErr         bool
+	}{
+		{
+			name:         "disabled - broken files stay",
+			importedPath: "glob+://configs/*.libsonnet",
+			want: jsonnet.MakeContents(
+				"(import 'configs/a.libsonnet')+(import 'configs/broken.libsonnet')+(import 'configs/c.libsonnet')",
+			),
+		},
+		{
+			name:            "enabled - broken files are skipped",
+			skipBrokenFiles: true,
+			importedPath:    "glob+://configs/*.libsonnet",
+			want:            jsonnet.MakeContents("(import 'configs/a.libsonnet')+(import 'configs/c.libsonnet')"),
+		},
+		{
+			name:            "enabled - importstr does not need valid Jsonnet",
+			skipBrokenFiles: true,
+			importedPath:    "glob-str+://only-broken/*.libsonnet",
+			want:            jsonnet.MakeContents("(importstr 'only-broken/x.libsonnet')"),
+		},
+		{
+			name:            "enabled - alias of an importstr prefix",
+			skipBrokenFiles: true,
+			importedPath:    "raw://only-broken/*.libsonnet",
+			want:            jsonnet.MakeContents("(importstr 'only-broken/x.libsonnet')"),
+		},
+		{
+			name:            "enabled - importstr variant of an alias",
+			skipBrokenFiles: true,
+			importedPath:    "glob-str://only-broken/*.libsonnet",
+			want:            jsonnet.MakeContents("(importstr 'only-broken/x.libsonnet')"),
+		},
+		{
+			name:            "enabled - all files broken - should return error",
+			skipBrokenFiles: true,
+			importedPath:    "glob+://only-broken/*.libsonnet",
+			want:            jsonnet.MakeContents(""),
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+			g.SkipBrokenFiles(tt.skipBrokenFiles)
+			if err := g.AddAliasPrefix("raw", "glob-str+"); err != nil {
+				t.Errorf("GlobImporter.AddAliasPrefix() error = %v", err)
+				return
+			}
+			if err := g.AddAliasPrefix("glob", "glob+"); err != nil {
+				t.Errorf("GlobImporter.AddAliasPrefix() error = %v", err)
+				return
+			}
+
+			got, _, err := g.Import("", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGlobImporter_ImportLatest(t *testing.T) {
+	now := time.Now()
+	testFiles := map[string]time.Time{
+		"snapshots/a.json":     now.Add(-2 * time.Hour),
+		"snapshots/b.json":     now,
+		"snapshots/c.json":     now.Add(-time.Hour),
+		"same/a.json":          now,
+		"same/b.json":          now,
+		"same/sub/c.json":      now.Add(-time.Hour),
+		"snapshots/notes.text": now.Add(time.Hour),
+	}
+	fs := afero.NewMemMapFs()
+	for file, mtime := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+		if err := fs.Chtimes(file, mtime, mtime); err != nil {
+			t.Errorf("fs.Chtimes() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         jsonnet.Contents
+		wantErr      bool
+	}{
+		{
+			name:         "newest file",
+			importedPath: "glob.latest://snapshots/*.json",
+			want:         jsonnet.MakeContents("(import 'snapshots/b.json')"),
+		},
+		{
+			name:         "newest file as string",
+			importedPath: "glob-str.latest://snapshots/*.json",
+			want:         jsonnet.MakeContents("(importstr 'snapshots/b.json')"),
+		},
+		{
+			name:         "same modification time - last one wins",
+			importedPath: "glob.latest://same/**/*.json",
+			want:         jsonnet.MakeContents("(import 'same/b.json')"),
+		},
+		{
+			name:         "no matches - should return error",
+			importedPath: "glob.latest://missing/*.json",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, _, err := g.Import("", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGlobImporter_ImportUpward(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"config.libsonnet":       "{level: 0}",
+		"a/config.libsonnet":     "{level: 1}",
+		"a/b/c/config.libsonnet": "{level: 3}",
+		"a/b/c/main.jsonnet":     "{}",
+		"x/other.libsonnet":      "{}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		boundary     string
+		importedFrom string
+		importedPath string
+		want         jsonnet.Contents
+		wantErr      bool
+	}{
+		{
+			name:         "root first and nearer files last",
+			importedFrom: "a/b/c/main.jsonnet",
+			importedPath: "glob.up+://config.libsonnet",
+			want: jsonnet.MakeContents(
+				"(import '../../../config.libsonnet')+(import '../../config.libsonnet')+(import 'config.libsonnet')",
+			),
+		},
+		{
+			name:         "stop at the boundary",
+			boundary:     "a/",
+			importedFrom: "a/b/c/main.jsonnet",
+			importedPath: "glob.up+://config.libsonnet",
+			want:         jsonnet.MakeContents("(import '../../config.libsonnet')+(import 'config.libsonnet')"),
+		},
+		{
+			name:         "minMatches counts all directories",
+			importedFrom: "a/b/c/main.jsonnet",
+			importedPath: "glob.up+://config.libsonnet?minMatches=4",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      true,
+		},
+		{
+			name:         "no matches - should return error",
+			importedFrom: "a/b/c/main.jsonnet",
+			importedPath: "glob.up+://other.libsonnet",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+			g.SetUpwardBoundary(tt.boundary)
+
+			got, _, err := g.Import(tt.importedFrom, tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGlobImporter_ImportSummary(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.libsonnet": "{a: 1}",
+		"configs/b.libsonnet": "{bb: 22}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name        string
+		level       zapcore.Level
+		wantSummary []map[string]interface{}
+	}{
+		{
+			name:  "info level",
+			level: zap.InfoLevel,
+			wantSummary: []map[string]interface{}{
+				{"matched": int64(2), "totalBytes": int64(14), "pattern": "configs/*.libsonnet"},
+			},
+		},
+		{
+			name:        "warn level - no summary",
+			level:       zap.WarnLevel,
+			wantSummary: []map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(tt.level)
+
+			g := NewGlobImporter()
+			g.fs = fs
+			g.Logger(zap.New(core))
+
+			if _, _, err := g.Import("", "glob+://configs/*.libsonnet"); err != nil {
+				t.Errorf("GlobImporter.Import() error = %v", err)
+				return
+			}
+
+			gotSummary := []map[string]interface{}{}
+			for _, entry := range logs.FilterMessage("glob summary").All() {
+				gotSummary = append(gotSummary, entry.ContextMap())
+			}
+			assert.Equal(t, tt.wantSummary, gotSummary)
+		})
+	}
+}
+
+func TestGlobImporter_ImportExclude(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"configs/_a.libsonnet", "configs/b.libsonnet", "configs/c.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name          string
+		exclude       string
+		clearExclude  bool
+		importedPaths []string
+		want          jsonnet.Contents
+	}{
+		{
+			name: "exclude of a previous import does not leak",
+			importedPaths: []string{
+				"glob+://configs/*.libsonnet?exclude=**/_*",
+				"glob+://configs/*.libsonnet",
+			},
+			want: jsonnet.MakeContents(
+				"(import 'configs/_a.libsonnet')+(import 'configs/b.libsonnet')+(import 'configs/c.libsonnet')",
+			),
+		},
+		{
+			name:          "exclude set via Exclude() applies to all imports",
+			exclude:       "**/c.libsonnet",
+			importedPaths: []string{"glob+://configs/*.libsonnet?exclude=**/_*", "glob+://configs/*.libsonnet"},
+			want:          jsonnet.MakeContents("(import 'configs/_a.libsonnet')+(import 'configs/b.libsonnet')"),
+		},
+		{
+			name:          "exclude of the import applies in addition to Exclude()",
+			exclude:       "**/c.libsonnet",
+			importedPaths: []string{"glob+://configs/*.libsonnet?exclude=**/_*"},
+			want:          jsonnet.MakeContents("(import 'configs/b.libsonnet')"),
+		},
+		{
+			name:          "ClearExclude removes the exclude set via Exclude()",
+			exclude:       "**/c.libsonnet",
+			clearExclude:  true,
+			importedPaths: []string{"glob+://configs/*.libsonnet"},
+			want: jsonnet.MakeContents(
+				"(import 'configs/_a.libsonnet')+(import 'configs/b.libsonnet')+(import 'configs/c.libsonnet')",
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+			g.Exclude(tt.exclude)
+
+			if tt.clearExclude {
+				g.ClearExclude()
+			}
+
+			var got jsonnet.Contents
+			for _, importedPath := range tt.importedPaths {
+				var err error
+				if got, _, err = g.Import("", importedPath); err != nil {
+					t.Errorf("GlobImporter.Import() error = %v", err)
+					return
+				}
+			}
+			assert.Equal(t, tt.want.String(), got.String())
+		})
+	}
+}
+
+func TestGlobImporter_SetIgnoreFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.libsonnet":      "{}",
+		"configs/_b.libsonnet":     "{}",
+		"configs/c_test.libsonnet": "{}",
+		"configs/d.libsonnet":      "{}",
+		".globignore":              "# private files\n**/_*\n\n  **/*_test.libsonnet  \n",
+		"broken.globignore":        "**/[\n",
+		"everything.globignore":    "**/*.libsonnet\n",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		ignoreFile   string
+		importedPath string
+		want         []string
+		wantErr      bool
+		wantErrType  error
+	}{
+		{
+			name:         "patterns of the ignore file",
+			ignoreFile:   ".globignore",
+			importedPath: "glob+://configs/*.libsonnet",
+			want:         []string{"configs/a.libsonnet", "configs/d.libsonnet"},
+		},
+		{
+			name:         "combined with the exclude of the import",
+			ignoreFile:   ".globignore",
+			importedPath: "glob+://configs/*.libsonnet?exclude=**/d.*",
+			want:         []string{"configs/a.libsonnet"},
+		},
+		{
+			name:         "empty file name removes the patterns",
+			ignoreFile:   "",
+			importedPath: "glob+://configs/*.libsonnet",
+			want:         []string{"configs/_b.libsonnet", "configs/a.libsonnet", "configs/c_test.libsonnet", "configs/d.libsonnet"},
+		},
+		{
+			name:         "ignore file removes everything - should return error",
+			ignoreFile:   "everything.globignore",
+			importedPath: "glob+://configs/*.libsonnet",
+			wantErr:      true,
+			wantErrType:  ErrEmptyResult,
+		},
+		{
+			name:        "malformed pattern - should return error",
+			ignoreFile:  "broken.globignore",
+			wantErr:     true,
+			wantErrType: ErrMalformedGlobPattern,
+		},
+		{
+			name:       "missing ignore file - should return error",
+			ignoreFile: "missing.globignore",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+			// the patterns of an earlier ignore file will be replaced
+			assert.NoError(t, g.SetIgnoreFile(".globignore"))
+
+			err := g.SetIgnoreFile(tt.ignoreFile)
+			if err == nil {
+				var result GlobResult
+				result, err = g.Resolve("main.jsonnet", tt.importedPath)
+				assert.Equal(t, tt.want, result.Files)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.SetIgnoreFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrType != nil {
+				assert.ErrorIs(t, err, tt.wantErrType)
+			}
+		})
+	}
+}
+
+func TestGlobImporter_SetDefaultExclude(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"configs/_a.libsonnet", "configs/b.libsonnet", "configs/b_test.libsonnet", "configs/c.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name            string
+		defaultExcludes []string
+		clearExclude    bool
+		importedPaths   []string
+		want            []string
+		wantErr         bool
+		wantSetErr      error
+	}{
+		{
+			name:            "applies without exclude of the import",
+			defaultExcludes: []string{"**/*_test.libsonnet"},
+			importedPaths:   []string{"glob+://configs/*.libsonnet"},
+			want:            []string{"configs/_a.libsonnet", "configs/b.libsonnet", "configs/c.libsonnet"},
+		},
+		{
+			name:            "union with the exclude of the import",
+			defaultExcludes: []string{"**/*_test.libsonnet"},
+			importedPaths:   []string{"glob+://configs/*.libsonnet?exclude=**/_*"},
+			want:            []string{"configs/b.libsonnet", "configs/c.libsonnet"},
+		},
+		{
+			name:            "multiple patterns persist across imports",
+			defaultExcludes: []string{"**/*_test.libsonnet", "**/c.*"},
+			importedPaths:   []string{"glob+://configs/*.libsonnet?exclude=**/_*", "glob+://configs/*.libsonnet"},
+			want:            []string{"configs/_a.libsonnet", "configs/b.libsonnet"},
+		},
+		{
+			name:            "not removed by ClearExclude",
+			defaultExcludes: []string{"**/*_test.libsonnet"},
+			clearExclude:    true,
+			importedPaths:   []string{"glob+://configs/*.libsonnet"},
+			want:            []string{"configs/_a.libsonnet", "configs/b.libsonnet", "configs/c.libsonnet"},
+		},
+		{
+			name:            "removes all matches - should return error",
+			defaultExcludes: []string{"**/*.libsonnet"},
+			importedPaths:   []string{"glob+://configs/*.libsonnet"},
+			wantErr:         true,
+		},
+		{
+			name:            "invalid pattern - should return error",
+			defaultExcludes: []string{"**/*_test.libsonnet", "[a-"},
+			wantSetErr:      ErrMalformedGlobPattern,
+		},
+		{
+			name:            "empty pattern - should return error",
+			defaultExcludes: []string{""},
+			wantSetErr:      ErrMalformedGlobPattern,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			err := g.SetDefaultExclude(tt.defaultExcludes...)
+			if tt.wantSetErr != nil {
+				assert.ErrorIs(t, err, tt.wantSetErr)
+				assert.Empty(t, g.defaultExcludes, "keeps the previous default excludes")
+
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if tt.clearExclude {
+				g.ClearExclude()
+			}
+
+			var result GlobResult
+			for _, importedPath := range tt.importedPaths {
+				if result, err = g.Resolve("main.jsonnet", importedPath); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrEmptyResult)
+				return
+			}
+			assert.Equal(t, tt.want, result.Files)
+		})
+	}
+}
+
+func TestGlobImporter_ImportOrderFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.libsonnet":        "{}",
+		"configs/b.libsonnet":        "{}",
+		"configs/c.libsonnet":        "{}",
+		"configs/sub/d.libsonnet":    "{}",
+		"configs/order.txt":          "c.libsonnet\n# comment\n\nsub/d.libsonnet\nmissing.libsonnet\na.libsonnet\n",
+		"configs/none.txt":           "missing.libsonnet\n",
+		"vendor/configs/c.libsonnet": "{}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		jpaths       []string
+		importedPath string
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name:         "unlisted files will be appended",
+			importedPath: "glob+://configs/**/*.libsonnet?orderFile=order.txt",
+			want:         []string{"configs/c.libsonnet", "configs/sub/d.libsonnet", "configs/a.libsonnet", "configs/b.libsonnet"},
+		},
+		{
+			name:         "unlisted files will be excluded",
+			importedPath: "glob+://configs/**/*.libsonnet?orderFile=order.txt&orderUnlisted=exclude",
+			want:         []string{"configs/c.libsonnet", "configs/sub/d.libsonnet", "configs/a.libsonnet"},
+		},
+		{
+			name:         "files of JPaths and cwd keep their order",
+			jpaths:       []string{"vendor"},
+			importedPath: "glob+://configs/*.libsonnet?orderFile=order.txt",
+			want:         []string{"vendor/configs/c.libsonnet", "configs/c.libsonnet", "configs/a.libsonnet", "configs/b.libsonnet"},
+		},
+		{
+			name:         "no listed file matches - should return error",
+			importedPath: "glob+://configs/*.libsonnet?orderFile=none.txt&orderUnlisted=exclude",
+			wantErr:      true,
+		},
+		{
+			name:         "missing order file - should return error",
+			importedPath: "glob+://configs/*.libsonnet?orderFile=missing.txt",
+			wantErr:      true,
+		},
+		{
+			name:         "unknown orderUnlisted - should return error",
+			importedPath: "glob+://configs/*.libsonnet?orderFile=order.txt&orderUnlisted=drop",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter(tt.jpaths...)
+			g.fs = fs
+
+			result, err := g.Resolve("main.jsonnet", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, result.Files)
+		})
+	}
+}
+
+func TestGlobImporter_ImportExcludeLeak(t *testing.T) {
+	m := NewMultiImporter(NewGlobImporter(), NewFallbackFileImporter())
+	m.fs = afero.NewMemMapFs()
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	got, err := vm.EvaluateFile("testdata/globExclude/main.jsonnet")
+	if err != nil {
+		t.Errorf("vm.EvaluateFile() error = %v", err)
+		return
+	}
+	assert.JSONEq(t, `{"without": {"a": true}, "with": {"a": true, "hidden": true}}`, got)
+}
+
+func TestGlobImporter_ImportPatternList(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := []string{
+		"configs/b.libsonnet",
+		"configs/sub/a.libsonnet",
+		"overrides/a.libsonnet",
+		"overrides/c.json",
+	}
+	for _, file := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         jsonnet.Contents
+		wantErr      error
+	}{
+		{
+			name:         "results in the order of the patterns",
+			importedPath: "glob+://[overrides/*.libsonnet, configs/**/*.libsonnet]",
+			want: jsonnet.MakeContents(
+				"(import 'overrides/a.libsonnet')+(import 'configs/b.libsonnet')+(import 'configs/sub/a.libsonnet')",
+			),
+		},
+		{
+			name:         "commas inside braces do not split",
+			importedPath: "glob.path://[configs/*.libsonnet, overrides/*.{json,libsonnet}]",
+			want: jsonnet.MakeContents(
+				"{\n'configs/b.libsonnet': (import 'configs/b.libsonnet'),\n'overrides/a.libsonnet': (import 'overrides/a.libsonnet'),\n'overrides/c.json': (import 'overrides/c.json'),\n}",
+			),
+		},
+		{
+			name:         "with query parameters",
+			importedPath: "glob-str+://[configs/**/*.libsonnet,overrides/*]?exclude=**/a.*&minMatches=2",
+			want:         jsonnet.MakeContents("(importstr 'configs/b.libsonnet')+(importstr 'overrides/c.json')"),
+		},
+		{
+			name:         "minMatches counts for all patterns together - should return error",
+			importedPath: "glob+://[configs/*.libsonnet, overrides/*.json]?minMatches=3",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrTooFewMatches,
+		},
+		{
+			name:         "one pattern without matches - should return error",
+			importedPath: "glob+://[configs/*.libsonnet, missing/*.libsonnet]",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrEmptyResult,
+		},
+		{
+			name:         "empty pattern - should return error",
+			importedPath: "glob+://[configs/*.libsonnet, ]",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrMalformedGlobPattern,
+		},
+		{
+			name:         "malformed pattern - should return error",
+			importedPath: "glob+://[configs/*.libsonnet, overrides/{a.libsonnet]",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrMalformedGlobPattern,
+		},
+		{
+			name:         "missing closing bracket - should return error",
+			importedPath: "glob+://[configs/*.libsonnet, overrides/*.libsonnet",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrMalformedGlobPattern,
+		},
+		{
+			name:         "not supported by the prefix - should return error",
+			importedPath: "dir://[configs, overrides]",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrMalformedGlobPattern,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, _, err := g.Import("", tt.importedPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else if err != nil {
+				t.Errorf("GlobImporter.Import() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want.String(), got.String())
+		})
+	}
+}
+
+func TestGlobImporter_ImportDepthRange(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := []string{
+		"configs/l0.libsonnet",
+		"configs/a/l1.libsonnet",
+		"configs/a/b/l2.libsonnet",
+		"configs/a/b/c/l3.libsonnet",
+		"configs/a/b/c/d/l4.libsonnet",
+	}
+	for _, file := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         []string
+		wantErr      error
+	}{
+		{
+			name:         "levels 1 to 3",
+			importedPath: "glob.stem://configs/**{1,3}/*.libsonnet",
+			want:         []string{"l1", "l2", "l3"},
+		},
+		{
+			name:         "only the top level",
+			importedPath: "glob.stem://configs/**{0,0}/*.libsonnet",
+			want:         []string{"l0"},
+		},
+		{
+			name:         "lower boundary",
+			importedPath: "glob.stem://configs/**{0,1}/*.libsonnet",
+			want:         []string{"l0", "l1"},
+		},
+		{
+			name:         "upper boundary",
+			importedPath: "glob.stem://configs/**{4,4}/*.libsonnet",
+			want:         []string{"l4"},
+		},
+		{
+			name:         "range beyond the deepest file",
+			importedPath: "glob.stem://configs/**{3,10}/*.libsonnet",
+			want:         []string{"l3", "l4"},
+		},
+		{
+			name:         "range with a sub folder behind the '**'",
+			importedPath: "glob.stem://configs/**{2,2}/c/*.libsonnet",
+			want:         []string{"l3"},
+		},
+		{
+			name:         "range inside a list of patterns",
+			importedPath: "glob.stem://[configs/**{2,2}/*.libsonnet, configs/l0.libsonnet]",
+			want:         []string{"l0", "l2"},
+		},
+		{
+			name:         "no file inside the range - should return error",
+			importedPath: "glob.stem://configs/**{5,6}/*.libsonnet",
+			wantErr:      ErrEmptyResult,
+		},
+		{
+			name:         "inverted range - should return error",
+			importedPath: "glob.stem://configs/**{3,1}/*.libsonnet",
+			wantErr:      ErrMalformedGlobPattern,
+		},
+		{
+			name:         "multiple ranges - should return error",
+			importedPath: "glob.stem://configs/**{1,2}/**{1,2}/*.libsonnet",
+			wantErr:      ErrMalformedGlobPattern,
+		},
+		{
+			name:         "range together with another '**' - should return error",
+			importedPath: "glob.stem://**/configs/**{1,2}/*.libsonnet",
+			wantErr:      ErrMalformedGlobPattern,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, _, err := g.Import("", tt.importedPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, stem := range []string{"l0", "l1", "l2", "l3", "l4"} {
+				key := fmt.Sprintf("'%s':", stem)
+				assert.Equal(t, slices.Contains(tt.want, stem), strings.Contains(got.String(), key), key)
+			}
+		})
+	}
+}
+
+func TestGlobImporter_ImportRequireNonEmpty(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.libsonnet":       "{a: 1}",
+		"configs/empty.libsonnet":   "",
+		"configs/sub/b.libsonnet":   "{b: 2}",
+		"configs/sub/nil.libsonnet": "",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         string
+		wantErr      error
+	}{
+		{
+			name:         "without empty files",
+			importedPath: "glob+://configs/**/[ab].libsonnet?requireNonEmpty=true",
+			want:         "(import 'configs/a.libsonnet')+(import 'configs/sub/b.libsonnet')",
+		},
+		{
+			name:         "empty files are allowed by default",
+			importedPath: "glob+://configs/*.libsonnet",
+			want:         "(import 'configs/a.libsonnet')+(import 'configs/empty.libsonnet')",
+		},
+		{
+			name:         "excluded empty files",
+			importedPath: "glob+://configs/**/*.libsonnet?requireNonEmpty=true&exclude=**/{empty,nil}.libsonnet",
+			want:         "(import 'configs/a.libsonnet')+(import 'configs/sub/b.libsonnet')",
+		},
+		{
+			name:         "empty files - should return error",
+			importedPath: "glob+://configs/**/*.libsonnet?requireNonEmpty=true",
+			wantErr:      ErrEmptyFile,
+		},
+		{
+			name:         "malformed requireNonEmpty - should return error",
+			importedPath: "glob+://configs/*.libsonnet?requireNonEmpty=yes",
+			wantErr:      ErrMalformedGlobPattern,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, _, err := g.Import("", tt.importedPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+
+	// the error names all empty files
+	g := NewGlobImporter()
+	g.fs = fs
+	_, _, err := g.Import("", "glob+://configs/**/*.libsonnet?requireNonEmpty=true")
+	assert.ErrorContains(t, err, "configs/empty.libsonnet")
+	assert.ErrorContains(t, err, "configs/sub/nil.libsonnet")
+}
+
+func TestGlobImporter_ImportHash(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.libsonnet":     "{a: 1}",
+		"configs/sub/b.libsonnet": "{b: 2}",
+		"renamed/c.libsonnet":     "{a: 1}",
+		"shifted/a.libsonnet":     "{a: 1}{b",
+		"shifted/sub/b.libsonnet": ": 2}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	// sumOf hashes the given path and content pairs like the glob.hash prefix
+	sumOf := func(pathsAndContents ...string) jsonnet.Contents {
+		hash := sha256.New()
+		for i := 0; i < len(pathsAndContents); i += 2 {
+			content := pathsAndContents[i+1]
+			fmt.Fprintf(hash, "%s\x00%d\x00%s", pathsAndContents[i], len(content), content)
+		}
+		return jsonnet.MakeContents("'" + hex.EncodeToString(hash.Sum(nil)) + "'")
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         jsonnet.Contents
+		wantErr      bool
+	}{
+		{
+			name:         "hash of the paths and contents in sort order",
+			importedPath: "glob.hash://configs/**/*.libsonnet",
+			want:         sumOf("configs/a.libsonnet", "{a: 1}", "configs/sub/b.libsonnet", "{b: 2}"),
+		},
+		{
+			name:         "hash of a single file",
+			importedPath: "glob.hash://configs/a.libsonnet",
+			want:         sumOf("configs/a.libsonnet", "{a: 1}"),
+		},
+		{
+			name:         "hash of a renamed file",
+			importedPath: "glob.hash://renamed/*.libsonnet",
+			want:         sumOf("renamed/c.libsonnet", "{a: 1}"),
+		},
+		{
+			name:         "hash of bytes moved into the previous file",
+			importedPath: "glob.hash://shifted/**/*.libsonnet",
+			want:         sumOf("shifted/a.libsonnet", "{a: 1}{b", "shifted/sub/b.libsonnet", ": 2}"),
+		},
+		{
+			name:         "no matches - should return error",
+			importedPath: "glob.hash://missing/*.libsonnet",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, _, err := g.Import("", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want.String(), got.String())
+		})
+	}
+
+	// neither renaming a file nor moving bytes between files keeps the sum
+	g := NewGlobImporter()
+	g.fs = fs
+	sums := map[string]bool{}
+	for _, importedPath := range []string{
+		"glob.hash://configs/a.libsonnet",
+		"glob.hash://renamed/*.libsonnet",
+		"glob.hash://configs/**/*.libsonnet",
+		"glob.hash://shifted/**/*.libsonnet",
+	} {
+		got, _, err := g.Import("", importedPath)
+		assert.NoError(t, err)
+		sums[got.String()] = true
+	}
+	assert.Len(t, sums, 4)
+}
+
+func TestGlobImporter_ImportSizes(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"assets/a.json":      `{"a": 1}`,
+		"assets/sub/b.json":  `{"bb": 22}`,
+		"assets/broken.json": "{",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "keyed by path by default",
+			importedPath: "glob.sizes://assets/**/*.json?exclude=**/broken.json",
+			want:         `{ "assets/a.json": 8, "assets/sub/b.json": 10 }`,
+		},
+		{
+			name:         "keyed by stem",
+			importedPath: "glob.sizes://assets/**/*.json?by=stem&exclude=**/broken.json",
+			want:         `{ "a": 8, "b": 10 }`,
+		},
+		{
+			name:         "keyed by file without parsing the content",
+			importedPath: "glob.sizes://assets/broken.json?by=file",
+			want:         `{ "broken.json": 1 }`,
+		},
+		{
+			name:         "no matches - should return error",
+			importedPath: "glob.sizes://missing/*.json",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(g)
+
+			got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", fmt.Sprintf("import '%s'", tt.importedPath))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestGlobImporter_ImportYAMLString(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.yaml":   "name: a\nreplicas: 1\nlabels:\n  app: a\n",
+		"configs/b.json":   `{"replicas": 3}`,
+		"configs/bad.yaml": "- not\n- an object\n",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "merged in sort order",
+			importedPath: "glob.yamlstr://configs/*.{yaml,json}?exclude=**/bad.yaml",
+			want:         "labels:\n  app: a\nname: a\nreplicas: 3\n",
+		},
+		{
+			name:         "single file",
+			importedPath: "glob.yamlstr://configs/b.json",
+			want:         "replicas: 3\n",
+		},
+		{
+			name:         "not an object - should return error",
+			importedPath: "glob.yamlstr://configs/bad.yaml",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(g)
+
+			got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", fmt.Sprintf("import '%s'", tt.importedPath))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			want, _ := json.Marshal(tt.want)
+			assert.Equal(t, string(want)+"\n", got)
+		})
+	}
+}
+
+func TestGlobImporter_ImportCompanion(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"services/auth/main.libsonnet": "{name: 'auth'}",
+		"services/auth/meta.json":      `{"owner": "team-a"}`,
+		"services/auth/README.md":      "# auth",
+		"services/db/main.libsonnet":   "{name: 'db'}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		wantSnippet  string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "companions with the import kind per extension",
+			importedPath: "glob.companion://services/auth/main.libsonnet?companion=meta.json&companion=README.md",
+			wantSnippet: "{\n'auth': {\n'main': (import 'services/auth/main.libsonnet'),\n" +
+				"'meta': (import 'services/auth/meta.json'),\n'README': (importstr 'services/auth/README.md'),\n},\n}",
+			want: `{"auth": {"main": {"name": "auth"}, "meta": {"owner": "team-a"}, "README": "# auth"}}`,
+		},
+		{
+			name:         "skip missing companions",
+			importedPath: "glob.companion://services/*/main.libsonnet?companion=meta.json&missingCompanion=skip",
+			wantSnippet: "{\n'auth': {\n'main': (import 'services/auth/main.libsonnet'),\n" +
+				"'meta': (import 'services/auth/meta.json'),\n},\n'db': {\n'main': (import 'services/db/main.libsonnet'),\n},\n}",
+			want: `{"auth": {"main": {"name": "auth"}, "meta": {"owner": "team-a"}}, "db": {"main": {"name": "db"}}}`,
+		},
+		{
+			name:         "missing companion - should return error",
+			importedPath: "glob.companion://services/*/main.libsonnet?companion=meta.json",
+			wantErr:      true,
+		},
+		{
+			name:         "without companion - should return error",
+			importedPath: "glob.companion://services/*/main.libsonnet",
+			wantErr:      true,
+		},
+		{
+			name:         "unknown missingCompanion - should return error",
+			importedPath: "glob.companion://services/*/main.libsonnet?companion=meta.json&missingCompanion=ignore",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			result, err := g.Resolve("main.jsonnet", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.wantSnippet, result.Snippet)
+
+			data := map[string]jsonnet.Contents{}
+			for file, cnt := range testFiles {
+				data[file] = jsonnet.MakeContents(cnt)
+			}
+			vm := jsonnet.MakeVM()
+			vm.Importer(&jsonnet.MemoryImporter{Data: data})
+
+			got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", result.Snippet)
+			if err != nil {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+				return
+			}
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestGlobImporter_ImportByKey(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"services/a.yaml":      "name: auth\nmetadata:\n  name: auth-v1\n",
+		"services/b.yaml":      "name: db\nmetadata:\n  name: db-v1\n",
+		"services/c.json":      `{"name": "web", "metadata": {"name": 3}}`,
+		"other/auth.yaml":      "name: auth\n",
+		"other/noname.yaml":    "kind: x\n",
+		"other/list.yaml":      "name: [a, b]\n",
+		"data/a.json":          `{"id": "x", "a": 1}`,
+		"data/b.json":          `{"id": "x", "b": 2}`,
+		"data/broken.json":     `{"id": `,
+		"data/not-object.json": `[1, 2]`,
+		"ids/a.json":           `{"id": 1234567, "ok": true}`,
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         string
+		wantErr      error
+	}{
+		{
+			name:         "keyed by the name field",
+			importedPath: "glob.bykey://services/*?field=name",
+			want: "{\n'auth': (import 'yaml://services/a.yaml'),\n'db': (import 'yaml://services/b.yaml'),\n" +
+				"'web': (import 'services/c.json'),\n}",
+		},
+		{
+			name:         "keyed by a nested field",
+			importedPath: "glob.bykey://services/*?field=metadata.name",
+			want: "{\n'auth-v1': (import 'yaml://services/a.yaml'),\n'db-v1': (import 'yaml://services/b.yaml'),\n" +
+				"'3': (import 'services/c.json'),\n}",
+		},
+		{
+			name:         "keyed by a number without exponent",
+			importedPath: "glob.bykey://ids/*?field=id",
+			want:         "{\n'1234567': (import 'ids/a.json'),\n}",
+		},
+		{
+			name:         "keyed by a boolean",
+			importedPath: "glob.bykey://ids/*?field=ok",
+			want:         "{\n'true': (import 'ids/a.json'),\n}",
+		},
+		{
+			name:         "skip missing fields",
+			importedPath: "glob.bykey://other/{auth,noname}.yaml?field=name&missingField=skip",
+			want:         "{\n'auth': (import 'yaml://other/auth.yaml'),\n}",
+		},
+		{
+			name:         "last file wins for colliding keys",
+			importedPath: "glob.bykey://data/[ab].json?field=id&collision=last",
+			want:         "{\n'x': (import 'data/b.json'),\n}",
+		},
+		{
+			name:         "merge colliding keys",
+			importedPath: "glob.bykey://data/[ab].json?field=id&collision=merge",
+			want:         "{\n'x': (import 'data/a.json')+(import 'data/b.json'),\n}",
+		},
+		{
+			name:         "colliding keys - should return error",
+			importedPath: "glob.bykey://data/[ab].json?field=id",
+			wantErr:      ErrDuplicateKey,
+		},
+		{
+			name:         "colliding keys across directories - should return error",
+			importedPath: "glob.bykey://*/a*.yaml?field=name",
+			wantErr:      ErrDuplicateKey,
+		},
+		{
+			name:         "missing field - should return error",
+			importedPath: "glob.bykey://other/{auth,noname}.yaml?field=name",
+			wantErr:      ErrMissingField,
+		},
+		{
+			name:         "field without a scalar value - should return error",
+			importedPath: "glob.bykey://other/list.yaml?field=name",
+			wantErr:      ErrMissingField,
+		},
+		{
+			name:         "without field - should return error",
+			importedPath: "glob.bykey://services/*",
+			wantErr:      ErrMalformedGlobPattern,
+		},
+		{
+			name:         "unknown collision - should return error",
+			importedPath: "glob.bykey://services/*?field=name&collision=first",
+			wantErr:      ErrMalformedGlobPattern,
+		},
+		{
+			name:         "unknown missingField - should return error",
+			importedPath: "glob.bykey://services/*?field=name&missingField=ignore",
+			wantErr:      ErrMalformedGlobPattern,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, _, err := g.Import("main.jsonnet", tt.importedPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+
+	// files, which are no YAML or JSON objects, return an error
+	for _, importedPath := range []string{
+		"glob.bykey://data/broken.json?field=id",
+		"glob.bykey://data/not-object.json?field=id",
+	} {
+		g := NewGlobImporter()
+		g.fs = fs
+
+		_, _, err := g.Import("main.jsonnet", importedPath)
+		assert.Error(t, err, importedPath)
+	}
+}
+
+func TestGlobImporter_ImportByKeyEscaping(t *testing.T) {
+	// the value contains a quote, a backslash, a newline and an attempt to
+	// inject code into the generated snippet
+	value := "it's \\ a\nkey': error 'injected', '"
+	content := `{"name": "it's \\ a\nkey': error 'injected', '"}`
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "escaped/a.json", []byte(content), 0o644); err != nil {
+		t.Fatalf("afero.WriteFile() error = %v", err)
+	}
+
+	g := NewGlobImporter()
+	g.fs = fs
+
+	got, _, err := g.Import("main.jsonnet", "glob.bykey://escaped/*.json?field=name")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "{\n'it\\'s \\\\ a\\nkey\\': error \\'injected\\', \\'': (import 'escaped/a.json'),\n}", got.String())
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(&jsonnet.MemoryImporter{Data: map[string]jsonnet.Contents{
+		"escaped/a.json": jsonnet.MakeContents(content),
+	}})
+
+	fields, err := vm.EvaluateAnonymousSnippet("main.jsonnet", "std.objectFields("+got.String()+")")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	want, _ := json.Marshal([]string{value})
+	assert.JSONEq(t, string(want), fields)
+}
+
+func TestGlobImporter_ImportByKeyYAMLImporter(t *testing.T) {
+	evaluate := func(m *MultiImporter) (string, error) {
+		vm := jsonnet.MakeVM()
+		vm.Importer(m)
+
+		return vm.EvaluateFile("testdata/bykey/main.jsonnet")
+	}
+
+	// the default importers do not handle the generated `yaml://` imports
+	_, err := evaluate(NewMultiImporter())
+	assert.ErrorContains(t, err, "yaml://services/a.yaml")
+
+	got, err := evaluate(NewMultiImporter(NewGlobImporter(), NewYAMLImporter(), NewFallbackFileImporter()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"auth": {"name": "auth"}, "db": {"name": "db"}}`, got)
+}
+
+func TestGlobImporter_EagerCycleCheck(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"loop/a.jsonnet":       "import 'glob+://*.jsonnet'",
+		"loop/b.jsonnet":       "import 'glob+://*.jsonnet'",
+		"libs/host.libsonnet":  "{host: true}",
+		"libs/other.libsonnet": "import 'glob+://libs/*.libsonnet'",
+		"main.jsonnet":         "import 'glob+://libs/*.libsonnet'",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name            string
+		eagerCycleCheck bool
+		importedFrom    string
+		importedPath    string
+		// graphEdges are added to the import graph in front of the import
+		graphEdges [][2]string
+		wantErr    bool
+	}{
+		{
+			name:            "continuous import resolves the caller again - should return error",
+			eagerCycleCheck: true,
+			importedFrom:    "loop/a.jsonnet",
+			importedPath:    "glob+://*.jsonnet",
+			wantErr:         true,
+		},
+		{
+			name:         "continuous import without eager check",
+			importedFrom: "loop/a.jsonnet",
+			importedPath: "glob+://*.jsonnet",
+		},
+		{
+			name:            "resolved file imports the caller already - should return error",
+			eagerCycleCheck: true,
+			importedFrom:    "main.jsonnet",
+			importedPath:    "glob+://libs/*.libsonnet",
+			graphEdges:      [][2]string{{"libs/host.libsonnet", "main.jsonnet"}},
+			wantErr:         true,
+		},
+		{
+			name:            "same glob import in another folder does not resolve the caller",
+			eagerCycleCheck: true,
+			importedFrom:    "main.jsonnet",
+			importedPath:    "glob+://libs/*.libsonnet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+			g.EagerCycleCheck(tt.eagerCycleCheck)
+
+			for _, edge := range tt.graphEdges {
+				_ = g.graphRecorder().graph.AddVertex(edge[0])
+				_ = g.graphRecorder().graph.AddVertex(edge[1])
+				if err := g.graphRecorder().graph.AddEdge(edge[0], edge[1]); err != nil {
+					t.Errorf("AddEdge() error = %v", err)
+					return
+				}
+			}
+
+			_, _, err := g.Import(tt.importedFrom, tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrImportCycle)
+			}
+		})
+	}
+}
+
+func TestGlobImporter_ImportMap(t *testing.T) {
+	testFiles := map[string]string{
+		"services/a.libsonnet": "{name: 'a', port: null}",
+		"services/b.libsonnet": "{name: 'b', port: 80}",
+		"configs/a.yaml":       "name: a",
+	}
+	fs := afero.NewMemMapFs()
+	data := map[string]jsonnet.Contents{}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+		data[file] = jsonnet.MakeContents(cnt)
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		// caller wraps the generated snippet
+		caller   string
+		want     string
+		wantEval string
+		wantErr  bool
+	}{
+		{
+			name:         "std function",
+			importedPath: "glob.map://services/*.libsonnet?fn=std.prune",
+			caller:       "%s",
+			want:         "[\nstd.prune((import 'services/a.libsonnet')),\nstd.prune((import 'services/b.libsonnet')),\n]",
+			wantEval:     `[{"name": "a"}, {"name": "b", "port": 80}]`,
+		},
+		{
+			name:         "std function with importstr",
+			importedPath: "glob-str.map://configs/*.yaml?fn=std.parseYaml",
+			caller:       "%s",
+			want:         "[\nstd.parseYaml((importstr 'configs/a.yaml')),\n]",
+			wantEval:     `[{"name": "a"}]`,
+		},
+		{
+			name:         "without fn returns a function",
+			importedPath: "glob.map://services/*.libsonnet",
+			caller:       "local getName(s) = s.name; (%s)(getName)",
+			want:         "function(fn) [\nfn((import 'services/a.libsonnet')),\nfn((import 'services/b.libsonnet')),\n]",
+			wantEval:     `["a", "b"]`,
+		},
+		{
+			name:         "fn outside of std - should return error",
+			importedPath: "glob.map://services/*.libsonnet?fn=normalizeService",
+			wantErr:      true,
+		},
+		{
+			name:         "fn is not an identifier - should return error",
+			importedPath: "glob.map://services/*.libsonnet?fn=std.prune(x)",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, _, err := g.Import("", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidIdentifier)
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(&jsonnet.MemoryImporter{Data: data})
+			gotEval, err := vm.EvaluateAnonymousSnippet("main.jsonnet", fmt.Sprintf(tt.caller, got.String()))
+			if err != nil {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+				return
+			}
+			assert.JSONEq(t, tt.wantEval, gotEval)
+		})
+	}
+}
+
+func TestGlobImporter_RebaseImports(t *testing.T) {
+	root, err := filepath.Abs("testdata/globRebase")
+	if err != nil {
+		t.Errorf("filepath.Abs() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name          string
+		rebaseImports bool
+		wantSnippet   string
+	}{
+		{
+			name: "relative to the importing file",
+			wantSnippet: "{\n'helper': (import 'configs/helper.libsonnet')+(import 'configs/sub/helper.libsonnet'),\n" +
+				"'host': (import 'configs/sub/host.libsonnet'),\n}",
+		},
+		{
+			name:          "absolute",
+			rebaseImports: true,
+			wantSnippet: fmt.Sprintf("{\n'helper': (import '%[1]s/configs/helper.libsonnet')+(import '%[1]s/configs/sub/helper.libsonnet'),\n"+
+				"'host': (import '%[1]s/configs/sub/host.libsonnet'),\n}", filepath.ToSlash(root)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.RebaseImports(tt.rebaseImports)
+
+			result, err := g.Resolve("testdata/globRebase/main.jsonnet", "glob.stem+://configs/**/*.libsonnet")
+			if err != nil {
+				t.Errorf("GlobImporter.Resolve() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.wantSnippet, result.Snippet)
+
+			g = NewGlobImporter()
+			g.RebaseImports(tt.rebaseImports)
+			m := NewMultiImporter(g, NewFallbackFileImporter())
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(m)
+			// the nested import of host.libsonnet is relative to host.libsonnet in both cases
+			got, err := vm.EvaluateFile("testdata/globRebase/main.jsonnet")
+			if err != nil {
+				t.Errorf("vm.EvaluateFile() error = %v", err)
+				return
+			}
+			assert.JSONEq(t, `{"helper": {"name": "sub-helper"}, "host": {"host": "sub-helper"}}`, got)
+		})
+	}
+}
+
+func TestGlobImporter_NativeFunctions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"configs/b.libsonnet", "configs/sub/a.libsonnet", "configs/c.json", "vendor/v.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name    string
+		jpaths  []string
+		snippet string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "paths relative to the base",
+			snippet: "std.native('glob')('configs', '**/*.libsonnet')",
+			want:    `["b.libsonnet", "sub/a.libsonnet"]`,
+		},
+		{
+			name:    "with jpaths",
+			jpaths:  []string{"vendor"},
+			snippet: "std.native('glob')('configs', '*.libsonnet')",
+			want:    `["../vendor/v.libsonnet", "b.libsonnet"]`,
+		},
+		{
+			name:    "no matches return an empty array",
+			snippet: "std.native('glob')('configs', '*.yaml')",
+			want:    `[]`,
+		},
+		{
+			name:    "conditional logic",
+			snippet: "if std.length(std.native('glob')('.', 'configs/*.json')) > 0 then 'json' else 'none'",
+			want:    `"json"`,
+		},
+		{
+			name:    "pattern is not a string - should return error",
+			snippet: "std.native('glob')('configs', 1)",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter(tt.jpaths...)
+			g.fs = fs
+
+			vm := jsonnet.MakeVM()
+			for _, f := range g.NativeFunctions() {
+				vm.NativeFunction(f)
+			}
+
+			got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", tt.snippet)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestGlobImporter_ResolvePage(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"models/a.libsonnet", "models/b.libsonnet", "models/c.libsonnet", "models/sub/d.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name      string
+		pattern   string
+		offset    int
+		limit     int
+		want      []string
+		wantTotal int
+		wantErr   bool
+	}{
+		{
+			name:      "first page",
+			pattern:   "models/**/*.libsonnet",
+			offset:    0,
+			limit:     2,
+			want:      []string{"models/a.libsonnet", "models/b.libsonnet"},
+			wantTotal: 4,
+		},
+		{
+			name:      "last page is shorter than the limit",
+			pattern:   "models/**/*.libsonnet",
+			offset:    3,
+			limit:     2,
+			want:      []string{"models/sub/d.libsonnet"},
+			wantTotal: 4,
+		},
+		{
+			name:      "limit 0 returns all files after the offset",
+			pattern:   "models/**/*.libsonnet",
+			offset:    1,
+			want:      []string{"models/b.libsonnet", "models/c.libsonnet", "models/sub/d.libsonnet"},
+			wantTotal: 4,
+		},
+		{
+			name:      "offset out of range - should return error",
+			pattern:   "models/**/*.libsonnet",
+			offset:    4,
+			limit:     2,
+			want:      []string{},
+			wantTotal: 4,
+			wantErr:   true,
+		},
+		{
+			name:    "negative offset - should return error",
+			pattern: "models/**/*.libsonnet",
+			offset:  -1,
+			limit:   2,
+			want:    []string{},
+			wantErr: true,
+		},
+		{
+			name:    "negative limit - should return error",
+			pattern: "models/**/*.libsonnet",
+			limit:   -1,
+			want:    []string{},
+			wantErr: true,
+		},
+		{
+			name:    "no matches - should return error",
+			pattern: "models/*.json",
+			want:    []string{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, gotTotal, err := g.ResolvePage(tt.pattern, tt.offset, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.ResolvePage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantTotal, gotTotal)
+		})
+	}
+}
+
+func TestGlobImporter_ImportLogLevel(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "a.libsonnet", []byte("{a: 1}"), 0o644); err != nil {
+		t.Errorf("afero.WriteFile() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		wantErr      bool
+	}{
+		{
+			name:         "debug level for a single import",
+			importedPath: "glob+://*.libsonnet?logLevel=debug",
+		},
+		{
+			name:         "unknown level - should return error",
+			importedPath: "glob+://*.libsonnet?logLevel=trace",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := zap.New(nil)
+			g := NewGlobImporter()
+			g.fs = fs
+			g.Logger(logger)
+
+			_, _, err := g.Import("", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnknownConfig)
+			}
+			// the level is scoped to the import
+			assert.Same(t, logger, g.logger)
+
+			_, _, err = g.Import("", "glob+://*.libsonnet")
+			assert.NoError(t, err)
+			assert.Same(t, logger, g.logger)
+		})
+	}
+}
+
+func TestGlobImporter_ImportLogLevelDerived(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "a.libsonnet", []byte("{a: 1}"), 0o644); err != nil {
+		t.Errorf("afero.WriteFile() error = %v", err)
+		return
+	}
+
+	core, logs := observer.New(zap.DebugLevel)
+	g := NewGlobImporter()
+	g.fs = fs
+	g.Logger(zap.New(core).With(zap.String("component", "test")))
+
+	// the scoped level filters the entries of the given logger
+	_, _, err := g.Import("", "glob+://*.libsonnet?logLevel=info")
+	assert.NoError(t, err)
+	assert.Zero(t, logs.FilterLevelExact(zap.DebugLevel).Len())
+	assert.NotZero(t, logs.FilterLevelExact(zap.InfoLevel).Len())
+
+	for _, entry := range logs.TakeAll() {
+		assert.Equal(t, "test", entry.ContextMap()["component"], "derived from the given logger")
+	}
+
+	_, _, err = g.Import("", "glob+://*.libsonnet")
+	assert.NoError(t, err)
+	assert.NotZero(t, logs.FilterLevelExact(zap.DebugLevel).Len())
+}
+
+func TestGlobImporter_ImportLogLevelRaised(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "a.libsonnet", []byte("{a: 1}"), 0o644); err != nil {
+		t.Errorf("afero.WriteFile() error = %v", err)
+		return
+	}
+
+	// the core of the logger writes only info entries and above
+	core, logs := observer.New(zap.InfoLevel)
+	g := NewGlobImporter()
+	g.fs = fs
+	g.Logger(zap.New(core))
+
+	_, _, err := g.Import("", "glob+://*.libsonnet?logLevel=debug")
+	assert.NoError(t, err)
+	assert.NotZero(t, logs.FilterLevelExact(zap.DebugLevel).Len(), "debug entries of the import")
+	assert.Equal(t, zapcore.InvalidLevel, g.level.Level(), "restored level")
+
+	logs.TakeAll()
+
+	_, _, err = g.Import("", "glob+://*.libsonnet")
+	assert.NoError(t, err)
+	assert.Zero(t, logs.FilterLevelExact(zap.DebugLevel).Len(), "no debug entries of the next import")
+	assert.NotZero(t, logs.FilterLevelExact(zap.InfoLevel).Len())
+
+	_, _, err = g.Import("", "glob+://*.libsonnet?logLevel=trace")
+	assert.ErrorIs(t, err, ErrUnknownConfig)
+}
+
+func TestGlobImporter_ImportSettingsStayLocal(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"configs/a.libsonnet", "configs/b.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Fatalf("afero.WriteFile() error = %v", err)
+		}
+	}
+
+	g := NewGlobImporter()
+	g.fs = fs
+
+	_, err := g.Resolve("main.jsonnet", "glob.stem://configs/*.libsonnet?exclude=**/a.*&sort=lexical&minMatches=1&caseFold=lower")
+	assert.NoError(t, err)
+	assert.Equal(t, importSettings{}, g.importSettings, "the settings of the import stay local")
+
+	files, total, err := g.ResolvePage("configs/*.libsonnet", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []string{"configs/a.libsonnet", "configs/b.libsonnet"}, files)
+}
+
+func TestGlobImporter_handle(t *testing.T) {
+	type fields struct {
+		aliases    map[string]string
+		keyFunc    func(path string) string
+		pairsBy    string
+		keyField   string
+		valueField string
+	}
+	type args struct {
+		files  []string
+		prefix string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "glob-str+",
+			args: args{
+				files:  []string{"a.jsonnet", "b.jsonnet"},
+				prefix: "glob-str+",
+			},
+			want:    `(importstr 'a.jsonnet')+(importstr 'b.jsonnet')`,
+			wantErr: false,
+		},
+		{
+			name: "glob+",
+			args: args{
+				files:  []string{"a.jsonnet", "b.jsonnet"},
+				prefix: "glob+",
+			},
+			want:    `(import 'a.jsonnet')+(import 'b.jsonnet')`,
+			wantErr: false,
+		},
+		// ---------------------------------------------------------- glob.file
+		{
+			name: "glob.file",
+			args: args{
+				files:  []string{"a.jsonnet", "b.jsonnet"},
+				prefix: "glob.file",
+			},
+			want:    "{\n'a.jsonnet': (import 'a.jsonnet'),\n'b.jsonnet': (import 'b.jsonnet'),\n}",
+			wantErr: false,
+		},
+		{
+			name: "glob-str.file",
+			args: args{
+				files:  []string{"a.jsonnet", "b.jsonnet"},
+				prefix: "glob-str.file",
+			},
+			want:    "{\n'a.jsonnet': (importstr 'a.jsonnet'),\n'b.jsonnet': (importstr 'b.jsonnet'),\n}",
+			wantErr: false,
+		},
+		// --------------------------------------------------------- glob.yaml+
+		{
+			name: "glob.yaml+",
+			args: args{
+				files:  []string{"configs/a.yaml", "configs/b.yaml"},
+				prefix: "glob.yaml+",
+			},
+			want:    `(import 'yaml://configs/a.yaml')+(import 'yaml://configs/b.yaml')`,
+			wantErr: false,
+		},
+		// ------------------------------------------------------ glob.manifest
+		{
+			name: "glob.manifest",
+			args: args{
+				files:  []string{"a.libsonnet", "b.libsonnet"},
+				prefix: "glob.manifest",
+			},
+			want:    "{\nresult: (import 'a.libsonnet')+(import 'b.libsonnet'),\nsources: ['a.libsonnet', 'b.libsonnet'],\n}",
+			wantErr: false,
+		},
+		{
+			name: "glob-str.manifest",
+			args: args{
+				files:  []string{"a.txt", "b.txt"},
+				prefix: "glob-str.manifest",
+			},
+			want:    "{\nresult: (importstr 'a.txt')+(importstr 'b.txt'),\nsources: ['a.txt', 'b.txt'],\n}",
+			wantErr: false,
+		},
+		// --------------------------------------------------------- glob.pairs
+		{
+			name: "glob.pairs with default key and duplicate stems",
+			args: args{
+				files:  []string{"a/host.libsonnet", "b/host.libsonnet", "db.libsonnet"},
+				prefix: "glob.pairs",
+			},
+			want: "[\n{key: 'host', value: (import 'a/host.libsonnet')},\n" +
+				"{key: 'host', value: (import 'b/host.libsonnet')},\n" +
+				"{key: 'db', value: (import 'db.libsonnet')},\n]",
+			wantErr: false,
+		},
+		{
+			name:   "glob-str.pairs by file",
+			fields: fields{pairsBy: "file"},
+			args: args{
+				files:  []string{"a/host.txt"},
+				prefix: "glob-str.pairs",
+			},
+			want:    "[\n{key: 'host.txt', value: (importstr 'a/host.txt')},\n]",
+			wantErr: false,
+		},
+		{
+			name:   "glob.pairs by path",
+			fields: fields{pairsBy: "path"},
+			args: args{
+				files:  []string{"a/host.libsonnet"},
+				prefix: "glob.pairs",
+			},
+			want:    "[\n{key: 'a/host.libsonnet', value: (import 'a/host.libsonnet')},\n]",
+			wantErr: false,
+		},
+		{
+			name: "glob.pairs with quotes and backslashes in the keys",
+			fields: fields{
+				keyFunc: func(p string) string {
+					return "it's\\" + path.Base(p)
+				},
+			},
+			args: args{
+				files:  []string{"a/host.libsonnet"},
+				prefix: "glob.pairs",
+			},
+			want:    "[\n{key: 'it\\'s\\\\host.libsonnet', value: (import 'a/host.libsonnet')},\n]",
+			wantErr: false,
+		},
+		// ---------------------------------------------------------- glob.fold
+		{
+			name: "glob.fold",
+			args: args{
+				files:  []string{"a.libsonnet", "b.libsonnet"},
+				prefix: "glob.fold",
+			},
+			want:    "[\n(import 'a.libsonnet'),\n(import 'b.libsonnet'),\n]",
+			wantErr: false,
+		},
+		{
+			name: "glob-str.fold",
+			args: args{
+				files:  []string{"a.txt", "b.txt"},
+				prefix: "glob-str.fold",
+			},
+			want:    "[\n(importstr 'a.txt'),\n(importstr 'b.txt'),\n]",
+			wantErr: false,
+		},
+		// ------------------------------------------------------------ glob.kv
+		{
+			name: "glob.kv with default fields",
+			args: args{
+				files:  []string{"a/host.libsonnet", "db.libsonnet"},
+				prefix: "glob.kv",
+			},
+			want: "[\n{k: 'host', v: (import 'a/host.libsonnet')},\n" +
+				"{k: 'db', v: (import 'db.libsonnet')},\n]",
+			wantErr: false,
+		},
+		{
+			name:   "glob-str.kv with custom fields",
+			fields: fields{pairsBy: "file", keyField: "name", valueField: "content"},
+			args: args{
+				files:  []string{"a/host.txt"},
+				prefix: "glob-str.kv",
+			},
+			want:    "[\n{name: 'host.txt', content: (importstr 'a/host.txt')},\n]",
+			wantErr: false,
+		},
+		{
+			name:   "glob.pairs with custom key field",
+			fields: fields{keyField: "name"},
+			args: args{
+				files:  []string{"db.libsonnet"},
+				prefix: "glob.pairs",
+			},
+			want:    "[\n{name: 'db', value: (import 'db.libsonnet')},\n]",
+			wantErr: false,
+		},
+		// ---------------------------------------------------------- glob.lazy
+		{
+			name: "glob.lazy",
+			args: args{
+				files:  []string{"plugins/a.libsonnet", "plugins/b.libsonnet"},
+				prefix: "glob.lazy",
+			},
+			want: "{\n'a': function() (import 'plugins/a.libsonnet'),\n" +
+				"'b': function() (import 'plugins/b.libsonnet'),\n}",
+			wantErr: false,
+		},
+		{
+			name: "glob-str.lazy with colliding stems - last one wins",
+			args: args{
+				files:  []string{"a/readme.md", "b/readme.md"},
+				prefix: "glob-str.lazy",
+			},
+			want:    "{\n'readme': function() (importstr 'b/readme.md'),\n}",
+			wantErr: false,
+		},
+		// ----------------------------------------------------------- key func
+		{
+			name: "glob.stem with uppercasing key func",
+			fields: fields{
+				keyFunc: func(p string) string {
+					return strings.ToUpper(strings.TrimSuffix(path.Base(p), path.Ext(p)))
+				},
+			},
+			args: args{
+				files:  []string{"a/host.libsonnet", "b/db.libsonnet"},
+				prefix: "glob.stem",
+			},
+			want:    "{\n'HOST': (import 'a/host.libsonnet'),\n'DB': (import 'b/db.libsonnet'),\n}",
+			wantErr: false,
+		},
+		{
+			name: "glob.path+ with colliding keys of the key func",
+			fields: fields{
+				keyFunc: func(p string) string {
+					return strings.ToUpper(path.Base(p))
+				},
+			},
+			args: args{
+				files:  []string{"a/host.libsonnet", "b/host.libsonnet"},
+				prefix: "glob.path+",
+			},
+			want:    "{\n'HOST.LIBSONNET': (import 'a/host.libsonnet')+(import 'b/host.libsonnet'),\n}",
+			wantErr: false,
+		},
+		{
+			name: "glob.stem with quotes, backslashes and newlines in the keys of the key func",
+			fields: fields{
+				keyFunc: func(p string) string {
+					return "it's\\\n" + path.Base(p)
+				},
+			},
+			args: args{
+				files:  []string{"a/host.libsonnet"},
+				prefix: "glob.stem",
+			},
+			want:    "{\n'it\\'s\\\\\\nhost.libsonnet': (import 'a/host.libsonnet'),\n}",
+			wantErr: false,
+		},
+		// -------------------------------------------------------- glob.locals
+		{
+			name: "glob.locals",
+			args: args{
+				files:  []string{"libs/host.libsonnet", "libs/my-db.libsonnet", "libs/1st.libsonnet"},
+				prefix: "glob.locals",
+			},
+			want: "local host = (import 'libs/host.libsonnet');\n" +
+				"local my_db = (import 'libs/my-db.libsonnet');\n" +
+				"local _1st = (import 'libs/1st.libsonnet');\n" +
+				"{\nhost: host,\nmy_db: my_db,\n_1st: _1st,\n}",
+			wantErr: false,
+		},
+		{
+			name: "glob.locals with colliding identifiers - should return error",
+			args: args{
+				files:  []string{"a/my-db.libsonnet", "b/my_db.libsonnet"},
+				prefix: "glob.locals",
+			},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name: "glob.locals with keyword - should return error",
+			args: args{
+				files:  []string{"local.libsonnet"},
+				prefix: "glob.locals",
+			},
+			want:    "",
+			wantErr: true,
+		},
+		// --------------------------------------------------- OS path separator
+		{
+			name: "glob.path with OS specific path separators",
+			args: args{
+				files:  []string{filepath.Join("sub", "a.jsonnet"), filepath.Join("sub", "b", "c.jsonnet")},
+				prefix: "glob.path",
+			},
+			want:    "{\n'sub/a.jsonnet': (import 'sub/a.jsonnet'),\n'sub/b/c.jsonnet': (import 'sub/b/c.jsonnet'),\n}",
+			wantErr: false,
+		},
+		{
+			name: "glob.dir with OS specific path separators",
+			args: args{
+				files:  []string{filepath.Join("sub", "a.jsonnet"), filepath.Join("sub", "b", "c.jsonnet")},
+				prefix: "glob.dir",
+			},
+			want:    "{\n'sub/': (import 'sub/a.jsonnet'),\n'sub/b/': (import 'sub/b/c.jsonnet'),\n}",
+			wantErr: false,
+		},
+		// ---------------------------------------------------------- glob.auto
+		{
+			name: "glob.auto",
+			args: args{
+				files:  []string{"a.libsonnet", "b.TXT", "c.png", "d.unknown"},
+				prefix: "glob.auto",
+			},
+			want: "{\n'a.libsonnet': (import 'a.libsonnet'),\n'b.TXT': (importstr 'b.TXT'),\n" +
+				"'c.png': (importbin 'c.png'),\n'd.unknown': (importstr 'd.unknown'),\n}",
 			wantErr: false,
 		},
-		// ---------------------------------------------------------- glob.file
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.aliases = tt.fields.aliases
+			g.SetKeyFunc(tt.fields.keyFunc)
+			g.pairsBy = tt.fields.pairsBy
+			g.keyField, g.valueField = tt.fields.keyField, tt.fields.valueField
+
+			got, err := g.handle(tt.args.files, tt.args.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.handle() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGlobImporter_handleFormat(t *testing.T) {
+	files := []string{"a.libsonnet", "sub/a.libsonnet", "b.libsonnet"}
+
+	tests := []struct {
+		name    string
+		format  string
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "default object",
+			prefix: "glob.stem+",
+			want:   "{\n'a': (import 'a.libsonnet')+(import 'sub/a.libsonnet'),\n'b': (import 'b.libsonnet'),\n}",
+		},
+		{
+			name:   "pretty object",
+			format: "pretty",
+			prefix: "glob.stem+",
+			want:   "{\n  'a': (import 'a.libsonnet') + (import 'sub/a.libsonnet'),\n  'b': (import 'b.libsonnet'),\n}",
+		},
+		{
+			name:   "compact object",
+			format: "compact",
+			prefix: "glob.stem+",
+			want:   "{'a': (import 'a.libsonnet')+(import 'sub/a.libsonnet'), 'b': (import 'b.libsonnet')}",
+		},
+		{
+			name:   "compact pairs",
+			format: "compact",
+			prefix: "glob.pairs",
+			want: "[{key: 'a', value: (import 'a.libsonnet')}, {key: 'a', value: (import 'sub/a.libsonnet')}, " +
+				"{key: 'b', value: (import 'b.libsonnet')}]",
+		},
+		{
+			name:   "compact manifest",
+			format: "compact",
+			prefix: "glob.manifest",
+			want: "{result: (import 'a.libsonnet')+(import 'sub/a.libsonnet')+(import 'b.libsonnet'), " +
+				"sources: ['a.libsonnet', 'sub/a.libsonnet', 'b.libsonnet']}",
+		},
+		{
+			name:   "pretty merge",
+			format: "pretty",
+			prefix: "glob+",
+			want:   "(import 'a.libsonnet') + (import 'sub/a.libsonnet') + (import 'b.libsonnet')",
+		},
+		{
+			name:    "unknown format - should return error",
+			format:  "fancy",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			if err := g.SetFormat(tt.format); (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.SetFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := g.handle(files, tt.prefix)
+			if err != nil {
+				t.Errorf("GlobImporter.handle() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			// the format must not change the result
+			vm := jsonnet.MakeVM()
+			vm.Importer(&jsonnet.MemoryImporter{Data: map[string]jsonnet.Contents{
+				"a.libsonnet":     jsonnet.MakeContents("{a: 1}"),
+				"sub/a.libsonnet": jsonnet.MakeContents("{sub: 1}"),
+				"b.libsonnet":     jsonnet.MakeContents("{b: 1}"),
+			}})
+			_, err = vm.EvaluateAnonymousSnippet("main.jsonnet", got)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGlobImporter_handleLocalsCompact(t *testing.T) {
+	g := NewGlobImporter()
+	assert.NoError(t, g.SetFormat("compact"))
+
+	got, err := g.handle([]string{"a.libsonnet", "b.libsonnet"}, "glob.locals")
+	assert.NoError(t, err)
+	assert.Equal(t, "local a = (import 'a.libsonnet'); local b = (import 'b.libsonnet'); {a: a, b: b}", got)
+}
+
+func TestGlobImporter_handleIdentifierCollisions(t *testing.T) {
+	files := []string{"a/my-db.libsonnet", "b/my_db.libsonnet"}
+
+	g := NewGlobImporter()
+	_, err := g.handle(files, "glob.locals")
+	assert.ErrorIs(t, err, ErrDuplicateKey)
+
+	g = NewGlobImporter()
+	g.IdentifierKeys(true)
+	_, err = g.handle(files, "glob.stem")
+	assert.ErrorIs(t, err, ErrDuplicateKey)
+
+	g = NewGlobImporter()
+	_, err = g.handle([]string{"local.libsonnet"}, "glob.locals")
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+}
+
+func TestGlobImporter_handleBoth(t *testing.T) {
+	files := []string{"a.libsonnet", "sub/a.libsonnet", "b.libsonnet"}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		keyFunc func(path string) string
+		want    string
+	}{
+		{
+			name:   "import",
+			prefix: "glob.both",
+			want: "{\nbyStem: {\n'a': (import 'sub/a.libsonnet'),\n'b': (import 'b.libsonnet'),\n},\n" +
+				"byPath: {\n'a.libsonnet': (import 'a.libsonnet'),\n'sub/a.libsonnet': (import 'sub/a.libsonnet'),\n" +
+				"'b.libsonnet': (import 'b.libsonnet'),\n},\n}",
+		},
+		{
+			name:   "importstr",
+			prefix: "glob-str.both",
+			want: "{\nbyStem: {\n'a': (importstr 'sub/a.libsonnet'),\n'b': (importstr 'b.libsonnet'),\n},\n" +
+				"byPath: {\n'a.libsonnet': (importstr 'a.libsonnet'),\n'sub/a.libsonnet': (importstr 'sub/a.libsonnet'),\n" +
+				"'b.libsonnet': (importstr 'b.libsonnet'),\n},\n}",
+		},
+		{
+			name:    "keyFunc - only the stems are changed",
+			prefix:  "glob.both",
+			keyFunc: func(p string) string { return "key-" + p },
+			want: "{\nbyStem: {\n'key-a.libsonnet': (import 'a.libsonnet'),\n'key-sub/a.libsonnet': (import 'sub/a.libsonnet'),\n" +
+				"'key-b.libsonnet': (import 'b.libsonnet'),\n},\n" +
+				"byPath: {\n'a.libsonnet': (import 'a.libsonnet'),\n'sub/a.libsonnet': (import 'sub/a.libsonnet'),\n" +
+				"'b.libsonnet': (import 'b.libsonnet'),\n},\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.keyFunc = tt.keyFunc
+
+			got, err := g.handle(files, tt.prefix)
+			if err != nil {
+				t.Errorf("GlobImporter.handle() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(&jsonnet.MemoryImporter{Data: map[string]jsonnet.Contents{
+				"a.libsonnet":     jsonnet.MakeContents("{a: 1}"),
+				"sub/a.libsonnet": jsonnet.MakeContents("{sub: 1}"),
+				"b.libsonnet":     jsonnet.MakeContents("{b: 1}"),
+			}})
+			_, err = vm.EvaluateAnonymousSnippet("main.jsonnet", got)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGlobImporter_ImportSmart(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"docs/a.libsonnet": "{name: 'a'}",
+		"docs/a.md":        "# A",
+		"docs/b.md":        "# B",
+		"docs/c.json":      `{"c": true}`,
+		"docs/sub/a.md":    "# Sub A",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		wantSnippet  string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "by stem is the default",
+			importedPath: "glob.smart://docs/*.*",
+			wantSnippet: "{\n'a': (importstr 'docs/a.md'),\n'b': (importstr 'docs/b.md'),\n" +
+				"'c': (import 'docs/c.json'),\n}",
+			want: `{"a": "# A", "b": "# B", "c": {"c": true}}`,
+		},
+		{
+			name:         "by file",
+			importedPath: "glob.smart://docs/*.*?by=file",
+			wantSnippet: "{\n'a.libsonnet': (import 'docs/a.libsonnet'),\n'a.md': (importstr 'docs/a.md'),\n" +
+				"'b.md': (importstr 'docs/b.md'),\n'c.json': (import 'docs/c.json'),\n}",
+			want: `{"a.libsonnet": {"name": "a"}, "a.md": "# A", "b.md": "# B", "c.json": {"c": true}}`,
+		},
+		{
+			name:         "by path",
+			importedPath: "glob.smart://docs/**/*.md?by=path",
+			wantSnippet: "{\n'docs/a.md': (importstr 'docs/a.md'),\n'docs/b.md': (importstr 'docs/b.md'),\n" +
+				"'docs/sub/a.md': (importstr 'docs/sub/a.md'),\n}",
+			want: `{"docs/a.md": "# A", "docs/b.md": "# B", "docs/sub/a.md": "# Sub A"}`,
+		},
+		{
+			name:         "merged strings of colliding keys",
+			importedPath: "glob.smart+://docs/**/a.md",
+			wantSnippet:  "{\n'a': (importstr 'docs/a.md')+(importstr 'docs/sub/a.md'),\n}",
+			want:         `{"a": "# A# Sub A"}`,
+		},
+		{
+			name:         "unknown key selector - should return error",
+			importedPath: "glob.smart://docs/*.*?by=dir",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			result, err := g.Resolve("main.jsonnet", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.wantSnippet, result.Snippet)
+
+			data := map[string]jsonnet.Contents{}
+			for file, cnt := range testFiles {
+				data[file] = jsonnet.MakeContents(cnt)
+			}
+			vm := jsonnet.MakeVM()
+			vm.Importer(&jsonnet.MemoryImporter{Data: data})
+
+			got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", result.Snippet)
+			if err != nil {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+				return
+			}
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestGlobImporter_ImportSet(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"features/featureA.libsonnet":     "{}",
+		"features/featureB.libsonnet":     "{ broken",
+		"features/sub/featureA.libsonnet": "{}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		member       string
+		want         string
+	}{
+		{
+			name:         "set of stems",
+			importedPath: "glob.set://features/**/*.libsonnet",
+			member:       "featureA",
+			want:         `{"featureA": null, "featureB": null}`,
+		},
+		{
+			name:         "set of files",
+			importedPath: "glob.set://features/*.libsonnet?by=file",
+			member:       "featureB.libsonnet",
+			want:         `{"featureA.libsonnet": null, "featureB.libsonnet": null}`,
+		},
+		{
+			name:         "set of paths",
+			importedPath: "glob.set://features/**/*.libsonnet?by=path",
+			member:       "features/sub/featureA.libsonnet",
+			want: `{"features/featureA.libsonnet": null, "features/featureB.libsonnet": null, ` +
+				`"features/sub/featureA.libsonnet": null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+			// the files will not be imported, therefore broken files do not matter
+			g.SkipBrokenFiles(true)
+
+			result, err := g.Resolve("main.jsonnet", tt.importedPath)
+			if err != nil {
+				t.Errorf("GlobImporter.Resolve() error = %v", err)
+				return
+			}
+
+			vm := jsonnet.MakeVM()
+			got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", result.Snippet)
+			if err != nil {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+				return
+			}
+			assert.JSONEq(t, tt.want, got)
+
+			has, err := vm.EvaluateAnonymousSnippet("main.jsonnet",
+				fmt.Sprintf("local set = %s; [std.objectHas(set, '%s'), std.objectHas(set, 'featureC')]", result.Snippet, tt.member))
+			if err != nil {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+				return
+			}
+			assert.JSONEq(t, `[true, false]`, has)
+		})
+	}
+}
+
+func TestGlobImporter_SetKindMap(t *testing.T) {
+	tests := []struct {
+		name        string
+		kinds       map[string]string
+		file        string
+		want        string
+		wantErr     bool
+		wantErrType error
+	}{
+		{
+			name:  "custom extension",
+			kinds: map[string]string{".CSV": "importstr", ".jsonnet": "import"},
+			file:  "data.csv",
+			want:  "importstr",
+		},
+		{
+			name:  "replaced default falls back to importstr",
+			kinds: map[string]string{".csv": "importstr"},
+			file:  "a.libsonnet",
+			want:  "importstr",
+		},
+		{
+			name:        "unknown kind - should return error",
+			kinds:       map[string]string{".csv": "importcsv"},
+			wantErr:     true,
+			wantErrType: ErrUnknownImportKind,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			err := g.SetKindMap(tt.kinds)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.SetKindMap() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.wantErrType)
+				return
+			}
+			assert.Equal(t, tt.want, g.importKindFor(tt.file))
+		})
+	}
+}
+
+func TestGlobImporter_checkJPaths(t *testing.T) {
+	tests := []struct {
+		name         string
+		jpaths       []string
+		strictJPaths bool
+		testFolders  []string
+		testFiles    map[string]string
+		wantErr      bool
+		wantErrType  error
+	}{
+		{
+			name:        "existing jpath",
+			jpaths:      []string{"vendor"},
+			testFolders: []string{"vendor"},
+		},
+		{
+			name:   "missing jpath - only warning",
+			jpaths: []string{"rodnev"},
+		},
+		{
+			name:         "missing jpath in strict mode - should return error",
+			jpaths:       []string{"vendor", "rodnev"},
+			strictJPaths: true,
+			testFolders:  []string{"vendor"},
+			wantErr:      true,
+			wantErrType:  ErrMissingJPath,
+		},
+		{
+			name:         "jpath is a file in strict mode - should return error",
+			jpaths:       []string{"vendor"},
+			strictJPaths: true,
+			testFiles: map[string]string{
+				"vendor": "{a: 1}",
+			},
+			wantErr:     true,
+			wantErrType: ErrMissingJPath,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter(tt.jpaths...)
+			g.StrictJPaths(tt.strictJPaths)
+
+			fs := afero.NewMemMapFs()
+			for _, tF := range tt.testFolders {
+				if err := fs.MkdirAll(tF, 0o755); err != nil {
+					t.Errorf("GlobImporter.checkJPaths() error = %v", err)
+					return
+				}
+			}
+			for file, cnt := range tt.testFiles {
+				if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+					t.Errorf("GlobImporter.checkJPaths() error = %v", err)
+					return
+				}
+			}
+			g.fs = fs
+
+			err := g.checkJPaths(zap.New(nil))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GlobImporter.checkJPaths() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.wantErrType)
+			}
+		})
+	}
+}
+
+func TestGlobImporter_handleLazy(t *testing.T) {
+	vm := jsonnet.MakeVM()
+	vm.Importer(&jsonnet.MemoryImporter{Data: map[string]jsonnet.Contents{
+		"plugins/a.libsonnet":      jsonnet.MakeContents("{a: 1}"),
+		"plugins/broken.libsonnet": jsonnet.MakeContents("error 'must not be imported'"),
+	}})
+
+	g := NewGlobImporter()
+
+	got, err := g.handle([]string{"plugins/a.libsonnet", "plugins/broken.libsonnet"}, "glob.lazy")
+	if err != nil {
+		t.Errorf("GlobImporter.handle() error = %v", err)
+		return
+	}
+
+	// only the called function imports its file
+	gotEval, err := vm.EvaluateAnonymousSnippet("lazy.jsonnet", "local plugins = "+got+"; plugins.a()")
+	if err != nil {
+		t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+		return
+	}
+	assert.Equal(t, "{\n   \"a\": 1\n}\n", gotEval)
+}
+
+func TestGlobImporter_handleAnnotate(t *testing.T) {
+	files := []string{"a/host.libsonnet", "b/host.libsonnet"}
+	vm := jsonnet.MakeVM()
+	vm.Importer(&jsonnet.MemoryImporter{Data: map[string]jsonnet.Contents{
+		"a/host.libsonnet": jsonnet.MakeContents("{a: 1}"),
+		"b/host.libsonnet": jsonnet.MakeContents("{b: 2}"),
+	}})
+
+	tests := []struct {
+		name     string
+		prefix   string
+		want     string
+		wantEval string
+	}{
+		{
+			name:     "glob+",
+			prefix:   "glob+",
+			want:     "\n// from a/host.libsonnet\n(import 'a/host.libsonnet')+\n// from b/host.libsonnet\n(import 'b/host.libsonnet')",
+			wantEval: "{\n   \"a\": 1,\n   \"b\": 2\n}\n",
+		},
+		{
+			name:   "glob.stem+",
+			prefix: "glob.stem+",
+			want: "{\n'host': \n// from a/host.libsonnet\n(import 'a/host.libsonnet')+" +
+				"\n// from b/host.libsonnet\n(import 'b/host.libsonnet'),\n}",
+			wantEval: "{\n   \"host\": {\n      \"a\": 1,\n      \"b\": 2\n   }\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.Annotate(true)
+
+			got, err := g.handle(files, tt.prefix)
+			if err != nil {
+				t.Errorf("GlobImporter.handle() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+
+			gotEval, err := vm.EvaluateAnonymousSnippet("annotated.jsonnet", got)
+			if err != nil {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.wantEval, gotEval)
+		})
+	}
+}
+
+func TestGlobImporter_SetJPathsWithPriority(t *testing.T) {
+	g := NewGlobImporter("old")
+	g.SetJPathsWithPriority(map[string]int{"vendor": 1, "base": -1})
+
+	assert.Equal(t, []string{"base", "vendor"}, g.JPaths)
+	assert.Equal(t, []int{-1, 0, 1}, g.prioritiesOf(g.JPaths))
+
+	// duplicates after the normalization keep the highest priority
+	g.SetJPathsWithPriority(map[string]int{"vendor": 1, "./vendor": 2, "base/": -1})
+
+	assert.Equal(t, []string{"base", "vendor"}, g.JPaths)
+	assert.Equal(t, map[string]int{"base": -1, "vendor": 2}, g.jpathPriorities)
+
+	// SetJPaths drops the priorities
+	g.SetJPaths("vendor", "base")
+
+	assert.Equal(t, []string{"vendor", "base"}, g.JPaths)
+	assert.Nil(t, g.jpathPriorities)
+	assert.Equal(t, []int{0}, g.prioritiesOf(g.JPaths))
+}
+
+func TestGlobImporter_ResolveAlias(t *testing.T) {
+	g := NewGlobImporter()
+	if err := g.AddAliasPrefix("stem", "glob.stem"); err != nil {
+		t.Fatalf("AddAliasPrefix() failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		alias      string
+		wantPrefix string
+		wantOk     bool
+	}{
+		{
+			name:       "registered alias",
+			alias:      "stem",
+			wantPrefix: "glob.stem",
+			wantOk:     true,
+		},
+		{
+			name:  "built-in prefix is no alias",
+			alias: "glob.stem",
+		},
+		{
+			name:  "unknown alias",
+			alias: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPrefix, gotOk := g.ResolveAlias(tt.alias)
+			assert.Equal(t, tt.wantPrefix, gotPrefix)
+			assert.Equal(t, tt.wantOk, gotOk)
+		})
+	}
+}
+
+func TestGlobImporter_ListAliases(t *testing.T) {
+	g := NewGlobImporter()
+	assert.Empty(t, g.ListAliases())
+
+	if err := g.AddAliasPrefix("team", "glob.stem+"); err != nil {
+		t.Fatalf("AddAliasPrefix() failed: %v", err)
+	}
+	if err := g.AddAliasPrefix("web", "team"); err != nil {
+		t.Fatalf("AddAliasPrefix() failed: %v", err)
+	}
+
+	aliases := g.ListAliases()
+	assert.Equal(t, map[string]string{"team": "glob.stem+", "web": "team"}, aliases)
+
+	// the returned map is a copy
+	aliases["web"] = "glob+"
+	delete(aliases, "team")
+
+	assert.Equal(t, map[string]string{"team": "glob.stem+", "web": "team"}, g.ListAliases())
+}
+
+func TestGlobImporter_CanHandle(t *testing.T) {
+	g := NewGlobImporter()
+	for prefix, want := range map[string]bool{
+		"glob.stem":  true,
+		"glob.stem+": true,
+		"glob-str+":  true,
+		"dir":        true,
+		"dir+":       true,
+		"directory":  false,
+		"dirty":      false,
+		"dir.stem":   false,
+		"yaml":       false,
+	} {
+		assert.Equal(t, want, g.CanHandle(prefix), prefix)
+	}
+}
+
+func TestGlobImporter_ChainedAlias(t *testing.T) {
+	g := NewGlobImporter()
+	g.fs = afero.NewMemMapFs()
+	for file, cnt := range map[string]string{
+		"team/a.libsonnet":     "{a: 1}",
+		"team/sub/a.libsonnet": "{b: 2}",
+	} {
+		if err := afero.WriteFile(g.fs, file, []byte(cnt), 0o644); err != nil {
+			t.Fatalf("afero.WriteFile() error = %v", err)
+		}
+	}
+
+	assert.NoError(t, g.AddAliasPrefix("team", "glob.stem+"))
+	assert.NoError(t, g.AddAliasPrefix("web", "team"))
+
+	prefix, ok := g.ResolveAlias("web")
+	assert.True(t, ok)
+	assert.Equal(t, "glob.stem+", prefix)
+	assert.True(t, g.CanHandle("web"))
+	assert.Contains(t, g.Prefixa(), "web")
+
+	got, _, err := g.Import("", "web://team/**/*.libsonnet")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "{\n'a': (import 'team/a.libsonnet')+(import 'team/sub/a.libsonnet'),\n}", got.String())
+
+	// a loop of aliases will be rejected
+	assert.ErrorIs(t, g.AddAliasPrefix("team", "web"), ErrMalformedAlias)
+	assert.ErrorIs(t, g.AddAliasPrefix("web", "web"), ErrMalformedAlias)
+	prefix, ok = g.ResolveAlias("web")
+	assert.True(t, ok)
+	assert.Equal(t, "glob.stem+", prefix)
+
+	// and also detected during the import
+	g.aliases["team"] = "web"
+	_, _, err = g.Import("", "web://team/**/*.libsonnet")
+	assert.ErrorIs(t, err, ErrMalformedAlias)
+	_, err = g.handle([]string{"team/a.libsonnet"}, "team")
+	assert.ErrorIs(t, err, ErrMalformedAlias)
+	_, ok = g.ResolveAlias("web")
+	assert.False(t, ok)
+}
+
+func TestGlobImporter_SetJPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		jpaths []string
+		want   []string
+	}{
+		{
+			name:   "no jpaths",
+			jpaths: nil,
+			want:   nil,
+		},
+		{
+			name:   "clean paths",
+			jpaths: []string{"./vendor", "lib/", "a/../base"},
+			want:   []string{"vendor", "lib", "base"},
+		},
+		{
+			name:   "remove duplicates and preserve the order",
+			jpaths: []string{"vendor", "lib", "./vendor", "vendor/", "lib"},
+			want:   []string{"vendor", "lib"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewGlobImporter(tt.jpaths...).JPaths)
+
+			g := NewGlobImporter("old")
+			g.SetJPaths(tt.jpaths...)
+			assert.Equal(t, tt.want, g.JPaths)
+		})
+	}
+}
+
+// createConfigTree creates a folder tree with the given depth, where each
+// folder contains fanOut sub folders, a 'configs' folder and a libsonnet file.
+func createConfigTree(fs afero.Fs, dir string, depth, fanOut int) error {
+	for _, file := range []string{"main.libsonnet", "configs/a.libsonnet"} {
+		if err := afero.WriteFile(fs, path.Join(dir, file), []byte("{}"), 0o644); err != nil {
+			return err
+		}
+	}
+
+	if depth == 0 {
+		return nil
+	}
+
+	for i := 0; i < fanOut; i++ {
+		if err := createConfigTree(fs, path.Join(dir, fmt.Sprintf("sub%d", i)), depth-1, fanOut); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func BenchmarkGlobImporter_resolveFilesFrom(b *testing.B) {
+	fs := afero.NewMemMapFs()
+	if err := createConfigTree(fs, "tree", 4, 4); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, pattern := range []string{
+		"tree/**/*.libsonnet",
+		"tree/**/configs/*.libsonnet",
+		"tree/**/configs/**/*.libsonnet",
+	} {
+		b.Run(pattern, func(b *testing.B) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			for n := 0; n < b.N; n++ {
+				if _, err := g.resolveFilesFrom([]string{}, ".", pattern); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestGlobImporter_ExtGroups(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.json":      "{}",
+		"configs/b.yaml":      "b: 1",
+		"configs/c.toml":      "c = 1",
+		"configs/d.libsonnet": "{}",
+		"configs/e.txt":       "e",
+		"configs/f.cue":       "f: 1",
+		"data.json":           "{}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		groups       map[string][]string
+		importedPath string
+		want         []string
+		wantErrType  error
+	}{
+		{
+			name:         "built-in data group",
+			importedPath: "glob.path://configs/*.@data",
+			want:         []string{"configs/a.json", "configs/b.yaml", "configs/c.toml"},
+		},
 		{
-			name: "glob.file",
-			args: args{
-				files:  []string{"a.jsonnet", "b.jsonnet"},
-				prefix: "glob.file",
-			},
-			want:    "{\n'a.jsonnet': (import 'a.jsonnet'),\n'b.jsonnet': (import 'b.jsonnet'),\n}",
-			wantErr: false,
+			name:         "built-in jsonnet group",
+			importedPath: "glob.path://configs/*.@jsonnet",
+			want:         []string{"configs/d.libsonnet"},
 		},
 		{
-			name: "glob-str.file",
-			args: args{
-				files:  []string{"a.jsonnet", "b.jsonnet"},
-				prefix: "glob-str.file",
-			},
-			want:    "{\n'a.jsonnet': (importstr 'a.jsonnet'),\n'b.jsonnet': (importstr 'b.jsonnet'),\n}",
-			wantErr: false,
+			name:         "group inside the first path element",
+			importedPath: "glob.path://*.@data",
+			want:         []string{"data.json"},
+		},
+		{
+			name:         "custom group",
+			groups:       map[string][]string{"schema": {".cue", "txt", "cue"}},
+			importedPath: "glob.path://configs/*.@schema",
+			want:         []string{"configs/e.txt", "configs/f.cue"},
+		},
+		{
+			name:         "redefined built-in group",
+			groups:       map[string][]string{"data": {"toml"}},
+			importedPath: "glob.path://configs/*.@data",
+			want:         []string{"configs/c.toml"},
+		},
+		{
+			name:         "group inside a list of patterns",
+			importedPath: "glob.path://[configs/*.@text, *.@data]",
+			want:         []string{"configs/e.txt", "data.json"},
+		},
+		{
+			name:         "undefined group - should return error",
+			importedPath: "glob.path://configs/*.@schema",
+			wantErrType:  ErrUnknownExtGroup,
+		},
+		{
+			name:         "removed group - should return error",
+			groups:       map[string][]string{"data": nil},
+			importedPath: "glob.path://configs/*.@data",
+			wantErrType:  ErrUnknownExtGroup,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewGlobImporter()
-			g.aliases = tt.fields.aliases
+			g.fs = fs
+			for name, exts := range tt.groups {
+				g.DefineExtGroup(name, exts)
+			}
 
-			got, err := g.handle(tt.args.files, tt.args.prefix)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GlobImporter.handle() error = %v, wantErr %v", err, tt.wantErr)
+			result, err := g.Resolve("main.jsonnet", tt.importedPath)
+			if tt.wantErrType != nil {
+				assert.ErrorIs(t, err, tt.wantErrType)
+				return
+			}
+			if err != nil {
+				t.Errorf("GlobImporter.Resolve() error = %v", err)
+				return
+			}
+			assert.ElementsMatch(t, tt.want, result.Files)
+		})
+	}
+}
+
+func TestGlobImporter_PerDirConfig(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/.globconf":               "# local defaults\nsort=lexical\nexclude=*_test.libsonnet\n",
+		"configs/a.libsonnet":             "{}",
+		"configs/a/b.libsonnet":           "{}",
+		"configs/a_test.libsonnet":        "{}",
+		"plain/a.libsonnet":               "{}",
+		"plain/a_test.libsonnet":          "{}",
+		"broken/.globconf":                "order=lexical\n",
+		"broken/a.libsonnet":              "{}",
+		"badsort/.globconf":               "sort=random\n",
+		"badsort/a.libsonnet":             "{}",
+		"onlyexcluded/.globconf":          "exclude=*.libsonnet\n",
+		"onlyexcluded/a.libsonnet":        "{}",
+		"configs/nested/.globconf":        "exclude=b.libsonnet\n",
+		"configs/nested/b.libsonnet":      "{}",
+		"configs/nested/c.libsonnet":      "{}",
+		"configs/nested/c_test.libsonnet": "{}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		perDirConfig bool
+		importedPath string
+		want         []string
+		wantErrType  error
+	}{
+		{
+			name:         "disabled - the config file will be ignored",
+			importedPath: "glob+://configs/**/*.libsonnet?exclude=**/nested/**",
+			want:         []string{"configs/a/b.libsonnet", "configs/a.libsonnet", "configs/a_test.libsonnet"},
+		},
+		{
+			name:         "sort and exclude of the directory",
+			perDirConfig: true,
+			importedPath: "glob+://configs/**/*.libsonnet?exclude=**/nested/**",
+			want:         []string{"configs/a.libsonnet", "configs/a/b.libsonnet"},
+		},
+		{
+			name:         "sort of the import wins",
+			perDirConfig: true,
+			importedPath: "glob+://configs/**/*.libsonnet?exclude=**/nested/**&sort=hierarchical",
+			want:         []string{"configs/a/b.libsonnet", "configs/a.libsonnet"},
+		},
+		{
+			name:         "only the config of the resolved directory applies",
+			perDirConfig: true,
+			importedPath: "glob+://configs/nested/*.libsonnet",
+			want:         []string{"configs/nested/c.libsonnet", "configs/nested/c_test.libsonnet"},
+		},
+		{
+			name:         "directory without config file",
+			perDirConfig: true,
+			importedPath: "glob+://plain/*.libsonnet",
+			want:         []string{"plain/a.libsonnet", "plain/a_test.libsonnet"},
+		},
+		{
+			name:         "unknown key - should return error",
+			perDirConfig: true,
+			importedPath: "glob+://broken/*.libsonnet",
+			wantErrType:  ErrUnknownConfig,
+		},
+		{
+			name:         "unknown sort - should return error",
+			perDirConfig: true,
+			importedPath: "glob+://badsort/*.libsonnet",
+			wantErrType:  ErrUnknownConfig,
+		},
+		{
+			name:         "exclude removes all files - should return error",
+			perDirConfig: true,
+			importedPath: "glob+://onlyexcluded/*.libsonnet",
+			wantErrType:  ErrEmptyResult,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+			g.PerDirConfig(tt.perDirConfig)
+
+			result, err := g.Resolve("main.jsonnet", tt.importedPath)
+			if tt.wantErrType != nil {
+				assert.ErrorIs(t, err, tt.wantErrType)
+				return
+			}
+			if err != nil {
+				t.Errorf("GlobImporter.Resolve() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, result.Files)
+			// the sort of the directory does not leak into the next import
+			assert.NotEqual(t, "lexical", g.sortMode)
+		})
+	}
+}
+
+func TestGlobImporter_ImportConcurrent(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"configs/a.libsonnet", "configs/b.libsonnet", "configs/c.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	imports := map[string]string{
+		"glob.stem://configs/*.libsonnet?exclude=**/a.libsonnet": "{\n'b': (import 'configs/b.libsonnet'),\n'c': (import 'configs/c.libsonnet'),\n}",
+		"glob.stem://configs/*.libsonnet?exclude=**/c.libsonnet": "{\n'a': (import 'configs/a.libsonnet'),\n'b': (import 'configs/b.libsonnet'),\n}",
+		"glob+://configs/*.libsonnet?sort=lexical":               "(import 'configs/a.libsonnet')+(import 'configs/b.libsonnet')+(import 'configs/c.libsonnet')",
+		"glob.kv://configs/[ab].libsonnet?keyField=name":         "[\n{name: 'a', v: (import 'configs/a.libsonnet')},\n{name: 'b', v: (import 'configs/b.libsonnet')},\n]",
+	}
+
+	g := NewGlobImporter()
+	g.fs = fs
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10*len(imports))
+
+	for i := 0; i < 10; i++ {
+		for importedPath, want := range imports {
+			wg.Add(1)
+
+			go func(importedPath, want string) {
+				defer wg.Done()
+
+				got, _, err := g.Import("main.jsonnet", importedPath)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if got.String() != want {
+					errs <- fmt.Errorf("GlobImporter.Import(%s) = %s, want %s", importedPath, got.String(), want)
+				}
+			}(importedPath, want)
+		}
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	// the settings of the imports do not leak into the GlobImporter
+	assert.Equal(t, "", g.importExcludePattern)
+	assert.Equal(t, "", g.sortMode)
+}
+
+func TestGlobImporter_ImportFold(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"configs/b.libsonnet", "configs/a.libsonnet", "configs/sub/a.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	g := NewGlobImporter()
+	g.fs = fs
+
+	merged, err := g.Resolve("main.jsonnet", "glob+://configs/**/*.libsonnet")
+	if err != nil {
+		t.Errorf("GlobImporter.Resolve() error = %v", err)
+		return
+	}
+
+	folded, err := g.Resolve("main.jsonnet", "glob.fold://configs/**/*.libsonnet")
+	if err != nil {
+		t.Errorf("GlobImporter.Resolve() error = %v", err)
+		return
+	}
+
+	// the array follows the merge order of glob+
+	assert.Equal(t, merged.Files, folded.Files)
+	assert.Equal(t, "[\n(import 'configs/a.libsonnet'),\n(import 'configs/b.libsonnet'),\n"+
+		"(import 'configs/sub/a.libsonnet'),\n]", folded.Snippet)
+	assert.Equal(t, "(import 'configs/a.libsonnet')+(import 'configs/b.libsonnet')+"+
+		"(import 'configs/sub/a.libsonnet')", merged.Snippet)
+}
+
+func TestGlobImporter_WarnShadowed(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"a.libsonnet", "lib/a.libsonnet", "vendor/a.libsonnet", "vendor/b.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		warnShadowed bool
+		want         []map[string]interface{}
+	}{
+		{
+			name:         "cwd shadows both JPaths",
+			warnShadowed: true,
+			want: []map[string]interface{}{
+				{"path": "a.libsonnet", "shadowed": "lib/a.libsonnet", "shadowedBy": "a.libsonnet"},
+				{"path": "a.libsonnet", "shadowed": "vendor/a.libsonnet", "shadowedBy": "a.libsonnet"},
+			},
+		},
+		{
+			name:         "disabled - no warnings",
+			warnShadowed: false,
+			want:         []map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.WarnLevel)
+
+			g := NewGlobImporter("vendor", "lib")
+			g.fs = fs
+			g.Logger(zap.New(core))
+			g.WarnShadowed(tt.warnShadowed)
+
+			if _, _, err := g.Import("main.jsonnet", "glob+://*.libsonnet"); err != nil {
+				t.Errorf("GlobImporter.Import() error = %v", err)
 				return
 			}
+
+			got := []map[string]interface{}{}
+			for _, entry := range logs.FilterMessage("shadowed file").All() {
+				got = append(got, entry.ContextMap())
+			}
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
+
+func TestGlobImporter_ExcludeShadows(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"a.libsonnet", "b.libsonnet", "configs/c.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         string
+		wantErr      error
+		wantShadows  bool
+	}{
+		{
+			name:         "exclude equals the pattern - should return error",
+			importedPath: "glob+://*.libsonnet?exclude=*.libsonnet",
+			wantErr:      ErrEmptyResult,
+			wantShadows:  true,
+		},
+		{
+			name:         "normalized exclude equals the pattern - should return error",
+			importedPath: "glob+://configs//*.libsonnet?exclude=configs/*.libsonnet",
+			wantErr:      ErrEmptyResult,
+			wantShadows:  true,
+		},
+		{
+			name:         "exclude matches every path - should return error",
+			importedPath: "glob+://configs/*.libsonnet?exclude=**",
+			wantErr:      ErrEmptyResult,
+			wantShadows:  true,
+		},
+		{
+			name:         "exclude removes all current matches only - should return error",
+			importedPath: "glob+://*.libsonnet?exclude={a,b}.libsonnet",
+			wantErr:      ErrEmptyResult,
+		},
+		{
+			name:         "exclude removes some matches",
+			importedPath: "glob+://*.libsonnet?exclude=a.libsonnet",
+			want:         "(import 'b.libsonnet')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+
+			got, _, err := g.Import("", tt.importedPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, tt.wantShadows, errors.Is(err, ErrExcludeShadows))
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestGlobImporter_SetGlobFunc(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := []string{
+		"configs/a.libsonnet",
+		"configs/b.libsonnet",
+		"configs/unknown.libsonnet",
+		"configs/sub/c.libsonnet",
+	}
+	for _, file := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	errDatabase := errors.New("database not reachable")
+	// known is a trivial in-memory matcher, which knows only some of the files
+	known := func(_ afero.Fs, base, pattern string) ([]string, error) {
+		if base == "offline" {
+			return nil, errDatabase
+		}
+		matches := []string{}
+		for _, p := range []string{"configs/a.libsonnet", "configs/b.libsonnet", "configs/sub"} {
+			rel, found := strings.CutPrefix(p, base+"/")
+			if ok, _ := path.Match(pattern, rel); found && ok {
+				matches = append(matches, rel)
+			}
+		}
+		return matches, nil
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		globFunc     GlobFunc
+		want         string
+		wantErr      error
+	}{
+		{
+			name:         "matches of the glob function",
+			importedPath: "glob+://configs/*.libsonnet",
+			globFunc:     known,
+			want:         "(import 'configs/a.libsonnet')+(import 'configs/b.libsonnet')",
+		},
+		{
+			name:         "excludes apply to the matches",
+			importedPath: "glob+://configs/*.libsonnet?exclude=**/a.libsonnet",
+			globFunc:     known,
+			want:         "(import 'configs/b.libsonnet')",
+		},
+		{
+			name:         "directories are removed for dir",
+			importedPath: "dir://configs",
+			globFunc:     known,
+			want:         "{\n'a.libsonnet': (import 'configs/a.libsonnet'),\n'b.libsonnet': (import 'configs/b.libsonnet'),\n}",
+		},
+		{
+			name:         "doublestar without glob function",
+			importedPath: "glob+://configs/*.libsonnet",
+			want:         "(import 'configs/a.libsonnet')+(import 'configs/b.libsonnet')+(import 'configs/unknown.libsonnet')",
+		},
+		{
+			name:         "failing glob function - should return error",
+			importedPath: "glob+://offline/*.libsonnet",
+			globFunc:     known,
+			wantErr:      errDatabase,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+			g.SetGlobFunc(tt.globFunc)
+
+			got, _, err := g.Import("", tt.importedPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestGlobImporter_IdentifierKeys(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := []string{
+		"keys/a/b.libsonnet",
+		"keys/my-app.libsonnet",
+		"collision/a-b.libsonnet",
+		"collision/a_b.libsonnet",
+		"keyword/local.libsonnet",
+	}
+	for _, file := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name           string
+		importedPath   string
+		identifierKeys bool
+		want           string
+		wantErr        error
+	}{
+		{
+			name:           "paths as identifiers",
+			importedPath:   "glob.path://keys/**/*.libsonnet",
+			identifierKeys: true,
+			want: `{
+'keys_a_b_libsonnet': (import 'keys/a/b.libsonnet'),
+'keys_my_app_libsonnet': (import 'keys/my-app.libsonnet'),
+}`,
+		},
+		{
+			name:           "stems as identifiers",
+			importedPath:   "glob.stem://keys/*.libsonnet",
+			identifierKeys: true,
+			want: `{
+'my_app': (import 'keys/my-app.libsonnet'),
+}`,
+		},
+		{
+			name:         "raw keys by default",
+			importedPath: "glob.path://keys/**/*.libsonnet",
+			want: `{
+'keys/a/b.libsonnet': (import 'keys/a/b.libsonnet'),
+'keys/my-app.libsonnet': (import 'keys/my-app.libsonnet'),
+}`,
+		},
+		{
+			name:           "keys becoming the same identifier - should return error",
+			importedPath:   "glob.stem://collision/*.libsonnet",
+			identifierKeys: true,
+			wantErr:        ErrDuplicateKey,
+		},
+		{
+			name:           "keyword as key - should return error",
+			importedPath:   "glob.stem://keyword/*.libsonnet",
+			identifierKeys: true,
+			wantErr:        ErrInvalidIdentifier,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = fs
+			g.IdentifierKeys(tt.identifierKeys)
+
+			got, _, err := g.Import("", tt.importedPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestGlobImporter_WarnTrivialGlob(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{"config.libsonnet", "other.libsonnet", "lib/a.libsonnet"} {
+		if err := afero.WriteFile(fs, file, []byte("{}"), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name            string
+		warnTrivialGlob bool
+		importedFrom    string
+		importedPath    string
+		want            string
+		wantWarnings    []string
+	}{
+		{
+			name:            "literal pattern behaves like a plain import",
+			warnTrivialGlob: true,
+			importedFrom:    "main.jsonnet",
+			importedPath:    "glob+://other.libsonnet",
+			want:            "(import 'other.libsonnet')",
+			wantWarnings:    []string{"other.libsonnet"},
+		},
+		{
+			name:            "literal pattern of the importing file itself is removed",
+			warnTrivialGlob: true,
+			importedFrom:    "config.libsonnet",
+			importedPath:    "glob.stem://config.libsonnet",
+			want:            "{\n}",
+			wantWarnings:    []string{"config.libsonnet"},
+		},
+		{
+			name:            "only the literal patterns of a list",
+			warnTrivialGlob: true,
+			importedFrom:    "main.jsonnet",
+			importedPath:    "glob+://[lib/*.libsonnet, other.libsonnet]",
+			want:            "(import 'lib/a.libsonnet')+(import 'other.libsonnet')",
+			wantWarnings:    []string{"other.libsonnet"},
+		},
+		{
+			name:            "only the literal alternatives of glob.first",
+			warnTrivialGlob: true,
+			importedFrom:    "main.jsonnet",
+			importedPath:    "glob.first://missing.libsonnet|lib/*.libsonnet",
+			want:            "(import 'lib/a.libsonnet')",
+			wantWarnings:    []string{"missing.libsonnet"},
+		},
+		{
+			name:            "glob.up+ expects literal names",
+			warnTrivialGlob: true,
+			importedFrom:    "lib/a.libsonnet",
+			importedPath:    "glob.up+://config.libsonnet",
+			want:            "(import '../config.libsonnet')",
+			wantWarnings:    []string{},
+		},
+		{
+			name:         "disabled - no warnings",
+			importedFrom: "main.jsonnet",
+			importedPath: "glob+://other.libsonnet",
+			want:         "(import 'other.libsonnet')",
+			wantWarnings: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.WarnLevel)
+
+			g := NewGlobImporter()
+			g.fs = fs
+			g.Logger(zap.New(core))
+			g.WarnTrivialGlob(tt.warnTrivialGlob)
+
+			got, _, err := g.Import(tt.importedFrom, tt.importedPath)
+
+			warnings := []string{}
+			for _, entry := range logs.FilterMessage("trivial glob pattern").All() {
+				warnings = append(warnings, entry.ContextMap()["pattern"].(string))
+			}
+			assert.Equal(t, tt.wantWarnings, warnings)
+
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
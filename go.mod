@@ -9,6 +9,7 @@ require (
 	github.com/spf13/afero v1.11.0
 	github.com/stretchr/testify v1.8.1
 	go.uber.org/zap v1.27.0
+	sigs.k8s.io/yaml v1.4.0
 )
 
 require (
@@ -17,5 +18,4 @@ require (
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/text v0.20.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-	sigs.k8s.io/yaml v1.4.0 // indirect
 )
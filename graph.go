@@ -0,0 +1,719 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dominikbraun/graph"
+	"github.com/dominikbraun/graph/draw"
+)
+
+// graphRoot is the synthetic root vertex of the import graph: the entry file
+// will be imported from "" (see vm.EvaluateFile), which becomes ".".
+const graphRoot = "."
+
+// originAttribute is the edge attribute, which marks the edges added via a
+// GraphRecorder with the SourceKind, like globOrigin for the edges from a glob
+// import to its resolved files.
+const (
+	originAttribute = "origin"
+	remoteAttribute = "remote"
+	globOrigin      = string(SourceGlob)
+)
+
+// Source kinds of the vertices and edges added via a GraphRecorder.
+const (
+	SourceFile   SourceKind = "file"
+	SourceGlob   SourceKind = "glob"
+	SourceRemote SourceKind = "remote"
+)
+
+// sourceStyles are the DOT attributes per SourceKind, which render the
+// contributions of all importers with consistent colors.
+var sourceStyles = map[SourceKind]map[string]string{
+	SourceGlob:   {"shape": "rect", "style": "dashed", "color": "grey", "fontcolor": "grey"},
+	SourceRemote: {"shape": "cds", "style": "dashed", "color": "blue", "fontcolor": "blue"},
+}
+
+type (
+	// SourceKind describes where the content of a vertex comes from.
+	SourceKind string
+	// GraphMeta is the typed metadata of a vertex or edge added via a
+	// GraphRecorder.
+	GraphMeta struct {
+		// Kind selects the style; the edges store it as "origin" attribute.
+		Kind SourceKind
+		// Remote marks content fetched from a remote location; such
+		// vertices and edges will be colored like SourceRemote.
+		Remote bool
+	}
+	// GraphRecorder lets importers contribute vertices and edges to the
+	// import graph of the MultiImporter. It will be passed to each importer,
+	// which implements the GraphContributor interface, before its import.
+	// It is safe for concurrent use.
+	GraphRecorder struct {
+		mu     sync.Mutex
+		graph  graph.Graph[string, string]
+		weight int
+		// events receives the added edges (see
+		// MultiImporter.StreamImportEvents).
+		events io.Writer
+		// canonicalize maps the paths of the vertices to their canonical
+		// paths (see MultiImporter.CanonicalizePaths).
+		canonicalize bool
+	}
+	// GraphContributor is an optional interface for importers, which want to
+	// add their own vertices and edges to the import graph.
+	GraphContributor interface {
+		// SetGraphRecorder will be called before each import with the
+		// recorder for this import.
+		SetGraphRecorder(*GraphRecorder)
+	}
+
+	// importEvent is a single line of the stream of import events.
+	importEvent struct {
+		From   string `json:"from"`
+		To     string `json:"to"`
+		Weight int    `json:"weight"`
+		Origin string `json:"origin,omitempty"`
+	}
+
+	// serializedGraph is the stable JSON representation of an import graph.
+	serializedGraph struct {
+		Vertices []serializedVertex `json:"vertices"`
+		Edges    []serializedEdge   `json:"edges"`
+	}
+	serializedVertex struct {
+		Name       string            `json:"name"`
+		Weight     int               `json:"weight"`
+		Attributes map[string]string `json:"attributes,omitempty"`
+	}
+	serializedEdge struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+		Weight int    `json:"weight"`
+		// GlobExpanded is true for the edges from a glob import to its
+		// resolved files and false for direct imports.
+		GlobExpanded bool              `json:"globExpanded,omitempty"`
+		Attributes   map[string]string `json:"attributes,omitempty"`
+	}
+
+	// GraphSnapshot is a stable and comparable representation of the import
+	// graph with sorted nodes and edges. Weights and attributes are not part
+	// of it, because they depend on the order of the imports.
+	GraphSnapshot struct {
+		Nodes []string    `json:"nodes"`
+		Edges []GraphEdge `json:"edges"`
+	}
+	// GraphEdge is an import of the Target by the Source.
+	GraphEdge struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+	// GraphDiff contains the nodes and edges, which were added or removed
+	// between two GraphSnapshots.
+	GraphDiff struct {
+		AddedNodes   []string    `json:"addedNodes"`
+		RemovedNodes []string    `json:"removedNodes"`
+		AddedEdges   []GraphEdge `json:"addedEdges"`
+		RemovedEdges []GraphEdge `json:"removedEdges"`
+	}
+)
+
+// newGraphRecorder returns a GraphRecorder, which adds the edges to the given
+// graph with the given weight.
+func newGraphRecorder(g graph.Graph[string, string], weight int) *GraphRecorder {
+	return &GraphRecorder{graph: g, weight: weight}
+}
+
+// Weight returns the weight of the current import, which will be used for the
+// added edges. It increases with each import.
+func (r *GraphRecorder) Weight() int {
+	return r.weight
+}
+
+// AddNode adds a vertex styled by the given metadata. An already existing
+// vertex returns graph.ErrVertexAlreadyExists. With
+// MultiImporter.CanonicalizePaths the canonical path will be used as name,
+// except for prefixed imports like `glob+://*.libsonnet`.
+func (r *GraphRecorder) AddNode(name string, meta GraphMeta) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name = r.vertex(name)
+
+	options := []func(*graph.VertexProperties){}
+	for key, value := range meta.attributes(false) {
+		options = append(options, graph.VertexAttribute(key, value))
+	}
+
+	return r.graph.AddVertex(name, options...)
+}
+
+// AddEdge adds an edge between two existing vertices styled by the given
+// metadata and streams it, if enabled (see MultiImporter.StreamImportEvents).
+func (r *GraphRecorder) AddEdge(source, target string, meta GraphMeta) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source, target = r.vertex(source), r.vertex(target)
+
+	options := []func(*graph.EdgeProperties){graph.EdgeWeight(r.weight)}
+	for key, value := range meta.attributes(true) {
+		options = append(options, graph.EdgeAttribute(key, value))
+	}
+
+	if err := r.graph.AddEdge(source, target, options...); err != nil {
+		return err
+	}
+
+	return writeImportEvent(r.events, importEvent{
+		From: source, To: target, Weight: r.weight, Origin: string(meta.Kind),
+	})
+}
+
+// createsCycle returns true, if an edge from source to target would create a
+// cycle.
+func (r *GraphRecorder) createsCycle(source, target string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return createsCycle(r.graph, r.vertex(source), r.vertex(target))
+}
+
+// vertex returns the name of the vertex for the given path.
+func (r *GraphRecorder) vertex(name string) string {
+	if !r.canonicalize {
+		return name
+	}
+
+	return canonicalVertex(name)
+}
+
+// fileVertex returns the name of the vertex for a file imported via the
+// relative path rel from a file inside the directory basepath. With
+// canonicalized paths it is the same vertex, which the MultiImporter adds for
+// the import of the file.
+func (r *GraphRecorder) fileVertex(basepath, rel string) string {
+	if !r.canonicalize || strings.Contains(rel, "://") {
+		return rel
+	}
+
+	if !filepath.IsAbs(rel) {
+		rel = filepath.Join(basepath, rel)
+	}
+
+	return canonicalVertex(rel)
+}
+
+// writeImportEvent writes the event as single line of JSON to the writer; a
+// nil writer will be skipped.
+func writeImportEvent(w io.Writer, event importEvent) error {
+	if w == nil {
+		return nil
+	}
+
+	if err := json.NewEncoder(w).Encode(event); err != nil {
+		return fmt.Errorf("while writing the import event %s -> %s, error: %w", event.From, event.To, err)
+	}
+
+	return nil
+}
+
+// attributes returns the DOT attributes for the metadata. Edges get only the
+// color and the line style, but additionally the kind as attribute.
+func (meta GraphMeta) attributes(edge bool) map[string]string {
+	style := sourceStyles[meta.Kind]
+	if meta.Remote {
+		style = sourceStyles[SourceRemote]
+	}
+
+	attributes := map[string]string{}
+	for key, value := range style {
+		if edge && key != "color" && key != "style" {
+			continue
+		}
+		attributes[key] = value
+	}
+
+	if edge && meta.Kind != "" {
+		attributes[originAttribute] = string(meta.Kind)
+	}
+
+	if meta.Remote {
+		attributes[remoteAttribute] = "true"
+	}
+
+	return attributes
+}
+
+// MarshalGraph returns the import graph as JSON including the vertices, edges,
+// weights and attributes. Vertices and edges are sorted to get a stable output.
+// Edges from a glob import to its resolved files are marked via
+// `"globExpanded": true`.
+func (m *MultiImporter) MarshalGraph() ([]byte, error) {
+	out, err := m.serializeGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(out)
+}
+
+// serializeGraph returns the import graph with sorted vertices and edges.
+func (m *MultiImporter) serializeGraph() (serializedGraph, error) {
+	return serialize(m.importGraph)
+}
+
+// serialize returns the given graph with sorted vertices and edges.
+func serialize(g graph.Graph[string, string]) (serializedGraph, error) {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return serializedGraph{}, fmt.Errorf("while marshaling the import graph, error: %w", err)
+	}
+
+	out := serializedGraph{Vertices: []serializedVertex{}, Edges: []serializedEdge{}}
+
+	for _, vertex := range stringKeysFromMap(adjacencyMap) {
+		_, properties, err := g.VertexWithProperties(vertex)
+		if err != nil {
+			return serializedGraph{}, fmt.Errorf("while marshaling the import graph, error: %w", err)
+		}
+
+		out.Vertices = append(out.Vertices, serializedVertex{
+			Name: vertex, Weight: properties.Weight, Attributes: properties.Attributes,
+		})
+
+		for target, edge := range adjacencyMap[vertex] {
+			out.Edges = append(out.Edges, serializedEdge{
+				Source: vertex, Target: target,
+				Weight:       edge.Properties.Weight,
+				GlobExpanded: edge.Properties.Attributes[originAttribute] == globOrigin,
+				Attributes:   edge.Properties.Attributes,
+			})
+		}
+	}
+
+	sort.Slice(out.Vertices, func(i, j int) bool { return out.Vertices[i].Name < out.Vertices[j].Name })
+	sort.Slice(out.Edges, func(i, j int) bool {
+		if out.Edges[i].Source != out.Edges[j].Source {
+			return out.Edges[i].Source < out.Edges[j].Source
+		}
+
+		return out.Edges[i].Target < out.Edges[j].Target
+	})
+
+	return out, nil
+}
+
+// WriteSubgraph writes only the part of the import graph, which is reachable
+// from the given root file, to w - for example to debug the dependencies of a
+// single file inside a huge import graph. The format is either "dot" for the
+// DOT language (like the import graph file) or "json" for the JSON
+// representation of MarshalGraph. A root, which is not part of the import
+// graph, returns an ErrUnknownVertex error.
+// Example: `err := m.WriteSubgraph(os.Stdout, "problem.libsonnet", "dot")`
+func (m *MultiImporter) WriteSubgraph(w io.Writer, root, format string) error {
+	sub, err := subgraph(m.importGraph, root)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "dot":
+		if err := draw.DOT(sub, w); err != nil {
+			return fmt.Errorf("while writing the subgraph of '%s', error: %w", root, err)
+		}
+	case "json":
+		out, err := serialize(sub)
+		if err != nil {
+			return err
+		}
+
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			return fmt.Errorf("while writing the subgraph of '%s', error: %w", root, err)
+		}
+	default:
+		return fmt.Errorf("%w: '%s', supported are 'dot' or 'json'", ErrUnknownGraphFormat, format)
+	}
+
+	return nil
+}
+
+// subgraph returns a copy of the given graph with only the vertices, which
+// are reachable from root (including root itself), and the edges between them.
+func subgraph(g graph.Graph[string, string], root string) (graph.Graph[string, string], error) {
+	if _, err := g.Vertex(root); err != nil {
+		return nil, fmt.Errorf("%w: '%s' is not part of the import graph", ErrUnknownVertex, root)
+	}
+
+	reachable := map[string]bool{}
+	if err := graph.BFS(g, root, func(vertex string) bool {
+		reachable[vertex] = true
+
+		return false
+	}); err != nil {
+		return nil, fmt.Errorf("while walking the import graph from '%s', error: %w", root, err)
+	}
+
+	sub := graph.New(graph.StringHash, graph.Directed(), graph.Weighted())
+
+	for vertex := range reachable {
+		_, properties, err := g.VertexWithProperties(vertex)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := sub.AddVertex(vertex,
+			graph.VertexAttributes(maps.Clone(properties.Attributes)), graph.VertexWeight(properties.Weight),
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	edges, err := g.Edges()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, edge := range edges {
+		if !reachable[edge.Source] || !reachable[edge.Target] {
+			continue
+		}
+
+		if err := sub.AddEdge(edge.Source, edge.Target,
+			graph.EdgeAttributes(maps.Clone(edge.Properties.Attributes)), graph.EdgeWeight(edge.Properties.Weight),
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	return sub, nil
+}
+
+// OnComplete registers a hook, which will be executed against the final
+// import graph by RunCompletionHooks, for example to enforce project-specific
+// dependency policies like "no file may be imported by more than 10 others".
+// The graph contains the synthetic root vertex "." in front of the entry file.
+// Each hook gets its own copy of the graph, so that changes of a hook affect
+// neither the import graph nor the other hooks.
+func (m *MultiImporter) OnComplete(fn func(g graph.Graph[string, string]) error) {
+	if fn != nil {
+		m.completionHooks = append(m.completionHooks, fn)
+	}
+}
+
+// RunCompletionHooks executes all hooks registered via OnComplete in their
+// order against the current import graph. go-jsonnet does not notify the
+// importer at the end of an evaluation, therefore call it after the
+// evaluation. All hooks will be executed; their errors will be returned
+// together.
+func (m *MultiImporter) RunCompletionHooks() error {
+	errs := []error{}
+
+	for i, hook := range m.completionHooks {
+		clone, err := m.importGraph.Clone()
+		if err != nil {
+			return fmt.Errorf("while copying the import graph for completion hook %d, error: %w", i, err)
+		}
+
+		if err := hook(clone); err != nil {
+			errs = append(errs, fmt.Errorf("completion hook %d: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GraphSnapshot returns the current import graph as GraphSnapshot, for
+// example to store it as baseline and to compare it with the graph of a
+// later evaluation via DiffGraphSnapshots. An unreadable graph returns an
+// empty snapshot.
+func (m *MultiImporter) GraphSnapshot() GraphSnapshot {
+	snapshot := GraphSnapshot{Nodes: []string{}, Edges: []GraphEdge{}}
+
+	serialized, err := m.serializeGraph()
+	if err != nil {
+		return snapshot
+	}
+
+	for _, v := range serialized.Vertices {
+		snapshot.Nodes = append(snapshot.Nodes, v.Name)
+	}
+
+	for _, e := range serialized.Edges {
+		snapshot.Edges = append(snapshot.Edges, GraphEdge{Source: e.Source, Target: e.Target})
+	}
+
+	return snapshot
+}
+
+// DiffGraphSnapshots returns the nodes and edges, which are only in b (added)
+// or only in a (removed). The order of the nodes and edges inside the
+// snapshots does not matter; the results are sorted.
+func DiffGraphSnapshots(a, b GraphSnapshot) GraphDiff {
+	diff := GraphDiff{
+		AddedNodes:   onlyIn(b.Nodes, a.Nodes),
+		RemovedNodes: onlyIn(a.Nodes, b.Nodes),
+		AddedEdges:   onlyIn(b.Edges, a.Edges),
+		RemovedEdges: onlyIn(a.Edges, b.Edges),
+	}
+
+	sort.Strings(diff.AddedNodes)
+	sort.Strings(diff.RemovedNodes)
+	sortEdges(diff.AddedEdges)
+	sortEdges(diff.RemovedEdges)
+
+	return diff
+}
+
+// IsEmpty returns true, if no nodes or edges were added or removed.
+func (d GraphDiff) IsEmpty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0
+}
+
+// onlyIn returns the unique items of a, which are not part of b.
+func onlyIn[T comparable](a, b []T) []T {
+	inB := make(map[T]bool, len(b))
+	for _, item := range b {
+		inB[item] = true
+	}
+
+	result := []T{}
+
+	for _, item := range a {
+		if !inB[item] {
+			inB[item] = true
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// sortEdges sorts the edges by their source and target.
+func sortEdges(edges []GraphEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+
+		return edges[i].Target < edges[j].Target
+	})
+}
+
+// LoadGraph replaces the import graph with the one from the given JSON (see
+// MarshalGraph). The cycle detection and the import graph file will continue
+// with the loaded state. The loading does not validate, if the files in the
+// graph still exist.
+func (m *MultiImporter) LoadGraph(data []byte) error {
+	var in serializedGraph
+	if err := json.Unmarshal(data, &in); err != nil {
+		return fmt.Errorf("while loading the import graph, error: %w", err)
+	}
+
+	importGraph := graph.New(
+		graph.StringHash, graph.Tree(), graph.Directed(), graph.Weighted(),
+	)
+
+	for _, v := range in.Vertices {
+		attributes := v.Attributes
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+
+		if err := importGraph.AddVertex(v.Name,
+			graph.VertexAttributes(attributes), graph.VertexWeight(v.Weight),
+		); err != nil {
+			return fmt.Errorf("while loading the vertex '%s' of the import graph, error: %w", v.Name, err)
+		}
+	}
+
+	for _, e := range in.Edges {
+		attributes := e.Attributes
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+
+		if err := importGraph.AddEdge(e.Source, e.Target,
+			graph.EdgeAttributes(attributes), graph.EdgeWeight(e.Weight),
+		); err != nil {
+			return fmt.Errorf("while loading the edge '%s' -> '%s' of the import graph, error: %w",
+				e.Source, e.Target, err)
+		}
+	}
+
+	m.importGraph = importGraph
+
+	return nil
+}
+
+// longestPath returns the vertices of the longest path (by number of edges)
+// inside the given graph. An empty graph or a graph with cycles returns nil,
+// because a longest path is undefined there.
+func longestPath(g graph.Graph[string, string]) []string {
+	order, err := graph.StableTopologicalSort(g, func(a, b string) bool { return a < b })
+	if err != nil || len(order) == 0 {
+		return nil
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil
+	}
+
+	distances := map[string]int{}
+	predecessors := map[string]string{}
+	end := order[0]
+
+	for _, vertex := range order {
+		if distances[vertex] > distances[end] {
+			end = vertex
+		}
+
+		targets := make([]string, 0, len(adjacencyMap[vertex]))
+		for target := range adjacencyMap[vertex] {
+			targets = append(targets, target)
+		}
+
+		sort.Strings(targets)
+
+		for _, target := range targets {
+			if distances[vertex]+1 > distances[target] {
+				distances[target] = distances[vertex] + 1
+				predecessors[target] = vertex
+			}
+		}
+	}
+
+	path := []string{end}
+
+	for {
+		predecessor, exists := predecessors[path[0]]
+		if !exists {
+			break
+		}
+
+		path = append([]string{predecessor}, path...)
+	}
+
+	return path
+}
+
+// highlightLongestPath returns a copy of the given graph, where the vertices
+// and edges of the longest path are colored in bold orange. The given graph
+// will be returned unchanged, if no longest path exists.
+func highlightLongestPath(g graph.Graph[string, string]) (graph.Graph[string, string], error) {
+	path := longestPath(g)
+	if len(path) < 2 {
+		return g, nil
+	}
+
+	onPath := map[string]bool{}
+	nextOnPath := map[string]string{}
+
+	for i, vertex := range path {
+		onPath[vertex] = true
+		if i+1 < len(path) {
+			nextOnPath[vertex] = path[i+1]
+		}
+	}
+
+	highlighted := graph.New(graph.StringHash, graph.Directed(), graph.Weighted())
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return g, err
+	}
+
+	for vertex := range adjacencyMap {
+		_, properties, err := g.VertexWithProperties(vertex)
+		if err != nil {
+			return g, err
+		}
+
+		attributes := maps.Clone(properties.Attributes)
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+
+		if onPath[vertex] {
+			attributes["color"] = "orange"
+			attributes["style"] = "bold"
+		}
+
+		if err := highlighted.AddVertex(vertex,
+			graph.VertexAttributes(attributes), graph.VertexWeight(properties.Weight),
+		); err != nil {
+			return g, err
+		}
+	}
+
+	edges, err := g.Edges()
+	if err != nil {
+		return g, err
+	}
+
+	for _, edge := range edges {
+		attributes := maps.Clone(edge.Properties.Attributes)
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+
+		if next, exists := nextOnPath[edge.Source]; exists && next == edge.Target {
+			attributes["color"] = "orange"
+			attributes["style"] = "bold"
+		}
+
+		if err := highlighted.AddEdge(edge.Source, edge.Target,
+			graph.EdgeAttributes(attributes), graph.EdgeWeight(edge.Properties.Weight),
+		); err != nil {
+			return g, err
+		}
+	}
+
+	return highlighted, nil
+}
+
+// withoutRoot returns a copy of the given graph without the graphRoot vertex
+// and its edges. The given graph will be returned unchanged, if it has no
+// graphRoot vertex.
+func withoutRoot(g graph.Graph[string, string]) (graph.Graph[string, string], error) {
+	if _, err := g.Vertex(graphRoot); err != nil {
+		return g, nil
+	}
+
+	clone, err := g.Clone()
+	if err != nil {
+		return g, err
+	}
+
+	edges, err := clone.Edges()
+	if err != nil {
+		return g, err
+	}
+
+	for _, edge := range edges {
+		if edge.Source != graphRoot && edge.Target != graphRoot {
+			continue
+		}
+
+		if err := clone.RemoveEdge(edge.Source, edge.Target); err != nil {
+			return g, err
+		}
+	}
+
+	if err := clone.RemoveVertex(graphRoot); err != nil {
+		return g, err
+	}
+
+	return clone, nil
+}
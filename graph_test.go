@@ -0,0 +1,667 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dominikbraun/graph"
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLongestPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph graph.Graph[string, string]
+		want  []string
+	}{
+		{
+			name:  "empty_graph",
+			graph: graph.New(graph.StringHash, graph.Directed()),
+			want:  nil,
+		},
+		{
+			name: "longest_of_two_branches",
+			//
+			// [caller.jsonnet] --> [a.libsonnet]
+			//          \
+			//           +--> [b.libsonnet] --> [c.libsonnet]
+			graph: addRelativesToGraph(
+				addRelativesToGraph(
+					createGraph("caller.jsonnet", "a.libsonnet", 0, false),
+					"caller.jsonnet", "b.libsonnet", 0, false,
+				),
+				"b.libsonnet", "c.libsonnet", 0, false,
+			),
+			want: []string{"caller.jsonnet", "b.libsonnet", "c.libsonnet"},
+		},
+		{
+			name: "graph_with_cycle",
+			graph: func() graph.Graph[string, string] {
+				g := graph.New(graph.StringHash, graph.Directed())
+				_ = g.AddVertex("a")
+				_ = g.AddVertex("b")
+				_ = g.AddEdge("a", "b")
+				_ = g.AddEdge("b", "a")
+				return g
+			}(),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, longestPath(tt.graph))
+		})
+	}
+}
+
+func TestHighlightLongestPath(t *testing.T) {
+	g := addRelativesToGraph(
+		addRelativesToGraph(
+			createGraph("caller.jsonnet", "a.libsonnet", 0, false),
+			"caller.jsonnet", "b.libsonnet", 0, false,
+		),
+		"b.libsonnet", "c.libsonnet", 0, false,
+	)
+
+	got, err := highlightLongestPath(g)
+	if err != nil {
+		t.Errorf("highlightLongestPath() error = %v", err)
+		return
+	}
+
+	_, properties, _ := got.VertexWithProperties("b.libsonnet")
+	assert.Equal(t, "orange", properties.Attributes["color"])
+	_, properties, _ = got.VertexWithProperties("a.libsonnet")
+	assert.Empty(t, properties.Attributes["color"])
+
+	edge, _ := got.Edge("b.libsonnet", "c.libsonnet")
+	assert.Equal(t, "orange", edge.Properties.Attributes["color"])
+	edge, _ = got.Edge("caller.jsonnet", "a.libsonnet")
+	assert.Empty(t, edge.Properties.Attributes["color"])
+
+	// the original graph must not be changed
+	_, properties, _ = g.VertexWithProperties("b.libsonnet")
+	assert.Empty(t, properties.Attributes["color"])
+}
+
+func TestMultiImporter_MarshalGraph(t *testing.T) {
+	m := NewMultiImporter()
+	m.importGraph = addRelativesToGraph(
+		createGraph("caller.jsonnet", "host.libsonnet", 1, false),
+		"host.libsonnet", "testdata/host.libsonnet", 2, false,
+	)
+
+	data, err := m.MarshalGraph()
+	if err != nil {
+		t.Errorf("MultiImporter.MarshalGraph() error = %v", err)
+		return
+	}
+	assert.JSONEq(t, `{
+		"vertices": [
+			{"name": "caller.jsonnet", "weight": 0, "attributes": {"shape": "invhouse"}},
+			{"name": "host.libsonnet", "weight": 0, "attributes": {"shape": "house"}},
+			{"name": "testdata/host.libsonnet", "weight": 0}
+		],
+		"edges": [
+			{"source": "caller.jsonnet", "target": "host.libsonnet", "weight": 1},
+			{"source": "host.libsonnet", "target": "testdata/host.libsonnet", "weight": 2}
+		]
+	}`, string(data))
+
+	loaded := NewMultiImporter()
+	loaded.fs = afero.NewMemMapFs()
+	if err := loaded.LoadGraph(data); err != nil {
+		t.Errorf("MultiImporter.LoadGraph() error = %v", err)
+		return
+	}
+
+	want, _ := m.importGraph.AdjacencyMap()
+	got, _ := loaded.importGraph.AdjacencyMap()
+	assert.Equal(t, want, got)
+
+	// the cycle detection continues with the loaded state
+	err = loaded.findImportCycle("testdata/host.libsonnet", "caller.jsonnet")
+	assert.ErrorIs(t, err, ErrImportCycle)
+}
+
+func TestMultiImporter_MarshalGraphGlobExpanded(t *testing.T) {
+	g := NewGlobImporter()
+	g.fs = afero.NewMemMapFs()
+	if err := afero.WriteFile(g.fs, "libs/host.libsonnet", []byte("{}"), 0o644); err != nil {
+		t.Errorf("afero.WriteFile() error = %v", err)
+		return
+	}
+
+	m := NewMultiImporter(g, NewFallbackFileImporter())
+	m.fs = afero.NewMemMapFs()
+
+	if _, _, err := m.Import("main.jsonnet", "glob+://libs/*.libsonnet"); err != nil {
+		t.Errorf("MultiImporter.Import() error = %v", err)
+		return
+	}
+
+	// a direct import
+	assert.NoError(t, m.findImportCycle("main.jsonnet", "other.libsonnet"))
+
+	data, err := m.MarshalGraph()
+	if err != nil {
+		t.Errorf("MultiImporter.MarshalGraph() error = %v", err)
+		return
+	}
+
+	var got serializedGraph
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("json.Unmarshal() error = %v", err)
+		return
+	}
+
+	expanded := map[string]bool{}
+	for _, e := range got.Edges {
+		expanded[e.Source+" -> "+e.Target] = e.GlobExpanded
+	}
+	assert.Equal(t, map[string]bool{
+		"main.jsonnet -> other.libsonnet":                 false,
+		"glob+://libs/*.libsonnet -> libs/host.libsonnet": true,
+	}, expanded)
+}
+
+func TestGraphRecorder(t *testing.T) {
+	tests := []struct {
+		name           string
+		meta           GraphMeta
+		wantVertex     map[string]string
+		wantEdge       map[string]string
+		wantEdgeWeight int
+	}{
+		{
+			name:       "file",
+			meta:       GraphMeta{Kind: SourceFile},
+			wantVertex: map[string]string{},
+			wantEdge:   map[string]string{"origin": "file"},
+		},
+		{
+			name:       "glob",
+			meta:       GraphMeta{Kind: SourceGlob},
+			wantVertex: map[string]string{"shape": "rect", "style": "dashed", "color": "grey", "fontcolor": "grey"},
+			wantEdge:   map[string]string{"style": "dashed", "color": "grey", "origin": "glob"},
+		},
+		{
+			name: "remote flag overrides the style of the kind",
+			meta: GraphMeta{Kind: SourceFile, Remote: true},
+			wantVertex: map[string]string{
+				"shape": "cds", "style": "dashed", "color": "blue", "fontcolor": "blue", "remote": "true",
+			},
+			wantEdge: map[string]string{"style": "dashed", "color": "blue", "origin": "file", "remote": "true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newGraphRecorder(graph.New(graph.StringHash, graph.Directed()), 7)
+			assert.Equal(t, 7, r.Weight())
+
+			assert.NoError(t, r.AddNode("a", tt.meta))
+			assert.NoError(t, r.AddNode("b", tt.meta))
+			assert.ErrorIs(t, r.AddNode("a", tt.meta), graph.ErrVertexAlreadyExists)
+			assert.NoError(t, r.AddEdge("a", "b", tt.meta))
+
+			_, properties, err := r.graph.VertexWithProperties("a")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVertex, properties.Attributes)
+
+			edge, err := r.graph.Edge("a", "b")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantEdge, edge.Properties.Attributes)
+			assert.Equal(t, 7, edge.Properties.Weight)
+		})
+	}
+}
+
+// contributingImporter is a custom importer, which adds a remote vertex per
+// import to the import graph.
+type contributingImporter struct {
+	recorder *GraphRecorder
+}
+
+func (c *contributingImporter) SetGraphRecorder(recorder *GraphRecorder) { c.recorder = recorder }
+func (c *contributingImporter) CanHandle(prefix string) bool             { return prefix == "remote" }
+func (c *contributingImporter) Logger(_ *zap.Logger)                     {}
+func (c *contributingImporter) Prefixa() []string                        { return []string{"remote"} }
+
+func (c *contributingImporter) Import(_, importedPath string) (jsonnet.Contents, string, error) {
+	_ = c.recorder.AddNode(importedPath, GraphMeta{Kind: SourceRemote, Remote: true})
+
+	return jsonnet.MakeContents("{}"), importedPath, nil
+}
+
+func TestMultiImporter_GraphContributor(t *testing.T) {
+	c := &contributingImporter{}
+	m := NewMultiImporter(c, NewFallbackFileImporter())
+
+	if _, _, err := m.Import("main.jsonnet", "remote://example.com/lib.libsonnet"); err != nil {
+		t.Errorf("MultiImporter.Import() error = %v", err)
+		return
+	}
+
+	_, properties, err := m.importGraph.VertexWithProperties("remote://example.com/lib.libsonnet")
+	assert.NoError(t, err)
+	assert.Equal(t, "true", properties.Attributes["remote"])
+	assert.Equal(t, "blue", properties.Attributes["color"])
+}
+
+func TestMultiImporter_StreamImportEvents(t *testing.T) {
+	g := NewGlobImporter()
+	g.fs = afero.NewMemMapFs()
+	if err := afero.WriteFile(g.fs, "libs/host.libsonnet", []byte("{}"), 0o644); err != nil {
+		t.Errorf("afero.WriteFile() error = %v", err)
+		return
+	}
+
+	m := NewMultiImporter(g, NewFallbackFileImporter())
+	m.fs = afero.NewMemMapFs()
+
+	var events strings.Builder
+	m.StreamImportEvents(&events)
+
+	if _, _, err := m.Import("main.jsonnet", "glob+://libs/*.libsonnet"); err != nil {
+		t.Errorf("MultiImporter.Import() error = %v", err)
+		return
+	}
+	assert.NoError(t, m.findImportCycle("sub/main.jsonnet", "other.libsonnet"))
+	// an existing edge will not be streamed again
+	assert.NoError(t, m.findImportCycle("sub/main.jsonnet", "other.libsonnet"))
+
+	assert.Equal(t, `{"from":"glob+://libs/*.libsonnet","to":"libs/host.libsonnet","weight":1,"origin":"glob"}
+{"from":"sub/main.jsonnet","to":"other.libsonnet","weight":1}
+{"from":"other.libsonnet","to":"sub/other.libsonnet","weight":1}
+`, events.String())
+
+	// disabled again
+	m.StreamImportEvents(nil)
+	assert.NoError(t, m.findImportCycle("main.jsonnet", "next.libsonnet"))
+	assert.Equal(t, 3, strings.Count(events.String(), "\n"))
+}
+
+func TestMultiImporter_LoadGraph_malformed(t *testing.T) {
+	m := NewMultiImporter()
+	assert.Error(t, m.LoadGraph([]byte("{")))
+	assert.Error(t, m.LoadGraph([]byte(`{"edges": [{"source": "a", "target": "b"}]}`)))
+}
+
+func TestWithoutRoot(t *testing.T) {
+	g := addRelativesToGraph(
+		createGraph(graphRoot, "main.jsonnet", 0, false),
+		"main.jsonnet", "host.libsonnet", 1, false,
+	)
+
+	got, err := withoutRoot(g)
+	if err != nil {
+		t.Errorf("withoutRoot() error = %v", err)
+		return
+	}
+
+	adjacencyMap, _ := got.AdjacencyMap()
+	assert.NotContains(t, adjacencyMap, graphRoot)
+	assert.Contains(t, adjacencyMap["main.jsonnet"], "host.libsonnet")
+
+	// the original graph must not be changed
+	_, err = g.Vertex(graphRoot)
+	assert.NoError(t, err)
+
+	// a graph without root will be returned unchanged
+	noRoot := createGraph("main.jsonnet", "host.libsonnet", 0, false)
+	got, err = withoutRoot(noRoot)
+	assert.NoError(t, err)
+	assert.Equal(t, noRoot, got)
+}
+
+func TestMultiImporter_HideGraphRoot(t *testing.T) {
+	tests := []struct {
+		name          string
+		hideGraphRoot bool
+		wantRoot      bool
+	}{
+		{
+			name:     "default keeps the root",
+			wantRoot: true,
+		},
+		{
+			name:          "hidden root",
+			hideGraphRoot: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter()
+			m.fs = afero.NewMemMapFs()
+			m.HideGraphRoot(tt.hideGraphRoot)
+
+			// the entry file will be imported from ""
+			assert.NoError(t, m.findImportCycle("", "main.jsonnet"))
+			assert.NoError(t, m.findImportCycle("main.jsonnet", "host.libsonnet"))
+			assert.NoError(t, m.storeImportGraph())
+
+			got, err := afero.ReadFile(m.fs, importGraphFileName)
+			if err != nil {
+				t.Errorf("afero.ReadFile() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.wantRoot, strings.Contains(string(got), `"."`))
+			assert.Contains(t, string(got), `"main.jsonnet" -> "host.libsonnet"`)
+
+			// the root stays inside the graph for the cycle detection
+			_, err = m.importGraph.Vertex(graphRoot)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMultiImporter_WriteSubgraph(t *testing.T) {
+	m := NewMultiImporter()
+	m.fs = afero.NewMemMapFs()
+
+	assert.NoError(t, m.findImportCycle("", "main.jsonnet"))
+	assert.NoError(t, m.findImportCycle("main.jsonnet", "ok.libsonnet"))
+	assert.NoError(t, m.findImportCycle("main.jsonnet", "problem.libsonnet"))
+	assert.NoError(t, m.findImportCycle("problem.libsonnet", "dep.libsonnet"))
+
+	tests := []struct {
+		name    string
+		root    string
+		format  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "json subgraph of the problem",
+			root:   "problem.libsonnet",
+			format: "json",
+			want: `{
+				"vertices": [
+					{"name": "dep.libsonnet", "weight": 0, "attributes": {"shape": "house"}},
+					{"name": "problem.libsonnet", "weight": 0, "attributes": {"shape": "house"}}
+				],
+				"edges": [
+					{"source": "problem.libsonnet", "target": "dep.libsonnet", "weight": 0}
+				]
+			}`,
+		},
+		{
+			name:    "unknown root - should return error",
+			root:    "missing.libsonnet",
+			format:  "dot",
+			wantErr: ErrUnknownVertex,
+		},
+		{
+			name:    "unknown format - should return error",
+			root:    "problem.libsonnet",
+			format:  "svg",
+			wantErr: ErrUnknownGraphFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &strings.Builder{}
+			err := m.WriteSubgraph(out, tt.root, tt.format)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.JSONEq(t, tt.want, out.String())
+		})
+	}
+
+	// the DOT output contains only the reachable vertices
+	out := &strings.Builder{}
+	assert.NoError(t, m.WriteSubgraph(out, "problem.libsonnet", "dot"))
+	assert.Contains(t, out.String(), `"problem.libsonnet" -> "dep.libsonnet"`)
+	assert.NotContains(t, out.String(), "main.jsonnet")
+	assert.NotContains(t, out.String(), "ok.libsonnet")
+}
+
+func TestMultiImporter_StoreGraphOnErrorOnly(t *testing.T) {
+	tests := []struct {
+		name             string
+		graphOnErrorOnly bool
+		importedPath     string
+		wantErr          bool
+		wantGraph        bool
+	}{
+		{
+			name:         "default stores the graph for every import",
+			importedPath: "host.libsonnet",
+			wantGraph:    true,
+		},
+		{
+			name:             "no graph for successful imports",
+			graphOnErrorOnly: true,
+			importedPath:     "host.libsonnet",
+		},
+		{
+			name:             "graph for failed imports",
+			graphOnErrorOnly: true,
+			importedPath:     "glob://missing/*.libsonnet",
+			wantErr:          true,
+			wantGraph:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGlobImporter()
+			g.fs = afero.NewMemMapFs()
+
+			m := NewMultiImporter(g, NewFallbackFileImporter())
+			m.fs = afero.NewMemMapFs()
+			m.SetImportGraphFile(importGraphFileName)
+			m.StoreGraphOnErrorOnly(tt.graphOnErrorOnly)
+
+			_, _, err := m.Import("testdata/globDot/caller_dot_path.jsonnet", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MultiImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			exists, err := afero.Exists(m.fs, importGraphFileName)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantGraph, exists)
+		})
+	}
+}
+
+func TestMultiImporter_StoreGraphOnErrorOnlyLogsError(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+
+	g := NewGlobImporter()
+	g.fs = afero.NewMemMapFs()
+
+	m := NewMultiImporter(g, NewFallbackFileImporter())
+	m.Logger(zap.New(core))
+	m.fs = afero.NewReadOnlyFs(afero.NewMemMapFs())
+	m.SetImportGraphFile(importGraphFileName)
+	m.StoreGraphOnErrorOnly(true)
+
+	_, _, err := m.Import("testdata/globDot/caller_dot_path.jsonnet", "glob.stem://missing/*.libsonnet")
+	assert.ErrorIs(t, err, ErrEmptyResult, "the error of the import is kept")
+
+	warnings := logs.FilterMessage("while storing the import graph of a failed import").All()
+	if assert.Len(t, warnings, 1) {
+		assert.Contains(t, warnings[0].ContextMap()["error"], importGraphFileName)
+	}
+}
+
+func TestMultiImporter_RunCompletionHooks(t *testing.T) {
+	m := NewMultiImporter()
+	assert.NoError(t, m.RunCompletionHooks(), "without hooks")
+
+	assert.NoError(t, m.findImportCycle("", "main.jsonnet"))
+	assert.NoError(t, m.findImportCycle("main.jsonnet", "a.libsonnet"))
+	assert.NoError(t, m.findImportCycle("main.jsonnet", "shared.libsonnet"))
+	assert.NoError(t, m.findImportCycle("a.libsonnet", "shared.libsonnet"))
+
+	// maxImporters is a dependency policy on the final graph
+	maxImporters := func(limit int) func(g graph.Graph[string, string]) error {
+		return func(g graph.Graph[string, string]) error {
+			predecessors, err := g.PredecessorMap()
+			if err != nil {
+				return err
+			}
+			for vertex, importers := range predecessors {
+				if len(importers) > limit {
+					return fmt.Errorf("'%s' is imported by %d files", vertex, len(importers))
+				}
+			}
+			return nil
+		}
+	}
+
+	m.OnComplete(maxImporters(2))
+	m.OnComplete(nil)
+	assert.NoError(t, m.RunCompletionHooks())
+
+	m.OnComplete(maxImporters(1))
+	m.OnComplete(func(g graph.Graph[string, string]) error {
+		if _, err := g.Vertex("b.libsonnet"); err != nil {
+			return errors.New("'b.libsonnet' is never imported")
+		}
+		return nil
+	})
+
+	err := m.RunCompletionHooks()
+	assert.ErrorContains(t, err, "completion hook 1: 'shared.libsonnet' is imported by 2 files")
+	assert.ErrorContains(t, err, "completion hook 2: 'b.libsonnet' is never imported")
+
+	// hooks work on copies of the import graph
+	hooks := NewMultiImporter()
+	assert.NoError(t, hooks.findImportCycle("", "main.jsonnet"))
+
+	hooks.OnComplete(func(g graph.Graph[string, string]) error {
+		return g.AddVertex("injected.libsonnet")
+	})
+	hooks.OnComplete(func(g graph.Graph[string, string]) error {
+		if _, err := g.Vertex("injected.libsonnet"); err == nil {
+			return errors.New("'injected.libsonnet' of the previous hook is visible")
+		}
+		return nil
+	})
+	assert.NoError(t, hooks.RunCompletionHooks())
+
+	_, err = hooks.importGraph.Vertex("injected.libsonnet")
+	assert.ErrorIs(t, err, graph.ErrVertexNotFound)
+}
+
+func TestMultiImporter_GraphSnapshot(t *testing.T) {
+	imports := [][2]string{
+		{"", "main.jsonnet"},
+		{"main.jsonnet", "b.libsonnet"},
+		{"main.jsonnet", "a.libsonnet"},
+	}
+
+	first := NewMultiImporter()
+	for _, i := range imports {
+		assert.NoError(t, first.findImportCycle(i[0], i[1]))
+	}
+
+	// the same imports in another order result in the same snapshot
+	second := NewMultiImporter()
+	second.importCounter = 10
+	for idx := len(imports) - 1; idx >= 0; idx-- {
+		assert.NoError(t, second.findImportCycle(imports[idx][0], imports[idx][1]))
+	}
+
+	want := GraphSnapshot{
+		Nodes: []string{".", "a.libsonnet", "b.libsonnet", "main.jsonnet"},
+		Edges: []GraphEdge{
+			{Source: ".", Target: "main.jsonnet"},
+			{Source: "main.jsonnet", Target: "a.libsonnet"},
+			{Source: "main.jsonnet", Target: "b.libsonnet"},
+		},
+	}
+	assert.Equal(t, want, first.GraphSnapshot())
+	assert.Equal(t, want, second.GraphSnapshot())
+	assert.Equal(t, GraphSnapshot{Nodes: []string{}, Edges: []GraphEdge{}}, NewMultiImporter().GraphSnapshot())
+}
+
+func TestDiffGraphSnapshots(t *testing.T) {
+	baseline := GraphSnapshot{
+		Nodes: []string{"main.jsonnet", "a.libsonnet", "b.libsonnet"},
+		Edges: []GraphEdge{
+			{Source: "main.jsonnet", Target: "a.libsonnet"},
+			{Source: "main.jsonnet", Target: "b.libsonnet"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		a         GraphSnapshot
+		b         GraphSnapshot
+		want      GraphDiff
+		wantEmpty bool
+	}{
+		{
+			name: "same snapshots in another order",
+			a:    baseline,
+			b: GraphSnapshot{
+				Nodes: []string{"b.libsonnet", "a.libsonnet", "main.jsonnet"},
+				Edges: []GraphEdge{
+					{Source: "main.jsonnet", Target: "b.libsonnet"},
+					{Source: "main.jsonnet", Target: "a.libsonnet"},
+				},
+			},
+			want: GraphDiff{
+				AddedNodes: []string{}, RemovedNodes: []string{}, AddedEdges: []GraphEdge{}, RemovedEdges: []GraphEdge{},
+			},
+			wantEmpty: true,
+		},
+		{
+			name: "added and removed dependencies",
+			a:    baseline,
+			b: GraphSnapshot{
+				Nodes: []string{"main.jsonnet", "a.libsonnet", "d.libsonnet", "c.libsonnet"},
+				Edges: []GraphEdge{
+					{Source: "main.jsonnet", Target: "a.libsonnet"},
+					{Source: "a.libsonnet", Target: "d.libsonnet"},
+					{Source: "a.libsonnet", Target: "c.libsonnet"},
+				},
+			},
+			want: GraphDiff{
+				AddedNodes:   []string{"c.libsonnet", "d.libsonnet"},
+				RemovedNodes: []string{"b.libsonnet"},
+				AddedEdges: []GraphEdge{
+					{Source: "a.libsonnet", Target: "c.libsonnet"},
+					{Source: "a.libsonnet", Target: "d.libsonnet"},
+				},
+				RemovedEdges: []GraphEdge{{Source: "main.jsonnet", Target: "b.libsonnet"}},
+			},
+		},
+		{
+			name: "new edge between known nodes",
+			a:    baseline,
+			b: GraphSnapshot{
+				Nodes: baseline.Nodes,
+				Edges: append([]GraphEdge{{Source: "a.libsonnet", Target: "b.libsonnet"}}, baseline.Edges...),
+			},
+			want: GraphDiff{
+				AddedNodes:   []string{},
+				RemovedNodes: []string{},
+				AddedEdges:   []GraphEdge{{Source: "a.libsonnet", Target: "b.libsonnet"}},
+				RemovedEdges: []GraphEdge{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffGraphSnapshots(tt.a, tt.b)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantEmpty, got.IsEmpty())
+		})
+	}
+}
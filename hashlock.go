@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// importLock is the content of the lock file written by WriteLockFile.
+type importLock struct {
+	// Files maps the path of each imported file, relative to the directory
+	// of the lock file, to the hex encoded SHA-256 hash of its content.
+	Files map[string]string `json:"files"`
+}
+
+// WriteLockFile writes a lock file with the path and the SHA-256 hash of the
+// content of every file imported so far (usually after the evaluation) to the
+// given path. Only real files will be locked; the generated contents of
+// prefixed imports, like the snippets of glob imports, are not part of it, but
+// the files imported by these snippets are. The paths are relative to the
+// directory of the lock file, so that it can be verified from any working
+// directory. The JSON output is sorted by path to get a stable lock file. Use
+// VerifyLockFile in subsequent builds to detect drift.
+func (m *MultiImporter) WriteLockFile(path string) error {
+	lock := importLock{Files: map[string]string{}}
+
+	locked, err := m.lockedFiles(path)
+	if err != nil {
+		return err
+	}
+
+	for file, name := range locked {
+		sum, err := m.hashFile(file)
+		if err != nil {
+			return err
+		}
+
+		lock.Files[name] = sum
+	}
+
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("while marshaling the lock file '%s', error: %w", path, err)
+	}
+
+	if err := afero.WriteFile(m.fs, path, append(data, '\n'), m.importGraphFileMode); err != nil {
+		return fmt.Errorf("while writing the lock file '%s', error: %w", path, err)
+	}
+
+	return nil
+}
+
+// VerifyLockFile compares the lock file at the given path (see WriteLockFile)
+// with the current files and returns an ErrLockMismatch error listing all
+// files, which were changed or removed since the lock file was written. Files
+// imported so far, which are not part of the lock file, are listed as well.
+func (m *MultiImporter) VerifyLockFile(path string) error {
+	data, err := afero.ReadFile(m.fs, path)
+	if err != nil {
+		return fmt.Errorf("while reading the lock file '%s', error: %w", path, err)
+	}
+
+	lock := importLock{}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return fmt.Errorf("while parsing the lock file '%s', error: %w", path, err)
+	}
+
+	mismatches := []string{}
+
+	locked := stringKeysFromMap(lock.Files)
+	sort.Strings(locked)
+
+	for _, file := range locked {
+		sum, err := m.hashFile(filepath.Join(filepath.Dir(path), filepath.FromSlash(file)))
+
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			mismatches = append(mismatches, fmt.Sprintf("'%s' was removed", file))
+		case err != nil:
+			return err
+		case sum != lock.Files[file]:
+			mismatches = append(mismatches, fmt.Sprintf("'%s' was changed", file))
+		}
+	}
+
+	imported, err := m.lockedFiles(path)
+	if err != nil {
+		return err
+	}
+
+	unlocked := []string{}
+	for _, name := range imported {
+		if _, locked := lock.Files[name]; !locked {
+			unlocked = append(unlocked, name)
+		}
+	}
+
+	sort.Strings(unlocked)
+
+	for _, name := range unlocked {
+		mismatches = append(mismatches, fmt.Sprintf("'%s' is not locked", name))
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%w with '%s': %s", ErrLockMismatch, path, strings.Join(mismatches, ", "))
+	}
+
+	return nil
+}
+
+// lockedFiles returns the files of all successful imports, which are regular
+// files, mapped to their paths relative to the directory of the lock file
+// with forward slashes. The foundAt values of prefixed imports, like
+// directories for glob imports, will be skipped.
+func (m *MultiImporter) lockedFiles(lockFile string) (map[string]string, error) {
+	dir, err := filepath.Abs(filepath.Dir(lockFile))
+	if err != nil {
+		return nil, fmt.Errorf("while resolving the directory of the lock file '%s', error: %w", lockFile, err)
+	}
+
+	locked := map[string]string{}
+
+	for file := range m.files {
+		if info, err := m.fs.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, fmt.Errorf("while resolving file %s for the lock file, error: %w", file, err)
+		}
+
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			return nil, fmt.Errorf("while resolving file %s for the lock file, error: %w", file, err)
+		}
+
+		locked[file] = filepath.ToSlash(rel)
+	}
+
+	return locked, nil
+}
+
+// hashFile returns the hex encoded SHA-256 hash of the content of the file.
+func (m *MultiImporter) hashFile(file string) (string, error) {
+	content, err := afero.ReadFile(m.fs, file)
+	if err != nil {
+		return "", fmt.Errorf("while reading file %s for the lock file, error: %w", file, err)
+	}
+
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiImporter_WriteLockFile(t *testing.T) {
+	evaluate := func() *MultiImporter {
+		m := NewMultiImporter()
+		vm := jsonnet.MakeVM()
+		vm.Importer(m)
+		if _, err := vm.EvaluateFile("testdata/hashlock/main.jsonnet"); err != nil {
+			t.Fatalf("vm.EvaluateFile() error = %v", err)
+		}
+		return m
+	}
+
+	dir := t.TempDir()
+	lockFile := filepath.Join(dir, "imports.lock.json")
+	if err := evaluate().WriteLockFile(lockFile); err != nil {
+		t.Fatalf("MultiImporter.WriteLockFile() error = %v", err)
+	}
+
+	// the paths are relative to the directory of the lock file
+	relative := func(file string) string {
+		t.Helper()
+
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			t.Fatalf("filepath.Abs() error = %v", err)
+		}
+
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			t.Fatalf("filepath.Rel() error = %v", err)
+		}
+
+		return filepath.ToSlash(rel)
+	}
+
+	lock := readLockFile(t, lockFile)
+	// real files only, the glob import itself is not part of the lock file
+	assert.ElementsMatch(t, []string{
+		relative("testdata/hashlock/main.jsonnet"),
+		relative("testdata/hashlock/host.libsonnet"),
+		relative("testdata/hashlock/libs/a.libsonnet"),
+		relative("testdata/hashlock/libs/b.libsonnet"),
+	}, stringKeysFromMap(lock.Files))
+
+	assert.NoError(t, evaluate().VerifyLockFile(lockFile))
+	// without an evaluation only the locked files will be verified
+	assert.NoError(t, NewMultiImporter().VerifyLockFile(lockFile))
+
+	t.Run("verify from another working directory", func(t *testing.T) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			t.Fatalf("os.Getwd() error = %v", err)
+		}
+		if err := os.Chdir(t.TempDir()); err != nil {
+			t.Fatalf("os.Chdir() error = %v", err)
+		}
+		defer func() {
+			if err := os.Chdir(cwd); err != nil {
+				t.Fatalf("os.Chdir() error = %v", err)
+			}
+		}()
+
+		assert.NoError(t, NewMultiImporter().VerifyLockFile(lockFile))
+	})
+
+	a, gone, b := relative("testdata/hashlock/libs/a.libsonnet"),
+		relative("testdata/hashlock/libs/gone.libsonnet"),
+		relative("testdata/hashlock/libs/b.libsonnet")
+
+	lock.Files[a] = "0000"
+	lock.Files[gone] = "0000"
+	delete(lock.Files, b)
+
+	data, _ := json.Marshal(lock)
+	if err := os.WriteFile(lockFile, data, 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	err := evaluate().VerifyLockFile(lockFile)
+	assert.ErrorIs(t, err, ErrLockMismatch)
+	assert.ErrorContains(t, err, "'"+a+"' was changed")
+	assert.ErrorContains(t, err, "'"+gone+"' was removed")
+	assert.ErrorContains(t, err, "'"+b+"' is not locked")
+
+	assert.Error(t, NewMultiImporter().VerifyLockFile(filepath.Join(t.TempDir(), "missing.lock.json")))
+}
+
+func readLockFile(t *testing.T, lockFile string) importLock {
+	t.Helper()
+
+	data, err := os.ReadFile(lockFile)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+
+	lock := importLock{}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	return lock
+}
@@ -6,22 +6,30 @@
 package importer
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dominikbraun/graph"
 	"github.com/dominikbraun/graph/draw"
 	"github.com/google/go-jsonnet"
 	"github.com/spf13/afero"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 const (
 	importGraphFileName = "import_graph.gv"
+	// importGraphFileMode is the same as used by os.Create.
+	importGraphFileMode os.FileMode = 0o666
 )
 
 var (
@@ -31,9 +39,30 @@ var (
 	ErrMalformedGlobPattern = errors.New("malformed glob pattern")
 	ErrImportCycle          = errors.New("import cycle")
 	ErrEmptyResult          = errors.New("empty result")
+	ErrExcludeShadows       = errors.New("exclude pattern fully shadows the glob pattern")
 	ErrUnknownConfig        = errors.New("unknown config")
 	ErrMalformedImport      = errors.New("malformed import string")
 	ErrMalformedQuery       = errors.New("malformed query parameter(s)")
+	ErrUnknownImportKind    = errors.New("unknown import kind")
+	ErrMissingJPath         = errors.New("missing jpath")
+	ErrInvalidIdentifier    = errors.New("invalid identifier")
+	ErrTooFewMatches        = errors.New("too few matches")
+	ErrFileNotFound         = errors.New("file not found")
+	ErrDuplicateContent     = errors.New("duplicate content")
+	ErrEmptyFile            = errors.New("empty file")
+	ErrMissingField         = errors.New("missing field")
+	ErrDuplicateKey         = errors.New("duplicate key")
+	ErrMaxDepthExceeded     = errors.New("max import depth exceeded")
+	ErrByteLimitExceeded    = errors.New("byte limit exceeded")
+	ErrUnknownLockName      = errors.New("unknown lock name")
+	ErrLockMismatch         = errors.New("lock mismatch")
+	ErrInvalidPage          = errors.New("invalid page")
+	ErrUnknownExtGroup      = errors.New("unknown extension group")
+	ErrUnknownVertex        = errors.New("unknown vertex")
+	ErrUnknownGraphFormat   = errors.New("unknown graph format")
+	// ErrRetryable can be wrapped by importers to mark transient errors,
+	// like network timeouts, which the MultiImporter retries (see SetRetry).
+	ErrRetryable = errors.New("retryable")
 )
 
 type (
@@ -51,7 +80,27 @@ type (
 		// Prefixa returns the list of prefixa, which will trigger the specific
 		// importer. An empty list means no prefix used/needed.
 		Prefixa() []string
-		setImportGraph(graph.Graph[string, string], int)
+	}
+
+	// RawPathImporter is an optional interface for importers, whose import
+	// paths behind a prefix are no valid URLs, like the alternatives of
+	// `glob.first://a|b`. Such importers validate the paths themselves.
+	RawPathImporter interface {
+		// AcceptsRawPath returns true, if the importer accepts import paths
+		// with the given prefix, which cannot be parsed as URL.
+		AcceptsRawPath(prefix string) bool
+	}
+
+	// RelativeImporter is an optional interface for importers, whose foundAt
+	// values are no local paths, like the files of an archive of the
+	// HTTPArchiveImporter. The MultiImporter lets them resolve the plain
+	// imports inside such files, which otherwise would be searched on the
+	// local filesystem.
+	RelativeImporter interface {
+		// ResolveRelative returns the import path for the plain importedPath
+		// inside the file importedFrom. The second return value is false, if
+		// importedFrom is no foundAt value of the importer.
+		ResolveRelative(importedFrom, importedPath string) (string, bool)
 	}
 
 	// FallbackFileImporter is a wrapper for the original go-jsonnet FileImporter.
@@ -60,22 +109,134 @@ type (
 	// import prefix (and of course also no prefix).
 	FallbackFileImporter struct {
 		*jsonnet.FileImporter
+		// fs reads the plain imports instead of the go-jsonnet FileImporter,
+		// if set via SetFilesystems.
+		fs    afero.Fs
+		cache contentsCache
 	}
 
 	// MultiImporter supports multiple importers and tries to find the right
 	// importer from a list of importers.
 	MultiImporter struct {
-		importers          []Importer
-		logger             *zap.Logger
-		logLevel           string
-		ignoreImportCycles bool
-		importGraph        graph.Graph[string, string]
-		importCounter      int
-		importGraphFile    string
-		enableImportGraph  bool
-		fs                 afero.Fs
+		importers           []Importer
+		logger              *zap.Logger
+		logLevel            string
+		ignoreImportCycles  bool
+		fallthroughOnError  bool
+		importGraph         graph.Graph[string, string]
+		importCounter       int
+		importGraphFile     string
+		importGraphFileMode os.FileMode
+		enableImportGraph   bool
+		// highlightLongest colors the longest import path inside the graph.
+		highlightLongest bool
+		// hideGraphRoot removes the synthetic root vertex "." from the stored
+		// graph.
+		hideGraphRoot bool
+		// graphOnErrorOnly stores the import graph only for failed imports
+		// instead of for every import.
+		graphOnErrorOnly bool
+		// rewriteRules replace the beginning of import paths before the
+		// routing (see AddRewriteRule).
+		rewriteRules []rewriteRule
+		// events receives an importEvent per added edge of the import graph
+		// (see StreamImportEvents).
+		events io.Writer
+		// completionHooks will be executed against the final import graph
+		// via RunCompletionHooks (see OnComplete).
+		completionHooks []func(g graph.Graph[string, string]) error
+		// canonicalizePaths maps each file to a single vertex inside the
+		// import graph, independent of the relative path used to import it.
+		canonicalizePaths bool
+		// maxImportDepth limits the length of an import chain; 0 means
+		// unlimited.
+		maxImportDepth int
+		// importDepths stores the length of the import chain per foundAt.
+		importDepths map[string]int
+		// files stores the cleaned foundAt values of the successful imports,
+		// which are no prefixed paths, for the lock file (see WriteLockFile).
+		files map[string]bool
+		// maxTotalBytes limits the sum of the sizes of all imported
+		// contents; 0 means unlimited.
+		maxTotalBytes int64
+		// goMaxTotalBytes is the maxTotalBytes set from go code (see
+		// SetMaxTotalBytes), which `config://set` imports can only lower.
+		goMaxTotalBytes int64
+		// totalBytes is the sum of the sizes of the contents counted so far;
+		// each foundAt value will be counted once (see countedBytes).
+		totalBytes   int64
+		countedBytes map[string]bool
+		// currentDepth is the length of the import chain of the running
+		// import. Unlike the importCounter, which only increases and is used
+		// for the edge weights and the unique foundAt values, it can shrink.
+		currentDepth int
+		// strict disables the FallbackFileImporter for plain imports, except
+		// for the entry file and the content of prefixed imports.
+		strict bool
+		// generated stores the foundAt values of prefixed imports, whose
+		// content is allowed to contain plain imports in strict mode.
+		generated     map[string]bool
+		plainImporter Importer
+		// retryAttempts is the number of retries for errors wrapping
+		// ErrRetryable; the wait time starts at retryBackoff and doubles
+		// with each retry.
+		retryAttempts int
+		retryBackoff  time.Duration
+		sleep         func(time.Duration)
+		fs            afero.Fs
 		*onMissingFile
 	}
+	// Config mirrors the query keys of the `config://set` import, like
+	// `LogLevel` for `logLevel=<level>`, to configure the MultiImporter from
+	// go code (see Configure). Zero values will not be applied.
+	Config struct {
+		// LogLevel is either "debug" or "info".
+		LogLevel string
+		// ImportGraph is the file, where the import graph will be stored.
+		ImportGraph        string
+		IgnoreImportCycles bool
+		// OnMissingFile is either a file or a content in double quotes, like
+		// `"{}"`.
+		OnMissingFile          string
+		StrictJPaths           bool
+		FallthroughOnError     bool
+		GraphHighlightLongest  bool
+		GraphHideRoot          bool
+		GraphOnErrorOnly       bool
+		CanonicalizePaths      bool
+		Annotate               bool
+		DetectDuplicateContent bool
+		SkipBrokenFiles        bool
+		EagerCycleCheck        bool
+		RebaseImports          bool
+		MaxImportDepth         int
+		MaxTotalBytes          int64
+		// GlobFormat is either "pretty" or "compact".
+		GlobFormat string
+		// GlobIgnoreFile is a file with exclude patterns for all glob
+		// imports.
+		GlobIgnoreFile  string
+		PerDirConfig    bool
+		WarnShadowed    bool
+		WarnTrivialGlob bool
+		IdentifierKeys  bool
+	}
+	// settings are the current settings returned by the `config://get`
+	// import.
+	settings struct {
+		LogLevel           string              `json:"logLevel"`
+		ImportGraph        importGraphSettings `json:"importGraph"`
+		IgnoreImportCycles bool                `json:"ignoreImportCycles"`
+	}
+	importGraphSettings struct {
+		Enabled bool   `json:"enabled"`
+		File    string `json:"file"`
+	}
+	// rewriteRule replaces the prefix match of an import path with replace.
+	rewriteRule struct {
+		match   string
+		replace string
+	}
 	onMissingFile struct {
 		enabled bool
 		kind    string
@@ -84,14 +245,91 @@ type (
 	}
 )
 
-func (f *FallbackFileImporter) setImportGraph(_ graph.Graph[string, string], _ int) {}
-
 // NewFallbackFileImporter returns finally the original go-jsonnet FileImporter.
 // As optional parameters extra library search paths (aka. jpath) can be provided too.
 func NewFallbackFileImporter(jpaths ...string) *FallbackFileImporter {
 	return &FallbackFileImporter{FileImporter: &jsonnet.FileImporter{JPaths: jpaths}}
 }
 
+// Import wraps the Import method of the go-jsonnet FileImporter. If the file
+// cannot be found, the returned error wraps ErrFileNotFound, while the
+// original error is kept.
+func (f *FallbackFileImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	if f.fs != nil {
+		return f.importFromFs(importedFrom, importedPath)
+	}
+
+	contents, foundAt, err := f.FileImporter.Import(importedFrom, importedPath)
+	if err != nil && isNotFound(err) {
+		return contents, foundAt, fmt.Errorf("%w: %w", ErrFileNotFound, err)
+	}
+	return contents, foundAt, err
+}
+
+// SetFilesystems lets the FallbackFileImporter read the plain imports from a
+// union of the given filesystems, like GlobImporter.SetFilesystems; pass the
+// same filesystems to both importers, so that the imports generated by the
+// GlobImporter can be read. The lookup stays the one of the go-jsonnet
+// FileImporter: first relative to the importing file, then inside the JPaths
+// in reverse order. Without any filesystem the go-jsonnet FileImporter reads
+// from the OS filesystem (default).
+func (f *FallbackFileImporter) SetFilesystems(fss ...afero.Fs) {
+	f.fs = nil
+	f.cache.reset()
+
+	if len(fss) > 0 {
+		f.fs = unionFs(fss...)
+	}
+}
+
+// importFromFs is the Import of the FallbackFileImporter for the filesystems
+// set via SetFilesystems.
+func (f *FallbackFileImporter) importFromFs(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	dir, _ := filepath.Split(importedFrom)
+	dirs := []string{dir}
+
+	for i := len(f.JPaths) - 1; i >= 0; i-- {
+		dirs = append(dirs, f.JPaths[i])
+	}
+
+	for _, d := range dirs {
+		foundAt := importedPath
+		if !filepath.IsAbs(importedPath) {
+			foundAt = filepath.Join(d, importedPath)
+		}
+
+		contents, err := f.cache.load(foundAt, func() (jsonnet.Contents, error) {
+			data, err := afero.ReadFile(f.fs, foundAt)
+			if err != nil {
+				return jsonnet.Contents{}, err
+			}
+
+			return jsonnet.MakeContentsRaw(data), nil
+		})
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return jsonnet.Contents{}, "", err
+		}
+
+		return contents, foundAt, nil
+	}
+
+	return jsonnet.Contents{}, "",
+		fmt.Errorf("%w: couldn't open import %#v: no match locally or in the Jsonnet library paths",
+			ErrFileNotFound, importedPath)
+}
+
+// isNotFound reports whether the error of the go-jsonnet FileImporter is about
+// a missing file. The FileImporter swallows the os.IsNotExist errors of the
+// single lookups and returns only a generic error, if no lookup succeeded.
+func isNotFound(err error) bool {
+	return os.IsNotExist(err) ||
+		strings.Contains(err.Error(), "no match locally or in the Jsonnet library paths")
+}
+
 // CanHandle method of the FallbackFileImporter returns always true.
 func (f *FallbackFileImporter) CanHandle(_ string) bool {
 	return true
@@ -115,13 +353,15 @@ func NewMultiImporter(importers ...Importer) *MultiImporter {
 		importGraph: graph.New(
 			graph.StringHash, graph.Tree(), graph.Directed(), graph.Weighted(),
 		),
-		importGraphFile:    importGraphFileName,
-		fs:                 afero.NewOsFs(),
-		logLevel:           "",
-		ignoreImportCycles: false,
-		importCounter:      0,
-		enableImportGraph:  false,
-		onMissingFile:      nil,
+		importGraphFile:     importGraphFileName,
+		importGraphFileMode: importGraphFileMode,
+		fs:                  afero.NewOsFs(),
+		logLevel:            "",
+		ignoreImportCycles:  false,
+		importCounter:       0,
+		enableImportGraph:   false,
+		importDepths:        map[string]int{},
+		onMissingFile:       nil,
 	}
 
 	if len(multiImporter.importers) == 0 {
@@ -134,6 +374,34 @@ func NewMultiImporter(importers ...Importer) *MultiImporter {
 	return multiImporter
 }
 
+// NewStrictMultiImporter returns an instance of a MultiImporter without the
+// FallbackFileImporter, so that imports, which none of the given importers can
+// handle, return an ErrNoImporter error. Plain imports, like
+// `import 'lib.libsonnet'`, are only allowed for the entry file and inside the
+// content of prefixed imports, like the imports generated by the
+// GlobImporter. Without importers the GlobImporter will be used.
+// FallbackFileImporters inside the given list will be ignored.
+func NewStrictMultiImporter(importers ...Importer) *MultiImporter {
+	strictImporters := []Importer{}
+
+	for _, i := range importers {
+		if _, isFallback := i.(*FallbackFileImporter); !isFallback {
+			strictImporters = append(strictImporters, i)
+		}
+	}
+
+	if len(strictImporters) == 0 {
+		strictImporters = []Importer{NewGlobImporter()}
+	}
+
+	multiImporter := NewMultiImporter(strictImporters...)
+	multiImporter.strict = true
+	multiImporter.generated = map[string]bool{}
+	multiImporter.plainImporter = NewFallbackFileImporter()
+
+	return multiImporter
+}
+
 // Logger method can be used to set a zap.Logger for all importers at once.
 // (see https://pkg.go.dev/go.uber.org/zap)
 func (m *MultiImporter) Logger(logger *zap.Logger) {
@@ -150,12 +418,271 @@ func (m *MultiImporter) SetImportGraphFile(name string) {
 	m.enableImportGraph = true
 }
 
+// LogLevel returns the log level set via the `config://set?logLevel=<level>`
+// import. An empty string means no level was set.
+func (m *MultiImporter) LogLevel() string {
+	return m.logLevel
+}
+
+// ImportGraphFile returns the name of the file, where the import graph will be
+// stored.
+func (m *MultiImporter) ImportGraphFile() string {
+	return m.importGraphFile
+}
+
+// ImportGraphEnabled returns true, if the import graph will be stored for
+// every import and not only on import cycles.
+func (m *MultiImporter) ImportGraphEnabled() bool {
+	return m.enableImportGraph
+}
+
+// CyclesIgnored returns true, if the test for import cycles is disabled.
+func (m *MultiImporter) CyclesIgnored() bool {
+	return m.ignoreImportCycles
+}
+
+// HighlightLongestImportPath enables or disables the highlighting of the
+// longest import path inside the stored import graph. Nothing will be
+// highlighted in graphs with import cycles.
+func (m *MultiImporter) HighlightLongestImportPath(enabled bool) {
+	m.highlightLongest = enabled
+}
+
+// HideGraphRoot enables or disables the removal of the synthetic root vertex
+// "." (the entry point) and its edges from the stored import graph. The
+// vertex stays inside the graph used for the cycle detection.
+func (m *MultiImporter) HideGraphRoot(enabled bool) {
+	m.hideGraphRoot = enabled
+}
+
+// StoreGraphOnErrorOnly enables or disables storing the import graph only for
+// failed imports, like import cycles, instead of for every import. Enabling it
+// also enables the import graph.
+func (m *MultiImporter) StoreGraphOnErrorOnly(enabled bool) {
+	m.graphOnErrorOnly = enabled
+	if enabled {
+		m.enableImportGraph = true
+	}
+}
+
+// SetImportGraphFileMode sets the file mode (before umask) used to create the
+// import graph file. Default is 0666 like in os.Create.
+func (m *MultiImporter) SetImportGraphFileMode(mode os.FileMode) {
+	m.importGraphFileMode = mode
+}
+
+// AddRewriteRule adds a rule, which replaces the beginning match of an import
+// path with replace before the import will be routed to an importer, like
+// match "legacy://" and replace "glob+://legacy/" to migrate old import
+// strings without editing them. The rules will be checked in the order they
+// were added and only the first matching rule rewrites the import path. The
+// rewritten path passes the cycle detection and the import graph like any
+// other import path.
+func (m *MultiImporter) AddRewriteRule(match, replace string) {
+	m.rewriteRules = append(m.rewriteRules, rewriteRule{match: match, replace: replace})
+}
+
+// rewrite applies the first matching rewrite rule to the import path.
+func (m *MultiImporter) rewrite(importedPath string) string {
+	for _, rule := range m.rewriteRules {
+		if rest, found := strings.CutPrefix(importedPath, rule.match); found {
+			return rule.replace + rest
+		}
+	}
+
+	return importedPath
+}
+
+// StreamImportEvents writes each edge added to the import graph as a line of
+// JSON, like `{"from":"main.jsonnet","to":"lib.libsonnet","weight":1}`, to the
+// given writer at the time it will be discovered. Edges of glob imports carry
+// additionally `"origin":"glob"`. This allows a live progress display without
+// waiting for the final graph; the DOT file stays the rendering of the final
+// state. A nil writer disables the stream.
+func (m *MultiImporter) StreamImportEvents(w io.Writer) {
+	m.events = w
+}
+
+// CanonicalizePaths enables or disables the canonicalization of the paths
+// inside the import graph. Without it, a relative import adds both the given
+// path and the path resolved against the importing file as vertices. With it,
+// each import adds a single vertex with the cleaned path, which is relative to
+// the importing file and has its symlinks resolved, if the file exists.
+func (m *MultiImporter) CanonicalizePaths(enabled bool) {
+	m.canonicalizePaths = enabled
+}
+
 // IgnoreImportCycles disables the test for import cycles and therefore also any
 // error in that regard.
 func (m *MultiImporter) IgnoreImportCycles() {
 	m.ignoreImportCycles = true
 }
 
+// FallthroughOnError enables or disables the fallthrough to the next importer,
+// which can handle the import path, if the current importer returns an empty
+// result. If all importers fail, the error of the first one will be returned.
+func (m *MultiImporter) FallthroughOnError(enabled bool) {
+	m.fallthroughOnError = enabled
+}
+
+// SetRetry lets the MultiImporter retry an import up to attempts times, if
+// the importer returns an error wrapping ErrRetryable. The wait time before
+// the first retry is backoff and doubles with each further retry. Other
+// errors, like the ones of missing files or empty glob results, will never be
+// retried. The default 0 attempts disables the retries.
+func (m *MultiImporter) SetRetry(attempts int, backoff time.Duration) {
+	m.retryAttempts = attempts
+	m.retryBackoff = backoff
+}
+
+// importWithRetry runs the Import of the given importer and retries it for
+// errors wrapping ErrRetryable (see SetRetry).
+func (m *MultiImporter) importWithRetry(importer Importer, importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	contents, foundAt, err := importer.Import(importedFrom, importedPath)
+
+	wait := m.retryBackoff
+	for retry := 1; retry <= m.retryAttempts && errors.Is(err, ErrRetryable); retry++ {
+		m.logger.Named("MultiImporter").Info("retry import after transient error",
+			zap.String("importedPath", importedPath),
+			zap.Int("retry", retry),
+			zap.Duration("backoff", wait),
+			zap.Error(err),
+		)
+
+		if m.sleep == nil {
+			m.sleep = time.Sleep
+		}
+
+		m.sleep(wait)
+		wait *= 2
+
+		contents, foundAt, err = importer.Import(importedFrom, importedPath)
+	}
+
+	return contents, foundAt, err
+}
+
+// SetMaxImportDepth limits the length of an import chain starting at the entry
+// file. Longer chains, for example of continuous glob imports, return
+// ErrMaxDepthExceeded. The default 0 means unlimited.
+func (m *MultiImporter) SetMaxImportDepth(depth int) {
+	m.maxImportDepth = depth
+}
+
+// SetMaxTotalBytes limits the sum of the sizes of all contents returned by the
+// importers during an evaluation. Each foundAt value will be counted once,
+// even if go-jsonnet imports it multiple times. For glob imports, this counts
+// the generated snippet (a few import expressions) and - because go-jsonnet
+// imports each resolved file via the MultiImporter too - the content of each
+// imported file. The import, which exceeds the limit, returns
+// ErrByteLimitExceeded. The default 0 means unlimited. The limit set here
+// can only be lowered by `config://set?maxTotalBytes=<n>` imports.
+func (m *MultiImporter) SetMaxTotalBytes(n int64) {
+	m.maxTotalBytes = n
+	m.goMaxTotalBytes = n
+}
+
+// countBytes adds the size of the contents found at foundAt to the total
+// bytes, if this foundAt value was not counted before, and returns
+// ErrByteLimitExceeded, if the total exceeds the maxTotalBytes.
+func (m *MultiImporter) countBytes(importedPath, foundAt string, contents jsonnet.Contents) error {
+	if m.maxTotalBytes <= 0 || m.countedBytes[foundAt] {
+		return nil
+	}
+
+	if m.countedBytes == nil {
+		m.countedBytes = map[string]bool{}
+	}
+
+	m.countedBytes[foundAt] = true
+	m.totalBytes += int64(len(contents.Data()))
+
+	if m.totalBytes > m.maxTotalBytes {
+		return fmt.Errorf("%w: importing '%s' results in %d bytes, but allowed are %d",
+			ErrByteLimitExceeded, importedPath, m.totalBytes, m.maxTotalBytes)
+	}
+
+	return nil
+}
+
+// Configure applies the given Config like a `config://set` import with the
+// same query keys and therefore with the same validation. Unlike the
+// `config://set` import, the MaxTotalBytes can raise the limit like
+// SetMaxTotalBytes.
+func (m *MultiImporter) Configure(cfg Config) error {
+	query := cfg.query()
+	if cfg.MaxTotalBytes > 0 {
+		query.Del("maxTotalBytes")
+		m.SetMaxTotalBytes(cfg.MaxTotalBytes)
+	}
+
+	return m.parseInFileConfigs(query.Encode())
+}
+
+// query converts the Config into the query of a `config://set` import; zero
+// values will be skipped.
+func (c Config) query() url.Values {
+	query := url.Values{}
+
+	setString := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	setBool := func(key string, value bool) {
+		if value {
+			query.Set(key, "true")
+		}
+	}
+
+	setString("logLevel", c.LogLevel)
+	setString("importGraph", c.ImportGraph)
+	setString("onMissingFile", c.OnMissingFile)
+	setString("globFormat", c.GlobFormat)
+	setString("globIgnoreFile", c.GlobIgnoreFile)
+
+	if c.IgnoreImportCycles {
+		query.Set("ignoreImportCycles", "")
+	}
+
+	setBool("strictJPaths", c.StrictJPaths)
+	setBool("fallthroughOnError", c.FallthroughOnError)
+	setBool("graphHighlightLongest", c.GraphHighlightLongest)
+	setBool("graphHideRoot", c.GraphHideRoot)
+	setBool("graphOnErrorOnly", c.GraphOnErrorOnly)
+	setBool("canonicalizePaths", c.CanonicalizePaths)
+	setBool("annotate", c.Annotate)
+	setBool("detectDuplicateContent", c.DetectDuplicateContent)
+	setBool("skipBrokenFiles", c.SkipBrokenFiles)
+	setBool("eagerCycleCheck", c.EagerCycleCheck)
+	setBool("rebaseImports", c.RebaseImports)
+	setBool("perDirConfig", c.PerDirConfig)
+	setBool("warnShadowed", c.WarnShadowed)
+	setBool("warnTrivialGlob", c.WarnTrivialGlob)
+	setBool("identifierKeys", c.IdentifierKeys)
+
+	if c.MaxImportDepth != 0 {
+		query.Set("maxImportDepth", strconv.Itoa(c.MaxImportDepth))
+	}
+
+	if c.MaxTotalBytes != 0 {
+		query.Set("maxTotalBytes", strconv.FormatInt(c.MaxTotalBytes, 10))
+	}
+
+	return query
+}
+
+// CurrentDepth returns the length of the import chain from the entry file
+// (depth 0) to the current import. Each glob import counts as one level in
+// front of its resolved files. It can be used by custom importers, which are
+// called by the MultiImporter. The depth is only valid during an import:
+// afterwards the depth before the import will be restored, which is 0
+// outside of any import. Note: the depth differs from the internal import
+// counter, which only increases and is used for the import graph.
+func (m *MultiImporter) CurrentDepth() int {
+	return m.currentDepth
+}
+
 // OnMissingFile specifies the content or the file which should be used if the
 // original import cannot find the file.
 func (m *MultiImporter) OnMissingFile(use string) {
@@ -179,63 +706,171 @@ func (m *MultiImporter) OnMissingFile(use string) {
 
 // Import is used by go-jsonnet to run this importer. It implements the go-jsonnet
 // Importer interface method.
-func (m *MultiImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+func (m *MultiImporter) Import(importedFrom, importedPath string) (_ jsonnet.Contents, _ string, err error) {
 	logger := m.logger.Named("MultiImporter")
 	logger.Debug("Import()",
 		zap.String("importedFrom", importedFrom),
 		zap.String("importedPath", importedPath),
 	)
 
-	prefix, err := m.parseImportString(importedFrom, importedPath)
+	if rewritten := m.rewrite(importedPath); rewritten != importedPath {
+		logger.Debug("rewrote importedPath",
+			zap.String("importedPath", importedPath),
+			zap.String("rewrittenPath", rewritten),
+		)
+
+		importedPath = rewritten
+	}
+
+	if resolved, ok := m.resolveRelative(importedFrom, importedPath); ok {
+		logger.Debug("resolved relative importedPath",
+			zap.String("importedPath", importedPath),
+			zap.String("resolvedPath", resolved),
+		)
+
+		importedPath = resolved
+	}
+
+	if m.graphOnErrorOnly {
+		defer func() {
+			if err != nil && m.enableImportGraph {
+				// the import error is more important than the one of the graph
+				if storeErr := m.storeImportGraph(); storeErr != nil {
+					logger.Warn("while storing the import graph of a failed import", zap.Error(storeErr))
+				}
+			}
+		}()
+	}
+
+	var prefix string
+	// fast path for imports without prefix and query
+	if isPlainImport(importedPath) {
+		err = m.parsePlainImport(importedFrom, importedPath)
+	} else {
+		prefix, err = m.parseImportString(importedFrom, importedPath)
+	}
+
 	if err != nil {
 		return jsonnet.MakeContents(""), "", err
 	}
 	p := strings.Repeat("./", m.importCounter)
 	foundAtCntr := p + "./" + importedFrom
 	if prefix == "config" {
+		if configVerb(importedPath) == "get" {
+			// must differ from the foundAt value of `config://set`
+			return m.settingsContents(foundAtCntr + importedPath)
+		}
+
 		return jsonnet.MakeContents("{}"), foundAtCntr, nil
 	}
 
-	for idx, importer := range m.importers {
+	// the entry file itself will be imported from "" (see vm.EvaluateFile)
+	depth := 0
+	if importedFrom != "" {
+		depth = m.importDepths[importedFrom] + 1
+	}
+
+	// the depth is only valid while the import is running; nested calls, like
+	// of the FirstOfImporter, get back the depth of their caller afterwards
+	defer func(previous int) { m.currentDepth = previous }(m.currentDepth)
+
+	m.currentDepth = depth
+
+	if m.maxImportDepth > 0 && depth > m.maxImportDepth {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: importing '%s' from '%s' results in a depth of %d, but allowed are %d",
+				ErrMaxDepthExceeded, importedPath, importedFrom, depth, m.maxImportDepth)
+	}
+
+	var (
+		firstErr      error
+		firstImporter Importer
+		firstFoundAt  string
+	)
+
+	importers := m.importers
+	if m.strict && prefix == "" {
+		if importedFrom != "" && !m.generated[importedFrom] {
+			return jsonnet.MakeContents(""), "",
+				fmt.Errorf("%w can handle given path: '%s', plain imports are only allowed inside prefixed imports in strict mode",
+					ErrNoImporter, importedPath)
+		}
+
+		importers = append(importers[:len(importers):len(importers)], m.plainImporter)
+	}
+
+	for idx, importer := range importers {
 		m.importCounter += idx
-		if importer.CanHandle(prefix) {
-			logger.Info("found importer for importedPath",
-				zap.String("importer", fmt.Sprintf("%T", importer)),
-				zap.String("importedPath", importedPath),
-				zap.String("prefix", prefix),
-			)
-			importer.setImportGraph(m.importGraph, m.importCounter)
+		if !importer.CanHandle(prefix) {
+			continue
+		}
 
-			contents, foundAt, err := importer.Import(importedFrom, importedPath)
-			if err != nil {
-				switch {
-				case errors.Is(err, ErrEmptyResult),
-					strings.Contains(err.Error(), "no match locally or in the Jsonnet library paths"):
-					o := m.onMissingFile
-					if o != nil {
-						if o.enabled {
-							switch o.kind {
-							case "content":
-
-								return jsonnet.MakeContents(o.content), foundAtCntr + foundAt, nil
-							case "file":
-
-								return importer.Import(foundAt, path.Join(path.Dir(importedFrom), o.file))
-							}
-						}
-					}
-				}
+		logger.Info("found importer for importedPath",
+			zap.String("importer", fmt.Sprintf("%T", importer)),
+			zap.String("importedPath", importedPath),
+			zap.String("prefix", prefix),
+		)
+		if contributor, ok := importer.(GraphContributor); ok {
+			recorder := newGraphRecorder(m.importGraph, m.importCounter)
+			recorder.events = m.events
+			recorder.canonicalize = m.canonicalizePaths
+			contributor.SetGraphRecorder(recorder)
+		}
 
-				return jsonnet.MakeContents(""), "",
-					fmt.Errorf("custom importer '%T' returns error: %w", importer, err)
+		contents, foundAt, err := m.importWithRetry(importer, importedFrom, importedPath)
+		if err == nil {
+			if err := m.countBytes(importedPath, foundAt, contents); err != nil {
+				return jsonnet.MakeContents(""), "", err
+			}
+
+			m.trackDepth(foundAt, depth)
+			m.trackFile(foundAt)
+
+			if m.strict && prefix != "" {
+				m.generated[foundAt] = true
 			}
 
 			return contents, foundAt, nil
 		}
+		// keep the original error, if all other importers fail too
+		if firstErr == nil {
+			firstErr, firstImporter, firstFoundAt = err, importer, foundAt
+		}
+
+		if !m.fallthroughOnError || !errors.Is(err, ErrEmptyResult) {
+			break
+		}
+
+		logger.Info("importer returns empty result, trying next importer",
+			zap.String("importer", fmt.Sprintf("%T", importer)),
+			zap.String("importedPath", importedPath),
+		)
+	}
+
+	if firstErr == nil {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w can handle given path: '%s'", ErrNoImporter, importedPath)
+	}
+
+	switch {
+	case errors.Is(firstErr, ErrEmptyResult), errors.Is(firstErr, ErrFileNotFound):
+		o := m.onMissingFile
+		if o != nil {
+			if o.enabled {
+				switch o.kind {
+				case "content":
+
+					return jsonnet.MakeContents(o.content), foundAtCntr + firstFoundAt, nil
+				case "file":
+
+					return firstImporter.Import(firstFoundAt, path.Join(path.Dir(importedFrom), o.file))
+				}
+			}
+		}
 	}
 
 	return jsonnet.MakeContents(""), "",
-		fmt.Errorf("%w can handle given path: '%s'", ErrNoImporter, importedPath)
+		fmt.Errorf("custom importer '%T' returns error: %w", firstImporter, firstErr)
 }
 
 // parseImportString uses the url library to parse the importedPath. Depending on the parsed
@@ -246,92 +881,310 @@ func (m *MultiImporter) Import(importedFrom, importedPath string) (jsonnet.Conte
 func (m *MultiImporter) parseImportString(importedFrom, importedPath string) (string, error) {
 	parsedURL, err := url.Parse(importedPath)
 	if err != nil {
-		return "", fmt.Errorf("%w: '%s', error: %w", ErrMalformedImport, importedPath, err)
+		// the importer behind a prefix can still handle such a path, like the
+		// alternatives of the `glob.first://` prefix
+		scheme, _, found := strings.Cut(importedPath, "://")
+		if !found || !m.acceptsRawPath(scheme) {
+			return "", fmt.Errorf("%w: '%s', error: %w", ErrMalformedImport, importedPath, err)
+		}
+
+		m.importCounter++
+
+		return scheme, nil
 	}
 
 	prefix := parsedURL.Scheme
 	switch prefix {
 	case "config":
+		if parsedURL.Host == "get" {
+			// each `config://get` needs its own foundAt value, because the
+			// settings can change during the evaluation
+			m.importCounter++
+
+			return prefix, nil
+		}
+
 		if err := m.parseInFileConfigs(parsedURL.RawQuery); err != nil {
 			return "", fmt.Errorf("in importedPath: '%s', error: %w", importedPath, err)
 		}
 
 		return prefix, nil
 	case "": // "normal" imports
-		if !m.ignoreImportCycles {
-			if err := m.findImportCycle(importedFrom, importedPath); err != nil {
-				return "",
-					fmt.Errorf("%w detected with adding %s to %s. DOT-graph stored in '%s'",
-						ErrImportCycle, importedFrom, importedPath, m.importGraphFile)
-			}
+		if err := m.trackImport(importedFrom, importedPath); err != nil {
+			return "", err
 		}
+	}
+	// set the level/weight inside the graph
+	m.importCounter++
+
+	return prefix, nil
+}
 
-		if m.enableImportGraph {
-			if err := m.storeImportGraph(); err != nil {
-				return "", err
+// resolveRelative resolves a plain import inside a file of an importer
+// implementing the RelativeImporter interface.
+func (m *MultiImporter) resolveRelative(importedFrom, importedPath string) (string, bool) {
+	if strings.Contains(importedPath, "://") || !strings.Contains(importedFrom, "://") {
+		return "", false
+	}
+
+	for _, importer := range m.importers {
+		if relative, ok := importer.(RelativeImporter); ok {
+			if resolved, ok := relative.ResolveRelative(importedFrom, importedPath); ok {
+				return resolved, true
 			}
 		}
 	}
+
+	return "", false
+}
+
+// acceptsRawPath returns true, if an importer for the prefix implements the
+// RawPathImporter interface and accepts paths, which are no valid URLs.
+func (m *MultiImporter) acceptsRawPath(prefix string) bool {
+	if prefix == "" || prefix == "config" {
+		return false
+	}
+
+	for _, importer := range m.importers {
+		if raw, ok := importer.(RawPathImporter); ok && importer.CanHandle(prefix) && raw.AcceptsRawPath(prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// trackDepth stores the length of the import chain for the given foundAt
+// value, which will be the importedFrom value of its own imports.
+func (m *MultiImporter) trackDepth(foundAt string, depth int) {
+	if m.importDepths == nil {
+		m.importDepths = map[string]int{}
+	}
+
+	m.importDepths[foundAt] = depth
+}
+
+// trackFile stores the foundAt value of a successful import for the lock
+// file, unless it is a prefixed path, like an `eval://` value.
+func (m *MultiImporter) trackFile(foundAt string) {
+	if strings.Contains(foundAt, "://") {
+		return
+	}
+
+	if m.files == nil {
+		m.files = map[string]bool{}
+	}
+
+	m.files[filepath.Clean(foundAt)] = true
+}
+
+// configVerb returns the host of a `config://<verb>` import, like "set" or
+// "get".
+func configVerb(importedPath string) string {
+	parsedURL, err := url.Parse(importedPath)
+	if err != nil {
+		return ""
+	}
+
+	return parsedURL.Host
+}
+
+// settingsContents returns the current settings as JSON object for the
+// `config://get` import.
+func (m *MultiImporter) settingsContents(foundAt string) (jsonnet.Contents, string, error) {
+	data, err := json.Marshal(settings{
+		LogLevel: m.logLevel,
+		ImportGraph: importGraphSettings{
+			Enabled: m.enableImportGraph,
+			File:    m.importGraphFile,
+		},
+		IgnoreImportCycles: m.ignoreImportCycles,
+	})
+	if err != nil {
+		return jsonnet.MakeContents(""), "", fmt.Errorf("while marshaling the settings: %w", err)
+	}
+
+	return jsonnet.MakeContentsRaw(data), foundAt, nil
+}
+
+// isPlainImport returns true, if the importedPath contains no character, which
+// could lead to a prefix, query, fragment or escaped character in url.Parse.
+func isPlainImport(importedPath string) bool {
+	return !strings.ContainsAny(importedPath, ":?#%")
+}
+
+// parsePlainImport is the fast path of parseImportString for imports without
+// prefix and query (see isPlainImport). It skips the URL parsing, but still
+// checks for import cycles.
+func (m *MultiImporter) parsePlainImport(importedFrom, importedPath string) error {
+	if err := m.trackImport(importedFrom, importedPath); err != nil {
+		return err
+	}
 	// set the level/weight inside the graph
 	m.importCounter++
 
-	return prefix, nil
+	return nil
+}
+
+// trackImport adds a "normal" import to the import graph, checks for import
+// cycles and stores the import graph, if enabled.
+func (m *MultiImporter) trackImport(importedFrom, importedPath string) error {
+	if !m.ignoreImportCycles {
+		if err := m.findImportCycle(importedFrom, importedPath); err != nil {
+			return fmt.Errorf("%w detected with adding %s to %s. DOT-graph stored in '%s'",
+				ErrImportCycle, importedFrom, importedPath, m.importGraphFile)
+		}
+	}
+
+	if m.enableImportGraph && !m.graphOnErrorOnly {
+		if err := m.storeImportGraph(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (m *MultiImporter) storeImportGraph() error {
-	image, err := m.fs.Create(m.importGraphFile)
+	image, err := m.fs.OpenFile(m.importGraphFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, m.importGraphFileMode)
 	if err != nil {
 		return fmt.Errorf("while storing import graph to file '%s', error: %w", m.importGraphFile, err)
 	}
+	defer image.Close()
+
+	importGraph := m.importGraph
+	if m.hideGraphRoot {
+		if importGraph, err = withoutRoot(importGraph); err != nil {
+			return fmt.Errorf("while hiding the root of the import graph, error: %w", err)
+		}
+	}
 
-	return draw.DOT(m.importGraph, image)
+	if m.highlightLongest {
+		if importGraph, err = highlightLongestPath(importGraph); err != nil {
+			return fmt.Errorf("while highlighting the longest import path, error: %w", err)
+		}
+	}
+
+	return draw.DOT(importGraph, image)
 }
 
 func (m *MultiImporter) findImportCycle(importedFrom, importedPath string) error {
+	if m.canonicalizePaths {
+		return m.findCanonicalImportCycle(importedFrom, importedPath)
+	}
+
 	cImportedFrom := filepath.Clean(importedFrom)
 
 	_ = m.importGraph.AddVertex(cImportedFrom, graph.VertexAttribute("shape", "invhouse"))
-	_ = m.importGraph.AddVertex(importedPath, graph.VertexAttribute("shape", "house"))
+	// a new vertex has no outgoing edges and therefore cannot create a cycle,
+	// which saves the expensive graph.CreatesCycle call
+	isNew := m.importGraph.AddVertex(importedPath, graph.VertexAttribute("shape", "house")) == nil
 
-	if hasCycle, _ := graph.CreatesCycle(m.importGraph, cImportedFrom, importedPath); hasCycle {
-		_ = m.importGraph.AddEdge(
-			cImportedFrom, importedPath, graph.EdgeWeight(m.importCounter), graph.EdgeAttribute("color", "red"),
-		)
+	if hasCycle := !isNew && createsCycle(m.importGraph, cImportedFrom, importedPath); hasCycle {
+		m.addEdge(cImportedFrom, importedPath, graph.EdgeAttribute("color", "red"))
 
-		image, _ := m.fs.Create(m.importGraphFile)
-		_ = draw.DOT(m.importGraph, image)
+		_ = m.storeImportGraph()
 
 		return fmt.Errorf("%w detected with adding %s to %s. DOT-Graph stored in '%s'",
 			ErrImportCycle, cImportedFrom, importedPath, m.importGraphFile)
 	}
 
-	_ = m.importGraph.AddEdge(cImportedFrom, importedPath, graph.EdgeWeight(m.importCounter))
+	m.addEdge(cImportedFrom, importedPath)
 
 	// given importedPath can also be relative to caller therefore get the whole path too
 	cwd, _ := filepath.Split(importedFrom)
 	resolvedPath := filepath.Join(cwd, importedPath)
 	// importedPath is given relative to caller ?
 	if importedPath != resolvedPath {
-		_ = m.importGraph.AddVertex(resolvedPath)
+		isNew := m.importGraph.AddVertex(resolvedPath) == nil
 
-		if cycle, _ := graph.CreatesCycle(m.importGraph, importedPath, resolvedPath); cycle {
-			_ = m.importGraph.AddEdge(
-				importedPath, resolvedPath, graph.EdgeWeight(m.importCounter), graph.EdgeAttribute("color", "red"),
-			)
+		if cycle := !isNew && createsCycle(m.importGraph, importedPath, resolvedPath); cycle {
+			m.addEdge(importedPath, resolvedPath, graph.EdgeAttribute("color", "red"))
 
-			image, _ := m.fs.Create(m.importGraphFile)
-			_ = draw.DOT(m.importGraph, image)
+			_ = m.storeImportGraph()
 
 			return fmt.Errorf("%w detected with adding %s to %s. DOT-Graph stored in '%s'",
 				ErrImportCycle, importedPath, resolvedPath, m.importGraphFile)
 		}
 
-		_ = m.importGraph.AddEdge(importedPath, resolvedPath, graph.EdgeWeight(m.importCounter))
+		m.addEdge(importedPath, resolvedPath)
 	}
 
 	return nil
 }
 
+// findCanonicalImportCycle is the variant of findImportCycle for canonicalized
+// paths: the importing and the imported file are each represented by exactly
+// one vertex. Prefixed imports, like `glob+://*.libsonnet`, and the files
+// imported by them are no paths relative to each other.
+func (m *MultiImporter) findCanonicalImportCycle(importedFrom, importedPath string) error {
+	target := importedPath
+	if !strings.Contains(importedFrom, "://") && !strings.Contains(importedPath, "://") {
+		target = filepath.Join(filepath.Dir(importedFrom), importedPath)
+	}
+
+	cImportedFrom := canonicalVertex(importedFrom)
+	cImportedPath := canonicalVertex(target)
+
+	_ = m.importGraph.AddVertex(cImportedFrom, graph.VertexAttribute("shape", "invhouse"))
+	isNew := m.importGraph.AddVertex(cImportedPath, graph.VertexAttribute("shape", "house")) == nil
+
+	if hasCycle := !isNew && createsCycle(m.importGraph, cImportedFrom, cImportedPath); hasCycle {
+		m.addEdge(cImportedFrom, cImportedPath, graph.EdgeAttribute("color", "red"))
+
+		_ = m.storeImportGraph()
+
+		return fmt.Errorf("%w detected with adding %s to %s. DOT-Graph stored in '%s'",
+			ErrImportCycle, cImportedFrom, cImportedPath, m.importGraphFile)
+	}
+
+	m.addEdge(cImportedFrom, cImportedPath)
+
+	return nil
+}
+
+// canonicalVertex returns the canonical path for the vertex of a file inside
+// the import graph. Prefixed imports, like `glob+://*.libsonnet`, stay as
+// they are.
+func canonicalVertex(name string) string {
+	if strings.Contains(name, "://") {
+		return name
+	}
+
+	return canonicalPath(name)
+}
+
+// canonicalPath returns the cleaned path with resolved symlinks. Paths, which
+// cannot be resolved, like not (yet) existing files, will only be cleaned.
+func canonicalPath(path string) string {
+	cleaned := filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(cleaned); err == nil {
+		return resolved
+	}
+
+	return cleaned
+}
+
+// addEdge adds an edge with the current import counter as weight to the import
+// graph and streams it, if enabled.
+func (m *MultiImporter) addEdge(source, target string, options ...func(*graph.EdgeProperties)) {
+	options = append([]func(*graph.EdgeProperties){graph.EdgeWeight(m.importCounter)}, options...)
+	if err := m.importGraph.AddEdge(source, target, options...); err != nil {
+		return
+	}
+
+	if err := writeImportEvent(m.events, importEvent{From: source, To: target, Weight: m.importCounter}); err != nil {
+		m.logger.Warn("while streaming the import event", zap.Error(err))
+	}
+}
+
+// createsCycle is a wrapper for graph.CreatesCycle, which ignores the error.
+func createsCycle(g graph.Graph[string, string], source, target string) bool {
+	hasCycle, _ := graph.CreatesCycle(g, source, target)
+
+	return hasCycle
+}
+
 func (m *MultiImporter) parseInFileConfigs(rawQuery string) error {
 	query, err := url.ParseQuery(rawQuery)
 	if err != nil {
@@ -348,6 +1201,98 @@ func (m *MultiImporter) parseInFileConfigs(rawQuery string) error {
 		m.ignoreImportCycles = true
 	}
 
+	for _, option := range globOptions {
+		value, exists := query[option.key]
+		if !exists {
+			continue
+		}
+
+		enabled, err := parseBoolConfig(option.key, value[0])
+		if err != nil {
+			return err
+		}
+
+		_ = m.eachGlob(func(g *GlobImporter) error {
+			option.set(g, enabled)
+
+			return nil
+		})
+	}
+
+	if ignoreFile, exists := query["globIgnoreFile"]; exists {
+		if err := m.eachGlob(func(g *GlobImporter) error { return g.SetIgnoreFile(ignoreFile[0]) }); err != nil {
+			return err
+		}
+	}
+
+	if format, exists := query["globFormat"]; exists {
+		if err := m.eachGlob(func(g *GlobImporter) error { return g.SetFormat(format[0]) }); err != nil {
+			return err
+		}
+	}
+
+	if onError, exists := query["graphOnErrorOnly"]; exists {
+		enabled, err := parseBoolConfig("graphOnErrorOnly", onError[0])
+		if err != nil {
+			return err
+		}
+
+		m.StoreGraphOnErrorOnly(enabled)
+	}
+
+	if canonicalize, exists := query["canonicalizePaths"]; exists {
+		if m.canonicalizePaths, err = parseBoolConfig("canonicalizePaths", canonicalize[0]); err != nil {
+			return err
+		}
+	}
+
+	if hide, exists := query["graphHideRoot"]; exists {
+		if m.hideGraphRoot, err = parseBoolConfig("graphHideRoot", hide[0]); err != nil {
+			return err
+		}
+	}
+
+	if highlight, exists := query["graphHighlightLongest"]; exists {
+		if m.highlightLongest, err = parseBoolConfig("graphHighlightLongest", highlight[0]); err != nil {
+			return err
+		}
+	}
+
+	if depth, exists := query["maxImportDepth"]; exists {
+		if m.maxImportDepth, err = strconv.Atoi(depth[0]); err != nil || m.maxImportDepth < 0 {
+			m.maxImportDepth = 0
+
+			return fmt.Errorf("%w: maxImportDepth=%s, supported is a positive number or 0 for unlimited",
+				ErrUnknownConfig, depth[0])
+		}
+	}
+
+	if maxBytes, exists := query["maxTotalBytes"]; exists {
+		n, err := strconv.ParseInt(maxBytes[0], 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%w: maxTotalBytes=%s, supported is a positive number or 0 for unlimited",
+				ErrUnknownConfig, maxBytes[0])
+		}
+
+		// the limit set from go code can only be lowered
+		if m.goMaxTotalBytes > 0 && (n == 0 || n > m.goMaxTotalBytes) {
+			m.logger.Warn("maxTotalBytes exceeds the limit set from go code, which will be kept",
+				zap.Int64("maxTotalBytes", n),
+				zap.Int64("limit", m.goMaxTotalBytes),
+			)
+
+			n = m.goMaxTotalBytes
+		}
+
+		m.maxTotalBytes = n
+	}
+
+	if fallthroughOnError, exists := query["fallthroughOnError"]; exists {
+		if m.fallthroughOnError, err = parseBoolConfig("fallthroughOnError", fallthroughOnError[0]); err != nil {
+			return err
+		}
+	}
+
 	if use, exists := query["onMissingFile"]; exists && use[0] != "" {
 		o := &onMissingFile{
 			enabled: true,
@@ -367,22 +1312,9 @@ func (m *MultiImporter) parseInFileConfigs(rawQuery string) error {
 	if level, exists := query["logLevel"]; exists {
 		m.logLevel = level[0]
 
-		var logger *zap.Logger
-
-		switch m.logLevel {
-		case "debug":
-			logger, err = zap.NewDevelopment()
-			if err != nil {
-				return fmt.Errorf("while setting debug logger: %w", err)
-			}
-		case "info":
-			logger, err = zap.NewProduction()
-			if err != nil {
-				return fmt.Errorf("while setting info logger: %w", err)
-			}
-		default:
-			return fmt.Errorf("%w: logLevel=%s, supported are 'logLevel=debug' or 'logLevel=info'",
-				ErrUnknownConfig, m.logLevel)
+		logger, err := newLoggerFor(m.logLevel)
+		if err != nil {
+			return err
 		}
 
 		m.Logger(logger)
@@ -391,8 +1323,129 @@ func (m *MultiImporter) parseInFileConfigs(rawQuery string) error {
 	return nil
 }
 
+// globOptions are the boolean options of the GlobImporters, which can be set
+// via `config://set`, with their query keys.
+var globOptions = []struct {
+	key string
+	set func(g *GlobImporter, enabled bool)
+}{
+	{"strictJPaths", (*GlobImporter).StrictJPaths},
+	{"annotate", (*GlobImporter).Annotate},
+	{"skipBrokenFiles", (*GlobImporter).SkipBrokenFiles},
+	{"rebaseImports", (*GlobImporter).RebaseImports},
+	{"eagerCycleCheck", (*GlobImporter).EagerCycleCheck},
+	{"detectDuplicateContent", (*GlobImporter).DetectDuplicateContent},
+	{"perDirConfig", (*GlobImporter).PerDirConfig},
+	{"warnShadowed", (*GlobImporter).WarnShadowed},
+	{"warnTrivialGlob", (*GlobImporter).WarnTrivialGlob},
+	{"identifierKeys", (*GlobImporter).IdentifierKeys},
+}
+
+// eachGlob calls fn for each GlobImporter of the MultiImporter, also behind a
+// DecoratingImporter, and stops at the first error.
+func (m *MultiImporter) eachGlob(fn func(g *GlobImporter) error) error {
+	for _, i := range m.importers {
+		if g, ok := globImporterOf(i); ok {
+			if err := fn(g); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// newLoggerFor returns a new zap.Logger for the given logLevel, which can be
+// either "debug" or "info".
+func newLoggerFor(logLevel string) (*zap.Logger, error) {
+	switch logLevel {
+	case "debug":
+		logger, err := zap.NewDevelopment()
+		if err != nil {
+			return nil, fmt.Errorf("while setting debug logger: %w", err)
+		}
+
+		return logger, nil
+	case "info":
+		logger, err := zap.NewProduction()
+		if err != nil {
+			return nil, fmt.Errorf("while setting info logger: %w", err)
+		}
+
+		return logger, nil
+	default:
+		return nil, fmt.Errorf("%w: logLevel=%s, supported are 'logLevel=debug' or 'logLevel=info'",
+			ErrUnknownConfig, logLevel)
+	}
+}
+
+// logLevels are the supported values of the `logLevel` query parameter.
+var logLevels = map[string]zapcore.Level{
+	"debug": zapcore.DebugLevel,
+	"info":  zapcore.InfoLevel,
+}
+
+// withAtomicLevel wraps the core of the given logger, so that the given
+// zap.AtomicLevel decides, which entries will be written (see
+// atomicLevelCore).
+func withAtomicLevel(logger *zap.Logger, level zap.AtomicLevel) *zap.Logger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &atomicLevelCore{Core: core, level: level}
+	}))
+}
+
+// atomicLevelCore writes the entries of the level of its zap.AtomicLevel and
+// above to the wrapped core, even if the wrapped core itself does not enable
+// them, like debug entries for a core with the info level. With the level
+// zapcore.InvalidLevel, the level of the wrapped core applies.
+type atomicLevelCore struct {
+	zapcore.Core
+	level zap.AtomicLevel
+}
+
+func (c *atomicLevelCore) Enabled(level zapcore.Level) bool {
+	if c.level.Level() == zapcore.InvalidLevel {
+		return c.Core.Enabled(level)
+	}
+
+	return c.level.Enabled(level)
+}
+
+func (c *atomicLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &atomicLevelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *atomicLevelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+
+	if c.Core.Enabled(entry.Level) {
+		return c.Core.Check(entry, checked)
+	}
+
+	// the wrapped core would drop the entry, so write it directly
+	return checked.AddCore(entry, c)
+}
+
+// parseBoolConfig parses the value of a boolean config. An empty value means
+// true, like in `config://set?strictJPaths`.
+func parseBoolConfig(name, value string) (bool, error) {
+	if value == "" {
+		return true, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s=%s, supported are '%s=<true|false>'",
+			ErrUnknownConfig, name, value, name)
+	}
+
+	return enabled, nil
+}
+
 // stringKeysFromMap returns the keys from a map as slice.
-func stringKeysFromMap(m map[string]string) []string {
+func stringKeysFromMap[V any](m map[string]V) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
@@ -1,10 +1,14 @@
 package importer
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dominikbraun/graph"
 	"github.com/dominikbraun/graph/draw"
@@ -26,6 +30,15 @@ func TestMultiImporter_parseInFileConfigs(t *testing.T) {
 		args                   args
 		wantEnableImportGraph  bool
 		wantIgnoreImportCycles bool
+		wantStrictJPaths       bool
+		wantFallthroughOnError bool
+		wantHighlightLongest   bool
+		wantAnnotate           bool
+		wantDetectDuplicate    bool
+		wantSkipBrokenFiles    bool
+		wantMaxImportDepth     int
+		wantGlobFormat         string
+		wantEagerCycleCheck    bool
 		wantOnMissingFile      *onMissingFile
 		wantErr                bool
 		wantErrType            error
@@ -89,6 +102,122 @@ func TestMultiImporter_parseInFileConfigs(t *testing.T) {
 			wantImportGraphFile:    importGraphFileName,
 			wantIgnoreImportCycles: true,
 		},
+		{
+			name: "strictJPaths",
+			args: args{
+				rawQuery: "strictJPaths",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantStrictJPaths:    true,
+		},
+		{
+			name: "strictJPaths_false",
+			args: args{
+				rawQuery: "strictJPaths=false",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantStrictJPaths:    false,
+		},
+		{
+			name: "strictJPaths_unknown_value_error",
+			args: args{
+				rawQuery: "strictJPaths=maybe",
+			},
+			wantErr:             true,
+			wantErrType:         ErrUnknownConfig,
+			wantImportGraphFile: importGraphFileName,
+		},
+		{
+			name: "fallthroughOnError",
+			args: args{
+				rawQuery: "fallthroughOnError=true",
+			},
+			wantImportGraphFile:    importGraphFileName,
+			wantFallthroughOnError: true,
+		},
+		{
+			name: "graphHighlightLongest",
+			args: args{
+				rawQuery: "graphHighlightLongest=true",
+			},
+			wantImportGraphFile:  importGraphFileName,
+			wantHighlightLongest: true,
+		},
+		{
+			name: "annotate",
+			args: args{
+				rawQuery: "annotate",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantAnnotate:        true,
+		},
+		{
+			name: "detectDuplicateContent",
+			args: args{
+				rawQuery: "detectDuplicateContent=true",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantDetectDuplicate: true,
+		},
+		{
+			name: "maxImportDepth",
+			args: args{
+				rawQuery: "maxImportDepth=50",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantMaxImportDepth:  50,
+		},
+		{
+			name: "maxImportDepth_negative",
+			args: args{
+				rawQuery: "maxImportDepth=-1",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantErr:             true,
+			wantErrType:         ErrUnknownConfig,
+		},
+		{
+			name: "eagerCycleCheck",
+			args: args{
+				rawQuery: "eagerCycleCheck=true",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantEagerCycleCheck: true,
+		},
+		{
+			name: "globFormat",
+			args: args{
+				rawQuery: "globFormat=compact",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantGlobFormat:      "compact",
+		},
+		{
+			name: "globFormat_unknown_value",
+			args: args{
+				rawQuery: "globFormat=fancy",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantErr:             true,
+			wantErrType:         ErrUnknownConfig,
+		},
+		{
+			name: "skipBrokenFiles",
+			args: args{
+				rawQuery: "skipBrokenFiles=true",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantSkipBrokenFiles: true,
+		},
+		{
+			name: "detectDuplicateContent_unknown_value",
+			args: args{
+				rawQuery: "detectDuplicateContent=maybe",
+			},
+			wantImportGraphFile: importGraphFileName,
+			wantErr:             true,
+			wantErrType:         ErrUnknownConfig,
+		},
 		{
 			name: "onMissingFile_file",
 			args: args{
@@ -131,6 +260,15 @@ func TestMultiImporter_parseInFileConfigs(t *testing.T) {
 			assert.Equal(t, tt.wantLogLevel, m.logLevel)
 			assert.Equal(t, tt.wantImportGraphFile, m.importGraphFile)
 			assert.Equal(t, tt.wantEnableImportGraph, m.enableImportGraph)
+			assert.Equal(t, tt.wantStrictJPaths, m.importers[0].(*GlobImporter).strictJPaths)
+			assert.Equal(t, tt.wantFallthroughOnError, m.fallthroughOnError)
+			assert.Equal(t, tt.wantHighlightLongest, m.highlightLongest)
+			assert.Equal(t, tt.wantAnnotate, m.importers[0].(*GlobImporter).annotate)
+			assert.Equal(t, tt.wantDetectDuplicate, m.importers[0].(*GlobImporter).detectDuplicateContent)
+			assert.Equal(t, tt.wantSkipBrokenFiles, m.importers[0].(*GlobImporter).skipBrokenFiles)
+			assert.Equal(t, tt.wantMaxImportDepth, m.maxImportDepth)
+			assert.Equal(t, tt.wantGlobFormat, m.importers[0].(*GlobImporter).format)
+			assert.Equal(t, tt.wantEagerCycleCheck, m.importers[0].(*GlobImporter).eagerCycleCheck)
 
 		})
 	}
@@ -156,7 +294,7 @@ func TestMultiImporter_InFileConfigs(t *testing.T) {
 		``,
 		`	"glob.stem+://libs/*.libsonnet" [ color="grey", fontcolor="grey", shape="rect", style="dashed",  weight=0 ];`,
 		``,
-		`	"glob.stem+://libs/*.libsonnet" -> "libs/host.libsonnet" [ color="grey", style="dashed",  weight=5 ];`,
+		`	"glob.stem+://libs/*.libsonnet" -> "libs/host.libsonnet" [ color="grey", origin="glob", style="dashed",  weight=5 ];`,
 		``,
 		`	"libs/host.libsonnet" [ color="grey", fontcolor="grey", shape="rect", style="dashed",  weight=0 ];`,
 		``,
@@ -296,6 +434,101 @@ func addRelativesToGraph(
 	return g
 }
 
+func TestMultiImporter_ConfigGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{
+			name:    "default settings",
+			snippet: "import 'config://get'",
+			want: `{
+   "ignoreImportCycles": false,
+   "importGraph": {
+      "enabled": false,
+      "file": "import_graph.gv"
+   },
+   "logLevel": ""
+}
+`,
+		},
+		{
+			name: "settings after config set",
+			snippet: `local set = import 'config://set?ignoreImportCycles&importGraph=graph.gv';
+set + import 'config://get'`,
+			want: `{
+   "ignoreImportCycles": true,
+   "importGraph": {
+      "enabled": true,
+      "file": "graph.gv"
+   },
+   "logLevel": ""
+}
+`,
+		},
+		{
+			name: "multiple gets",
+			snippet: `local before = import 'config://get';
+local set = before + import 'config://set?ignoreImportCycles';
+[before.ignoreImportCycles, (set + import 'config://get').ignoreImportCycles]`,
+			want: "[\n   false,\n   true\n]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter(NewGlobImporter(), NewFallbackFileImporter())
+			m.fs = afero.NewMemMapFs()
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(m)
+
+			got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", tt.snippet)
+			if err != nil {
+				t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMultiImporter_ConfigAccessors(t *testing.T) {
+	tests := []struct {
+		name                   string
+		rawQuery               string
+		wantLogLevel           string
+		wantImportGraphFile    string
+		wantImportGraphEnabled bool
+		wantCyclesIgnored      bool
+	}{
+		{
+			name:                "default settings",
+			rawQuery:            "",
+			wantImportGraphFile: importGraphFileName,
+		},
+		{
+			name:                   "all settings",
+			rawQuery:               "logLevel=debug&importGraph=graph.gv&ignoreImportCycles",
+			wantLogLevel:           "debug",
+			wantImportGraphFile:    "graph.gv",
+			wantImportGraphEnabled: true,
+			wantCyclesIgnored:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter()
+			assert.NoError(t, m.parseInFileConfigs(tt.rawQuery))
+
+			assert.Equal(t, tt.wantLogLevel, m.LogLevel())
+			assert.Equal(t, tt.wantImportGraphFile, m.ImportGraphFile())
+			assert.Equal(t, tt.wantImportGraphEnabled, m.ImportGraphEnabled())
+			assert.Equal(t, tt.wantCyclesIgnored, m.CyclesIgnored())
+		})
+	}
+}
+
 func TestMultiImporter_parseImportString(t *testing.T) {
 	type args struct {
 		importedFrom string
@@ -334,6 +567,64 @@ func TestMultiImporter_parseImportString(t *testing.T) {
 			wantErr:     false,
 			wantErrType: nil,
 		},
+		{
+			name: "importPath not allowed inside an URL - prefix is still returned",
+			args: args{
+				importedFrom: "caller.jsonnet",
+				importedPath: "glob.first://config.libsonnet | defaults/*.libsonnet",
+			},
+			fields: fields{
+				importGraph: graph.New(
+					graph.StringHash, graph.Tree(), graph.Directed(), graph.Weighted(),
+				),
+			},
+			want: "glob.first",
+		},
+		{
+			name: "importPath not allowed inside an URL for an importer without raw paths - should fail",
+			args: args{
+				importedFrom: "caller.jsonnet",
+				importedPath: "yaml://config | defaults.yaml",
+			},
+			fields: fields{
+				importGraph: graph.New(
+					graph.StringHash, graph.Tree(), graph.Directed(), graph.Weighted(),
+				),
+			},
+			want:        "",
+			wantErr:     true,
+			wantErrType: ErrMalformedImport,
+		},
+		{
+			name: "importPath not allowed inside an URL for an unknown prefix - should fail",
+			args: args{
+				importedFrom: "caller.jsonnet",
+				importedPath: "unknown://config | defaults",
+			},
+			fields: fields{
+				importGraph: graph.New(
+					graph.StringHash, graph.Tree(), graph.Directed(), graph.Weighted(),
+				),
+			},
+			want:        "",
+			wantErr:     true,
+			wantErrType: ErrMalformedImport,
+		},
+		{
+			name: "malformed importPath without prefix - should fail",
+			args: args{
+				importedFrom: "caller.jsonnet",
+				importedPath: "config%zz.libsonnet",
+			},
+			fields: fields{
+				importGraph: graph.New(
+					graph.StringHash, graph.Tree(), graph.Directed(), graph.Weighted(),
+				),
+			},
+			want:        "",
+			wantErr:     true,
+			wantErrType: ErrMalformedImport,
+		},
 		{
 			name: "importPath without ignoreImportCycles set - should fail as importcycle exists",
 			args: args{
@@ -593,6 +884,131 @@ func TestMultiImporter_findImportCycle(t *testing.T) {
 	}
 }
 
+func TestMultiImporter_findImportCycleCanonicalized(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "host.libsonnet"), []byte("{}"), 0o644); err != nil {
+		t.Errorf("os.WriteFile() error = %v", err)
+		return
+	}
+	if err := os.Symlink(filepath.Join(dir, "host.libsonnet"), filepath.Join(dir, "link.libsonnet")); err != nil {
+		t.Skipf("os.Symlink() not supported: %v", err)
+	}
+	host, _ := filepath.EvalSymlinks(filepath.Join(dir, "host.libsonnet"))
+	caller := filepath.Join(dir, "caller.jsonnet")
+
+	tests := []struct {
+		name         string
+		importGraph  graph.Graph[string, string]
+		importedFrom string
+		importedPath string
+		want         graph.Graph[string, string]
+		wantErr      bool
+	}{
+		{
+			name:         "importedPath_is_relative",
+			importGraph:  graph.New(graph.StringHash, graph.Tree(), graph.Directed(), graph.Weighted()),
+			importedFrom: "testdata/caller.jsonnet",
+			importedPath: "host.libsonnet",
+			//
+			// [testdata/caller.jsonnet] --> [testdata/host.libsonnet]
+			//
+			want: createGraph("testdata/caller.jsonnet", "testdata/host.libsonnet", 0, false),
+		},
+		{
+			name:         "cycle_indirectly_through_resolved_importPath",
+			importGraph:  createGraph("testdata/caller.jsonnet", "testdata/host.libsonnet", 0, false),
+			importedFrom: "testdata/host.libsonnet",
+			importedPath: "caller.jsonnet",
+			//
+			// [testdata/caller.jsonnet] --> [testdata/host.libsonnet]
+			//          ^                      /
+			//          +---------------------+
+			want: addRelativesToGraph(
+				createGraph("testdata/caller.jsonnet", "testdata/host.libsonnet", 0, false),
+				"testdata/host.libsonnet", "testdata/caller.jsonnet", 0, true,
+			),
+			wantErr: true,
+		},
+		{
+			name:         "same_file_via_different_relative_paths",
+			importGraph:  createGraph("testdata/caller.jsonnet", "testdata/host.libsonnet", 0, false),
+			importedFrom: "testdata/./sub/../caller.jsonnet",
+			importedPath: "sub/../host.libsonnet",
+			want:         createGraph("testdata/caller.jsonnet", "testdata/host.libsonnet", 0, false),
+		},
+		{
+			name:         "prefixed_importedFrom_is_no_directory",
+			importGraph:  graph.New(graph.StringHash, graph.Tree(), graph.Directed(), graph.Weighted()),
+			importedFrom: "mem://lib/caller.jsonnet",
+			importedPath: "host.libsonnet",
+			want:         createGraph("mem://lib/caller.jsonnet", "host.libsonnet", 0, false),
+		},
+		{
+			name:         "symlink_resolves_to_the_target",
+			importGraph:  graph.New(graph.StringHash, graph.Tree(), graph.Directed(), graph.Weighted()),
+			importedFrom: caller,
+			importedPath: "link.libsonnet",
+			want:         createGraph(caller, host, 0, false),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter()
+			m.CanonicalizePaths(true)
+			m.importGraph = tt.importGraph
+			m.fs = afero.NewMemMapFs()
+
+			err := m.findImportCycle(tt.importedFrom, tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MultiImporter.findImportCycle() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrImportCycle)
+			}
+
+			want, _ := tt.want.AdjacencyMap()
+			got, _ := m.importGraph.AdjacencyMap()
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestMultiImporter_CanonicalizePathsGlob(t *testing.T) {
+	dir := "testdata/canonicalGlob"
+
+	m := NewMultiImporter(NewGlobImporter(), NewFallbackFileImporter())
+	m.CanonicalizePaths(true)
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	if _, err := vm.EvaluateFile(filepath.Join(dir, "main.jsonnet")); err != nil {
+		t.Errorf("vm.EvaluateFile() error = %v", err)
+		return
+	}
+
+	adjacency, err := m.importGraph.AdjacencyMap()
+	if err != nil {
+		t.Errorf("importGraph.AdjacencyMap() error = %v", err)
+		return
+	}
+
+	vertices := []string{}
+	for vertex := range adjacency {
+		if strings.HasSuffix(vertex, "a.libsonnet") {
+			vertices = append(vertices, vertex)
+		}
+	}
+	// the same file via the glob, the plain import and the import inside
+	// lib/b.libsonnet
+	a := canonicalPath(filepath.Join(dir, "lib", "a.libsonnet"))
+	assert.Equal(t, []string{a}, vertices)
+	assert.Contains(t, adjacency["glob+://lib/*.libsonnet"], a)
+	assert.Contains(t, adjacency[canonicalPath(filepath.Join(dir, "main.jsonnet"))], a)
+	assert.Contains(t, adjacency[canonicalPath(filepath.Join(dir, "lib", "b.libsonnet"))], a)
+}
+
 func TestMultiImporter_Behavior(t *testing.T) {
 	lvl := zap.NewAtomicLevel()
 	cfg := zap.NewDevelopmentEncoderConfig()
@@ -822,37 +1238,862 @@ func TestMultiImporter_OnMissingFileBehavior(t *testing.T) {
 
 }
 
-var excpectedComplexOutput = `{
-   "dot": {
-      "host": {
-         "checksum": 3,
-         "imports": [
-            "testdata/globDot/host.libsonnet",
-            "testdata/globDot/subfolder/host.libsonnet",
-            "testdata/globDot/subfolder/subsubfolder/host.libsonnet"
-         ]
-      }
-   },
-   "dot_continuous": {
-      "caller_dot_path": {
-         "checksum": 1,
-         "imports": [
-            "testdata/globDot/caller_dot_path.jsonnet",
-            "testdata/globDot/host.libsonnet"
-         ],
-         "names": [
-            "host.libsonnet"
-         ]
-      },
-      "caller_dot_stem_double_star": {
-         "checksum": 1,
-         "imports": [
-            "testdata/globDot/caller_dot_stem_double_star.jsonnet",
-            "testdata/globDot/subfolder/subsubfolder/host.libsonnet"
-         ],
-         "names": [
-            "host"
-         ]
+func TestFallbackFileImporter_Import(t *testing.T) {
+	tests := []struct {
+		name         string
+		importedPath string
+		wantNotFound bool
+		wantErr      bool
+	}{
+		{
+			name:         "existing file",
+			importedPath: "testdata/simple/default.jsonnet",
+		},
+		{
+			name:         "missing file",
+			importedPath: "testdata/simple/missing.jsonnet",
+			wantNotFound: true,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFallbackFileImporter()
+			_, _, err := f.Import("", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FallbackFileImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.wantNotFound, errors.Is(err, ErrFileNotFound))
+			if tt.wantErr {
+				// the original error of the go-jsonnet FileImporter is kept
+				assert.Contains(t, err.Error(), "no match locally")
+			}
+		})
+	}
+}
+
+func TestFallbackFileImporter_SetFilesystems(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"app/main.jsonnet":        "{}",
+		"app/local.libsonnet":     "{local: true}",
+		"vendor/lib.libsonnet":    "{vendor: true}",
+		"overrides/lib.libsonnet": "{overrides: true}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Fatalf("afero.WriteFile() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		wantFoundAt  string
+		wantContent  string
+		wantErr      bool
+	}{
+		{
+			name:         "relative to the importing file",
+			importedPath: "local.libsonnet",
+			wantFoundAt:  "app/local.libsonnet",
+			wantContent:  "{local: true}",
+		},
+		{
+			name:         "last jpath first",
+			importedPath: "lib.libsonnet",
+			wantFoundAt:  "overrides/lib.libsonnet",
+			wantContent:  "{overrides: true}",
+		},
+		{
+			name:         "missing file - should return error",
+			importedPath: "missing.libsonnet",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFallbackFileImporter("vendor", "overrides")
+			f.SetFilesystems(fs)
+
+			contents, foundAt, err := f.Import("app/main.jsonnet", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FallbackFileImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrFileNotFound)
+				return
+			}
+			assert.Equal(t, tt.wantFoundAt, foundAt)
+			assert.Equal(t, tt.wantContent, contents.String())
+		})
+	}
+}
+
+func TestMultiImporter_SetMaxImportDepth(t *testing.T) {
+	// main.jsonnet -> 1.jsonnet -> 2.jsonnet -> 3.jsonnet
+	dir := t.TempDir()
+	testFiles := map[string]string{
+		"main.jsonnet": "import '1.jsonnet'",
+		"1.jsonnet":    "import '2.jsonnet'",
+		"2.jsonnet":    "import '3.jsonnet'",
+		"3.jsonnet":    "{depth: 3}",
+	}
+	for file, cnt := range testFiles {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(cnt), 0o644); err != nil {
+			t.Errorf("os.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name           string
+		maxImportDepth int
+		wantErr        bool
+	}{
+		{
+			name:           "unlimited",
+			maxImportDepth: 0,
+		},
+		{
+			name:           "chain within the limit",
+			maxImportDepth: 3,
+		},
+		{
+			name:           "chain exceeds the limit - should return error",
+			maxImportDepth: 2,
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter(NewGlobImporter(), NewFallbackFileImporter())
+			m.SetMaxImportDepth(tt.maxImportDepth)
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(m)
+
+			got, err := vm.EvaluateFile(filepath.Join(dir, "main.jsonnet"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("vm.EvaluateFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.Contains(t, err.Error(), ErrMaxDepthExceeded.Error())
+				return
+			}
+			assert.Equal(t, "{\n   \"depth\": 3\n}\n", got)
+		})
+	}
+}
+
+func TestMultiImporter_SetMaxTotalBytes(t *testing.T) {
+	evaluate := func(maxTotalBytes int64) (*MultiImporter, error) {
+		m := NewMultiImporter(NewGlobImporter(), NewFallbackFileImporter())
+		m.SetMaxTotalBytes(maxTotalBytes)
+
+		vm := jsonnet.MakeVM()
+		vm.Importer(m)
+
+		_, err := vm.EvaluateFile("testdata/hashlock/main.jsonnet")
+
+		return m, err
+	}
+
+	// the files and the generated glob snippet count once each
+	want := int64(len("(import 'libs/a.libsonnet')+(import 'libs/b.libsonnet')"))
+	for _, file := range []string{"main.jsonnet", "host.libsonnet", "libs/a.libsonnet", "libs/b.libsonnet"} {
+		info, err := os.Stat(filepath.Join("testdata/hashlock", file))
+		if err != nil {
+			t.Fatalf("os.Stat() error = %v", err)
+		}
+		want += info.Size()
+	}
+
+	tests := []struct {
+		name          string
+		maxTotalBytes int64
+		wantErr       bool
+	}{
+		{
+			name: "unlimited",
+		},
+		{
+			name:          "exactly the limit",
+			maxTotalBytes: want,
+		},
+		{
+			name:          "limit exceeded - should return error",
+			maxTotalBytes: want - 1,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := evaluate(tt.maxTotalBytes)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, ErrByteLimitExceeded.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			if tt.maxTotalBytes > 0 {
+				assert.Equal(t, want, m.totalBytes)
+			}
+		})
+	}
+}
+
+func TestMultiImporter_MaxTotalBytesInFileConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		// goLimit will be set via SetMaxTotalBytes in front of the rawQuery
+		goLimit  int64
+		rawQuery string
+		want     int64
+		wantErr  error
+	}{
+		{
+			name:     "without limit from go code",
+			rawQuery: "maxTotalBytes=2048",
+			want:     2048,
+		},
+		{
+			name:     "lowers the limit from go code",
+			goLimit:  1024,
+			rawQuery: "maxTotalBytes=512",
+			want:     512,
+		},
+		{
+			name:     "cannot raise the limit from go code",
+			goLimit:  1024,
+			rawQuery: "maxTotalBytes=2048",
+			want:     1024,
+		},
+		{
+			name:     "cannot disable the limit from go code",
+			goLimit:  1024,
+			rawQuery: "maxTotalBytes=0",
+			want:     1024,
+		},
+		{
+			name:     "malformed value keeps the limit - should return error",
+			goLimit:  1024,
+			rawQuery: "maxTotalBytes=lots",
+			want:     1024,
+			wantErr:  ErrUnknownConfig,
+		},
+		{
+			name:     "negative value keeps the limit - should return error",
+			goLimit:  1024,
+			rawQuery: "maxTotalBytes=-1",
+			want:     1024,
+			wantErr:  ErrUnknownConfig,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter()
+			m.SetMaxTotalBytes(tt.goLimit)
+
+			err := m.parseInFileConfigs(tt.rawQuery)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, m.maxTotalBytes)
+		})
+	}
+
+	// Configure can raise the limit like SetMaxTotalBytes
+	m := NewMultiImporter()
+	m.SetMaxTotalBytes(1024)
+	if err := m.Configure(Config{MaxTotalBytes: 2048}); err != nil {
+		t.Fatalf("MultiImporter.Configure() error = %v", err)
+	}
+	assert.Equal(t, int64(2048), m.maxTotalBytes)
+
+	if err := m.parseInFileConfigs("maxTotalBytes=4096"); err != nil {
+		t.Fatalf("MultiImporter.parseInFileConfigs() error = %v", err)
+	}
+	assert.Equal(t, int64(2048), m.maxTotalBytes)
+}
+
+func TestMultiImporter_Configure(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		// rawQuery is the same config as `config://set` query
+		rawQuery    string
+		wantErrType error
+	}{
+		{
+			name:     "empty config",
+			cfg:      Config{},
+			rawQuery: "",
+		},
+		{
+			name: "all settings",
+			cfg: Config{
+				LogLevel:               "info",
+				ImportGraph:            "graph.gv",
+				IgnoreImportCycles:     true,
+				OnMissingFile:          `"{}"`,
+				StrictJPaths:           true,
+				FallthroughOnError:     true,
+				GraphHighlightLongest:  true,
+				GraphHideRoot:          true,
+				GraphOnErrorOnly:       true,
+				CanonicalizePaths:      true,
+				Annotate:               true,
+				DetectDuplicateContent: true,
+				SkipBrokenFiles:        true,
+				EagerCycleCheck:        true,
+				RebaseImports:          true,
+				MaxImportDepth:         10,
+				MaxTotalBytes:          1024,
+				GlobFormat:             "compact",
+				GlobIgnoreFile:         "testdata/globExclude/.globignore",
+				PerDirConfig:           true,
+				WarnShadowed:           true,
+				WarnTrivialGlob:        true,
+				IdentifierKeys:         true,
+			},
+			rawQuery: `logLevel=info&importGraph=graph.gv&ignoreImportCycles&onMissingFile="{}"` +
+				"&strictJPaths=true&fallthroughOnError=true&graphHighlightLongest=true&graphHideRoot=true&graphOnErrorOnly=true&annotate=true" +
+				"&detectDuplicateContent=true&skipBrokenFiles=true&eagerCycleCheck=true&rebaseImports=true&maxImportDepth=10&maxTotalBytes=1024&globFormat=compact" +
+				"&globIgnoreFile=testdata/globExclude/.globignore&canonicalizePaths=true" +
+				"&perDirConfig=true&warnShadowed=true&warnTrivialGlob=true&identifierKeys=true",
+		},
+		{
+			name:        "unknown logLevel - should return error",
+			cfg:         Config{LogLevel: "trace"},
+			wantErrType: ErrUnknownConfig,
+		},
+		{
+			name:        "negative maxImportDepth - should return error",
+			cfg:         Config{MaxImportDepth: -1},
+			wantErrType: ErrUnknownConfig,
+		},
+		{
+			name:        "negative maxTotalBytes - should return error",
+			cfg:         Config{MaxTotalBytes: -1},
+			wantErrType: ErrUnknownConfig,
+		},
+		{
+			name:        "unknown globFormat - should return error",
+			cfg:         Config{GlobFormat: "fancy"},
+			wantErrType: ErrUnknownConfig,
+		},
+		{
+			name:        "missing globIgnoreFile - should return error",
+			cfg:         Config{GlobIgnoreFile: "testdata/globExclude/.missing"},
+			wantErrType: os.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter()
+			err := m.Configure(tt.cfg)
+			if tt.wantErrType != nil {
+				assert.ErrorIs(t, err, tt.wantErrType)
+				return
+			}
+			if err != nil {
+				t.Errorf("MultiImporter.Configure() error = %v", err)
+				return
+			}
+
+			want := NewMultiImporter()
+			if err := want.parseInFileConfigs(tt.rawQuery); err != nil {
+				t.Errorf("MultiImporter.parseInFileConfigs() error = %v", err)
+				return
+			}
+
+			assert.Equal(t, want.LogLevel(), m.LogLevel())
+			assert.Equal(t, want.ImportGraphFile(), m.ImportGraphFile())
+			assert.Equal(t, want.ImportGraphEnabled(), m.ImportGraphEnabled())
+			assert.Equal(t, want.CyclesIgnored(), m.CyclesIgnored())
+			assert.Equal(t, want.onMissingFile, m.onMissingFile)
+			assert.Equal(t, want.fallthroughOnError, m.fallthroughOnError)
+			assert.Equal(t, want.highlightLongest, m.highlightLongest)
+			assert.Equal(t, want.hideGraphRoot, m.hideGraphRoot)
+			assert.Equal(t, want.graphOnErrorOnly, m.graphOnErrorOnly)
+			assert.Equal(t, want.canonicalizePaths, m.canonicalizePaths)
+			assert.Equal(t, want.maxImportDepth, m.maxImportDepth)
+			assert.Equal(t, want.maxTotalBytes, m.maxTotalBytes)
+
+			wantGlob, gotGlob := want.importers[0].(*GlobImporter), m.importers[0].(*GlobImporter)
+			assert.Equal(t, wantGlob.strictJPaths, gotGlob.strictJPaths)
+			assert.Equal(t, wantGlob.annotate, gotGlob.annotate)
+			assert.Equal(t, wantGlob.detectDuplicateContent, gotGlob.detectDuplicateContent)
+			assert.Equal(t, wantGlob.skipBrokenFiles, gotGlob.skipBrokenFiles)
+			assert.Equal(t, wantGlob.eagerCycleCheck, gotGlob.eagerCycleCheck)
+			assert.Equal(t, wantGlob.rebaseImports, gotGlob.rebaseImports)
+			assert.Equal(t, wantGlob.format, gotGlob.format)
+			assert.Equal(t, wantGlob.ignorePatterns, gotGlob.ignorePatterns)
+			assert.Equal(t, wantGlob.perDirConfig, gotGlob.perDirConfig)
+			assert.Equal(t, wantGlob.warnShadowed, gotGlob.warnShadowed)
+			assert.Equal(t, wantGlob.warnTrivialGlob, gotGlob.warnTrivialGlob)
+			assert.Equal(t, wantGlob.identifierKeys, gotGlob.identifierKeys)
+		})
+	}
+}
+
+// depthRecorder records the current depth of the MultiImporter per import.
+type depthRecorder struct {
+	*FallbackFileImporter
+	m      *MultiImporter
+	depths map[string]int
+}
+
+func (d *depthRecorder) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	d.depths[importedPath] = d.m.CurrentDepth()
+	return d.FallbackFileImporter.Import(importedFrom, importedPath)
+}
+
+func TestMultiImporter_CurrentDepth(t *testing.T) {
+	// main.jsonnet -> 1.jsonnet -> 2.jsonnet
+	dir := t.TempDir()
+	testFiles := map[string]string{
+		"main.jsonnet": "import '1.jsonnet'",
+		"1.jsonnet":    "import '2.jsonnet'",
+		"2.jsonnet":    "{two: true}",
+	}
+	for file, cnt := range testFiles {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(cnt), 0o644); err != nil {
+			t.Errorf("os.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	recorder := &depthRecorder{FallbackFileImporter: NewFallbackFileImporter(), depths: map[string]int{}}
+	m := NewMultiImporter(NewGlobImporter(), recorder)
+	recorder.m = m
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	if _, err := vm.EvaluateFile(filepath.Join(dir, "main.jsonnet")); err != nil {
+		t.Errorf("vm.EvaluateFile() error = %v", err)
+		return
+	}
+	assert.Equal(t, map[string]int{
+		filepath.Join(dir, "main.jsonnet"): 0,
+		"1.jsonnet":                        1,
+		"2.jsonnet":                        2,
+	}, recorder.depths)
+	// the depth is only valid during an import
+	assert.Equal(t, 0, m.CurrentDepth())
+	assert.Greater(t, m.importCounter, 2)
+}
+
+func TestMultiImporter_RestrictToAliases(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"vendor-a/a.libsonnet": "{a: 1}",
+		"vendor-b/b.libsonnet": "{b: 2}",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	newRestricted := func(alias, jpath string) *GlobImporter {
+		g := NewGlobImporter(jpath)
+		g.fs = fs
+		if err := g.AddAliasPrefix(alias, "glob+"); err != nil {
+			t.Fatalf("AddAliasPrefix() failed: %v", err)
+		}
+		g.RestrictToAliases()
+		return g
+	}
+	m := NewMultiImporter(
+		newRestricted("team-a", "vendor-a"),
+		newRestricted("team-b", "vendor-b"),
+		NewFallbackFileImporter(),
+	)
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         jsonnet.Contents
+		wantErr      bool
+	}{
+		{
+			name:         "first importer",
+			importedPath: "team-a://*.libsonnet",
+			want:         jsonnet.MakeContents("(import 'vendor-a/a.libsonnet')"),
+		},
+		{
+			name:         "second importer",
+			importedPath: "team-b://*.libsonnet",
+			want:         jsonnet.MakeContents("(import 'vendor-b/b.libsonnet')"),
+		},
+		{
+			name:         "built-in prefix not handled - should end in the fallback importer",
+			importedPath: "glob+://*.libsonnet",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := m.Import("", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MultiImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMultiImporter_FallthroughOnError(t *testing.T) {
+	newGlobImporter := func(testFiles map[string]string) *GlobImporter {
+		fs := afero.NewMemMapFs()
+		for file, cnt := range testFiles {
+			if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+				t.Fatalf("afero.WriteFile() error = %v", err)
+			}
+		}
+		g := NewGlobImporter()
+		g.fs = fs
+		return g
+	}
+
+	tests := []struct {
+		name               string
+		fallthroughOnError bool
+		secondFiles        map[string]string
+		want               jsonnet.Contents
+		wantErr            bool
+		wantErrType        error
+	}{
+		{
+			name:               "disabled - should return error of the first importer",
+			fallthroughOnError: false,
+			secondFiles:        map[string]string{"a.libsonnet": "{a: 1}"},
+			want:               jsonnet.MakeContents(""),
+			wantErr:            true,
+			wantErrType:        ErrEmptyResult,
+		},
+		{
+			name:               "enabled - second importer resolves the import",
+			fallthroughOnError: true,
+			secondFiles:        map[string]string{"a.libsonnet": "{a: 1}"},
+			want:               jsonnet.MakeContents("(import 'a.libsonnet')"),
+		},
+		{
+			name:               "enabled - all importers fail - should return error of the first importer",
+			fallthroughOnError: true,
+			secondFiles:        map[string]string{"a.jsonnet": "{a: 1}"},
+			want:               jsonnet.MakeContents(""),
+			wantErr:            true,
+			wantErrType:        ErrEmptyResult,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter(
+				newGlobImporter(map[string]string{"a.jsonnet": "{a: 1}"}),
+				newGlobImporter(tt.secondFiles),
+			)
+			m.FallthroughOnError(tt.fallthroughOnError)
+
+			got, _, err := m.Import("", "glob+://*.libsonnet")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MultiImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.wantErrType)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewStrictMultiImporter(t *testing.T) {
+	tests := []struct {
+		name      string
+		importers []Importer
+		file      string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "entry file with glob imports",
+			file: "testdata/strict/main.jsonnet",
+			want: `{"configs": {"a": {"name": "a"}, "b": {"name": "b"}}}`,
+		},
+		{
+			name:      "given FallbackFileImporter will be ignored",
+			importers: []Importer{NewGlobImporter(), NewFallbackFileImporter()},
+			file:      "testdata/strict/main.jsonnet",
+			want:      `{"configs": {"a": {"name": "a"}, "b": {"name": "b"}}}`,
+		},
+		{
+			name:    "plain import inside the entry file - should return error",
+			file:    "testdata/strict/plain.jsonnet",
+			wantErr: true,
+		},
+		{
+			name:    "plain import inside a globbed file - should return error",
+			file:    "testdata/globRebase/main.jsonnet",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewStrictMultiImporter(tt.importers...)
+
+			vm := jsonnet.MakeVM()
+			vm.Importer(m)
+
+			got, err := vm.EvaluateFile(tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("vm.EvaluateFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.Contains(t, err.Error(), ErrNoImporter.Error())
+				return
+			}
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewStrictMultiImporter_Import(t *testing.T) {
+	m := NewStrictMultiImporter()
+
+	_, _, err := m.Import("main.jsonnet", "testdata/strict/configs/a.libsonnet")
+	assert.ErrorIs(t, err, ErrNoImporter)
+
+	_, _, err = m.Import("main.jsonnet", "yaml://testdata/strict/configs/a.yaml")
+	assert.ErrorIs(t, err, ErrNoImporter)
+
+	_, _, err = m.Import("main.jsonnet", "glob+://testdata/strict/configs/*.libsonnet")
+	assert.NoError(t, err)
+}
+
+// flakyImporter fails with the given error until the given number of calls
+// is reached.
+type flakyImporter struct {
+	*StdinImporter
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyImporter) Import(_, _ string) (jsonnet.Contents, string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return jsonnet.MakeContents(""), "", f.err
+	}
+
+	return jsonnet.MakeContents("{}"), "stdin://", nil
+}
+
+func TestMultiImporter_SetRetry(t *testing.T) {
+	transient := fmt.Errorf("%w: connection reset", ErrRetryable)
+
+	tests := []struct {
+		name        string
+		attempts    int
+		failures    int
+		err         error
+		wantErr     bool
+		wantCalls   int
+		wantBackoff []time.Duration
+	}{
+		{
+			name:        "succeeds on the second attempt",
+			attempts:    3,
+			failures:    1,
+			err:         transient,
+			wantCalls:   2,
+			wantBackoff: []time.Duration{10 * time.Millisecond},
+		},
+		{
+			name:        "backoff doubles with each retry",
+			attempts:    3,
+			failures:    3,
+			err:         transient,
+			wantCalls:   4,
+			wantBackoff: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond},
+		},
+		{
+			name:        "all attempts fail - should return error",
+			attempts:    2,
+			failures:    5,
+			err:         transient,
+			wantErr:     true,
+			wantCalls:   3,
+			wantBackoff: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		},
+		{
+			name:      "no retries by default - should return error",
+			failures:  1,
+			err:       transient,
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "not retryable error - should return error",
+			attempts:  3,
+			failures:  1,
+			err:       fmt.Errorf("%w: stdin://", ErrFileNotFound),
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &flakyImporter{StdinImporter: NewStdinImporter(), failures: tt.failures, err: tt.err}
+
+			m := NewMultiImporter(f)
+			m.SetRetry(tt.attempts, 10*time.Millisecond)
+
+			var gotBackoff []time.Duration
+			m.sleep = func(d time.Duration) { gotBackoff = append(gotBackoff, d) }
+
+			_, _, err := m.Import("main.jsonnet", "stdin://")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MultiImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.wantCalls, f.calls)
+			assert.Equal(t, tt.wantBackoff, gotBackoff)
+		})
+	}
+}
+
+func TestMultiImporter_SetImportGraphFileMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     os.FileMode
+		wantMode os.FileMode
+	}{
+		{
+			name:     "default mode",
+			wantMode: importGraphFileMode,
+		},
+		{
+			name:     "custom mode",
+			mode:     0o600,
+			wantMode: 0o600,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter()
+			m.fs = afero.NewMemMapFs()
+			if tt.mode != 0 {
+				m.SetImportGraphFileMode(tt.mode)
+			}
+
+			if err := m.storeImportGraph(); err != nil {
+				t.Errorf("MultiImporter.storeImportGraph() error = %v", err)
+				return
+			}
+
+			info, err := m.fs.Stat(m.ImportGraphFile())
+			if err != nil {
+				t.Errorf("stat import graph file error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.wantMode, info.Mode().Perm())
+		})
+	}
+}
+
+// createImportTree returns the (importedFrom, importedPath) pairs of an import
+// tree with the given depth, where each file imports fanOut other files.
+func createImportTree(depth, fanOut int) [][2]string {
+	imports := [][2]string{}
+	parents := []string{"main.jsonnet"}
+
+	for level := 0; level < depth; level++ {
+		children := []string{}
+		for _, parent := range parents {
+			dir := strings.TrimSuffix(parent, ".jsonnet")
+			for i := 0; i < fanOut; i++ {
+				child := fmt.Sprintf("%s/lib%d.jsonnet", dir, i)
+				imports = append(imports, [2]string{parent, child})
+				children = append(children, child)
+			}
+		}
+		parents = children
+	}
+
+	return imports
+}
+
+func BenchmarkMultiImporter_plainImports(b *testing.B) {
+	imports := createImportTree(4, 4)
+
+	b.Run("parseImportString", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			m := NewMultiImporter()
+			for _, i := range imports {
+				if _, err := m.parseImportString(i[0], i[1]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("parsePlainImport", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			m := NewMultiImporter()
+			for _, i := range imports {
+				if err := m.parsePlainImport(i[0], i[1]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+var excpectedComplexOutput = `{
+   "dot": {
+      "host": {
+         "checksum": 3,
+         "imports": [
+            "testdata/globDot/host.libsonnet",
+            "testdata/globDot/subfolder/host.libsonnet",
+            "testdata/globDot/subfolder/subsubfolder/host.libsonnet"
+         ]
+      }
+   },
+   "dot_continuous": {
+      "caller_dot_path": {
+         "checksum": 1,
+         "imports": [
+            "testdata/globDot/caller_dot_path.jsonnet",
+            "testdata/globDot/host.libsonnet"
+         ],
+         "names": [
+            "host.libsonnet"
+         ]
+      },
+      "caller_dot_stem_double_star": {
+         "checksum": 1,
+         "imports": [
+            "testdata/globDot/caller_dot_stem_double_star.jsonnet",
+            "testdata/globDot/subfolder/subsubfolder/host.libsonnet"
+         ],
+         "names": [
+            "host"
+         ]
       },
       "caller_dot_stem_double_star_plus": {
          "checksum": 3,
@@ -896,3 +2137,41 @@ var excpectedComplexOutput = `{
    }
 }
 `
+
+func TestMultiImporter_AddRewriteRule(t *testing.T) {
+	m := NewMultiImporter()
+	m.fs = afero.NewMemMapFs()
+	m.AddRewriteRule("legacy://", "libs/")
+	// only the first matching rule rewrites
+	m.AddRewriteRule("legacy://", "missing/")
+	m.AddRewriteRule("legacy-glob://", "glob.stem+://libs/")
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	got, err := vm.EvaluateFile("testdata/rewrite/main.jsonnet")
+	if err != nil {
+		t.Errorf("vm.EvaluateFile() error = %v", err)
+		return
+	}
+	assert.JSONEq(t, `[{"name": "host"}, {"host": {"name": "host"}}]`, got)
+
+	// the rewritten paths are part of the import graph
+	for _, vertex := range []string{"libs/host.libsonnet", "glob.stem+://libs/*.libsonnet"} {
+		_, err := m.importGraph.Vertex(vertex)
+		assert.NoError(t, err, vertex)
+	}
+}
+
+func TestMultiImporter_AddRewriteRuleCycle(t *testing.T) {
+	m := NewMultiImporter()
+	m.fs = afero.NewMemMapFs()
+	m.AddRewriteRule("b://", "b.jsonnet")
+	m.AddRewriteRule("a://", "a.jsonnet")
+
+	_, _, err := m.Import("a.jsonnet", "b://")
+	assert.ErrorIs(t, err, ErrFileNotFound)
+
+	_, _, err = m.Import("b.jsonnet", "a://")
+	assert.ErrorIs(t, err, ErrImportCycle)
+}
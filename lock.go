@@ -0,0 +1,190 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+)
+
+const lockPrefix = "lock"
+
+type (
+	// LockImporter imports files via logical names, which will be resolved
+	// through a lock file, via the prefix `lock://`. The lock file (JSON or
+	// YAML) maps each name either directly to a path or to an object with a
+	// `path` and an optional `version`. Relative paths are relative to the
+	// directory of the lock file.
+	// Example:
+	//   - lock file: {"common": {"path": "vendor/common@1.2.0/main.libsonnet", "version": "1.2.0"}}
+	//   - import 'lock://common'
+	LockImporter struct {
+		lockFile string
+		// A FileSystem abstraction; useful for tests
+		fs     afero.Fs
+		logger *zap.Logger
+		// loaded is true after the first read of the lock file; the entries
+		// and the error of this read will be used for all imports.
+		loaded  bool
+		entries map[string]string
+		loadErr error
+		cache   contentsCache
+	}
+
+	// lockEntry is the object form of an entry inside the lock file.
+	lockEntry struct {
+		Path    string `json:"path"`
+		Version string `json:"version,omitempty"`
+	}
+)
+
+// NewLockImporter returns a LockImporter, which resolves the imports through
+// the given lock file. The lock file will be read at the first import.
+func NewLockImporter(lockFile string) *LockImporter {
+	return &LockImporter{
+		lockFile: lockFile,
+		fs:       afero.NewOsFs(),
+		logger:   zap.New(nil),
+	}
+}
+
+// CanHandle returns true for the `lock` prefix.
+func (l *LockImporter) CanHandle(prefix string) bool {
+	return prefix == lockPrefix
+}
+
+// Logger can be used to set the zap.Logger for the LockImporter.
+func (l *LockImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		l.logger = logger
+	}
+}
+
+// Prefixa returns the list of supported prefixa for this importer.
+func (l *LockImporter) Prefixa() []string {
+	return []string{lockPrefix}
+}
+
+// Import implements the go-jsonnet iterface method. It resolves the name
+// behind the `lock://` prefix via the lock file and returns the content of the
+// locked file.
+func (l *LockImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	logger := l.logger.Named("LockImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+	)
+
+	name, ok := strings.CutPrefix(importedPath, lockPrefix+"://")
+	if !ok || name == "" {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: '%s', expected 'lock://<name>'", ErrMalformedImport, importedPath)
+	}
+
+	file, err := l.resolve(name)
+	if err != nil {
+		return jsonnet.MakeContents(""), "", err
+	}
+
+	contents, foundAt, err := l.cache.readFile(l.fs, file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return jsonnet.MakeContents(""), "",
+				fmt.Errorf("%w: '%s' locked for '%s'", ErrFileNotFound, file, name)
+		}
+
+		return jsonnet.MakeContents(""), "", fmt.Errorf("while reading the locked file '%s': %w", file, err)
+	}
+
+	logger.Debug("returns", zap.String("name", name), zap.String("foundAt", foundAt))
+
+	return contents, foundAt, nil
+}
+
+// resolve returns the path of the file locked for the given name. Unknown
+// names return an error with all available names.
+func (l *LockImporter) resolve(name string) (string, error) {
+	if err := l.load(); err != nil {
+		return "", err
+	}
+
+	file, exists := l.entries[name]
+	if !exists {
+		names := make([]string, 0, len(l.entries))
+		for n := range l.entries {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+
+		return "", fmt.Errorf("%w: '%s' in the lock file '%s', available are: %s",
+			ErrUnknownLockName, name, l.lockFile, strings.Join(names, ", "))
+	}
+
+	return file, nil
+}
+
+// load reads and parses the lock file at the first call and returns the same
+// error for all further calls.
+func (l *LockImporter) load() error {
+	if l.loaded {
+		return l.loadErr
+	}
+
+	l.loaded = true
+	l.entries, l.loadErr = l.parseLockFile()
+
+	return l.loadErr
+}
+
+// parseLockFile reads the lock file and returns the paths per name.
+func (l *LockImporter) parseLockFile() (map[string]string, error) {
+	data, err := afero.ReadFile(l.fs, l.lockFile)
+	if err != nil {
+		return nil, fmt.Errorf("while reading the lock file '%s': %w", l.lockFile, err)
+	}
+
+	// JSON is valid YAML, therefore both formats will be converted into JSON
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing the lock file '%s': %w", l.lockFile, err)
+	}
+
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(jsonData, &raw); err != nil {
+		return nil, fmt.Errorf("while parsing the lock file '%s', expected an object: %w", l.lockFile, err)
+	}
+
+	entries := make(map[string]string, len(raw))
+	dir := filepath.Dir(l.lockFile)
+
+	for name, value := range raw {
+		var entry lockEntry
+		if err := json.Unmarshal(value, &entry.Path); err != nil {
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return nil, fmt.Errorf("while parsing the entry '%s' of the lock file '%s': %w", name, l.lockFile, err)
+			}
+		}
+
+		if entry.Path == "" {
+			return nil, fmt.Errorf("%w: the entry '%s' of the lock file '%s' has no path",
+				ErrMalformedImport, name, l.lockFile)
+		}
+
+		file := filepath.FromSlash(entry.Path)
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		entries[name] = file
+	}
+
+	return entries, nil
+}
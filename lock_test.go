@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockImporter_Import(t *testing.T) {
+	testFiles := map[string]string{
+		"vendor/common/main.libsonnet": "{common: true}",
+		"vendor/utils.libsonnet":       "{utils: true}",
+	}
+
+	tests := []struct {
+		name         string
+		lockFile     string
+		lockContent  string
+		importedPath string
+		want         jsonnet.Contents
+		wantFoundAt  string
+		wantErr      error
+	}{
+		{
+			name:         "JSON lock file with path objects",
+			lockFile:     "lock.json",
+			lockContent:  `{"common": {"path": "vendor/common/main.libsonnet", "version": "1.2.0"}}`,
+			importedPath: "lock://common",
+			want:         jsonnet.MakeContents("{common: true}"),
+			wantFoundAt:  "vendor/common/./main.libsonnet",
+		},
+		{
+			name:         "YAML lock file with plain paths",
+			lockFile:     "lock.yaml",
+			lockContent:  "utils: vendor/utils.libsonnet\n",
+			importedPath: "lock://utils",
+			want:         jsonnet.MakeContents("{utils: true}"),
+			wantFoundAt:  "vendor/./utils.libsonnet",
+		},
+		{
+			name:         "paths relative to the lock file",
+			lockFile:     "vendor/lock.yaml",
+			lockContent:  "common: common/main.libsonnet\n",
+			importedPath: "lock://common",
+			want:         jsonnet.MakeContents("{common: true}"),
+			wantFoundAt:  "vendor/common/./main.libsonnet",
+		},
+		{
+			name:         "unknown name - should return error",
+			lockFile:     "lock.yaml",
+			lockContent:  "utils: vendor/utils.libsonnet\ncommon: vendor/common/main.libsonnet\n",
+			importedPath: "lock://missing",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrUnknownLockName,
+		},
+		{
+			name:         "missing locked file - should return error",
+			lockFile:     "lock.yaml",
+			lockContent:  "gone: vendor/gone.libsonnet\n",
+			importedPath: "lock://gone",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrFileNotFound,
+		},
+		{
+			name:         "entry without path - should return error",
+			lockFile:     "lock.yaml",
+			lockContent:  "common:\n  version: 1.2.0\n",
+			importedPath: "lock://common",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrMalformedImport,
+		},
+		{
+			name:         "missing name - should return error",
+			lockFile:     "lock.yaml",
+			lockContent:  "utils: vendor/utils.libsonnet\n",
+			importedPath: "lock://",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      ErrMalformedImport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if err := afero.WriteFile(fs, tt.lockFile, []byte(tt.lockContent), 0o644); err != nil {
+				t.Errorf("afero.WriteFile() error = %v", err)
+				return
+			}
+			for file, cnt := range testFiles {
+				if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+					t.Errorf("afero.WriteFile() error = %v", err)
+					return
+				}
+			}
+
+			l := NewLockImporter(tt.lockFile)
+			l.fs = fs
+
+			got, gotFoundAt, err := l.Import("main.jsonnet", tt.importedPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else if err != nil {
+				t.Errorf("LockImporter.Import() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want.String(), got.String())
+			assert.Equal(t, tt.wantFoundAt, gotFoundAt)
+		})
+	}
+}
+
+func TestLockImporter_ImportUnknownNameLists(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "lock.yaml", []byte("b: b.libsonnet\na: a.libsonnet\n"), 0o644); err != nil {
+		t.Errorf("afero.WriteFile() error = %v", err)
+		return
+	}
+
+	l := NewLockImporter("lock.yaml")
+	l.fs = fs
+
+	_, _, err := l.Import("main.jsonnet", "lock://c")
+	assert.ErrorIs(t, err, ErrUnknownLockName)
+	assert.Contains(t, err.Error(), "available are: a, b")
+}
+
+func TestLockImporter_Evaluate(t *testing.T) {
+	m := NewMultiImporter(NewLockImporter("testdata/lock/lock.yaml"), NewFallbackFileImporter())
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	// the same file imported via the lock file and directly
+	got, err := vm.EvaluateFile("testdata/lock/main.jsonnet")
+	if err != nil {
+		t.Errorf("vm.EvaluateFile() error = %v", err)
+		return
+	}
+	assert.JSONEq(t, `{
+		"locked": {"name": "common", "helper": true},
+		"plain": {"name": "common", "helper": true}
+	}`, got)
+}
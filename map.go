@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/google/go-jsonnet"
+	"go.uber.org/zap"
+)
+
+// mapPrefix is the scheme of a MapImporter created without a scheme.
+const mapPrefix = "map"
+
+// MapImporter imports the contents of an in-memory map of paths to contents
+// via the prefix `<scheme>://<path>`, for example to embed generated configs
+// or to test Jsonnet code without files. The contents can be used by
+// `import` and `importstr`.
+// Example:
+//   - import 'mem://configs/app.libsonnet'
+//   - importstr 'mem://banner.txt'
+type MapImporter struct {
+	scheme string
+	logger *zap.Logger
+	mu     sync.Mutex
+	// files stores the content per cleaned path.
+	files map[string]string
+	cache contentsCache
+}
+
+// NewMapImporter returns a MapImporter for the given scheme (default: "map")
+// with a copy of the given map of paths to contents. The files can be changed
+// later via Set.
+func NewMapImporter(files map[string]string, scheme string) *MapImporter {
+	if scheme == "" {
+		scheme = mapPrefix
+	}
+
+	m := &MapImporter{
+		scheme: scheme,
+		logger: zap.New(nil),
+		files:  make(map[string]string, len(files)),
+	}
+
+	for p, content := range files {
+		m.Set(p, content)
+	}
+
+	return m
+}
+
+// Set adds or replaces the content of the given path. go-jsonnet caches the
+// contents of each import per VM, therefore a changed content becomes visible
+// in a new VM or after setting the importer of the VM again via vm.Importer.
+func (m *MapImporter) Set(p, content string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p = path.Clean(p)
+	m.files[p] = content
+	m.cache.forget(m.foundAt(p))
+}
+
+// foundAt returns the foundAt value of the cleaned path.
+func (m *MapImporter) foundAt(p string) string {
+	return m.scheme + "://" + p
+}
+
+// CanHandle returns true for the scheme of the MapImporter.
+func (m *MapImporter) CanHandle(prefix string) bool {
+	return prefix == m.scheme
+}
+
+// Logger can be used to set the zap.Logger for the MapImporter.
+func (m *MapImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		m.logger = logger
+	}
+}
+
+// Prefixa returns the list of supported prefixa for this importer.
+func (m *MapImporter) Prefixa() []string {
+	return []string{m.scheme}
+}
+
+// Import implements the go-jsonnet iterface method. It returns the content
+// stored for the path behind the scheme. Missing paths return an
+// ErrFileNotFound error. The paths are absolute inside the map: imports inside
+// the contents must use the scheme as well, like `import 'map://lib.libsonnet'`.
+func (m *MapImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	logger := m.logger.Named("MapImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+	)
+
+	rest, ok := strings.CutPrefix(importedPath, m.scheme+"://")
+	if !ok || rest == "" {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: '%s', expected '%s://<path>'", ErrMalformedImport, importedPath, m.scheme)
+	}
+
+	p := path.Clean(rest)
+	foundAt := m.foundAt(p)
+
+	contents, err := m.cache.load(foundAt, func() (jsonnet.Contents, error) {
+		m.mu.Lock()
+		content, exists := m.files[p]
+		m.mu.Unlock()
+
+		if !exists {
+			return jsonnet.Contents{},
+				fmt.Errorf("%w: '%s' is not part of the map of the '%s' scheme", ErrFileNotFound, p, m.scheme)
+		}
+
+		return jsonnet.MakeContents(content), nil
+	})
+	if err != nil {
+		return jsonnet.MakeContents(""), "", err
+	}
+
+	logger.Debug("returns", zap.String("foundAt", foundAt))
+
+	return contents, foundAt, nil
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapImporter_Import(t *testing.T) {
+	tests := []struct {
+		name         string
+		scheme       string
+		importedPath string
+		want         string
+		wantFoundAt  string
+		wantErr      error
+	}{
+		{
+			name:         "stored path",
+			scheme:       "mem",
+			importedPath: "mem://configs/app.libsonnet",
+			want:         "{app: 'demo'}",
+			wantFoundAt:  "mem://configs/app.libsonnet",
+		},
+		{
+			name:         "cleaned path",
+			scheme:       "mem",
+			importedPath: "mem://./configs//app.libsonnet",
+			want:         "{app: 'demo'}",
+			wantFoundAt:  "mem://configs/app.libsonnet",
+		},
+		{
+			name:         "default scheme",
+			importedPath: "map://banner.txt",
+			want:         "hello",
+			wantFoundAt:  "map://banner.txt",
+		},
+		{
+			name:         "missing path - should return error",
+			scheme:       "mem",
+			importedPath: "mem://missing.libsonnet",
+			wantErr:      ErrFileNotFound,
+		},
+		{
+			name:         "without path - should return error",
+			scheme:       "mem",
+			importedPath: "mem://",
+			wantErr:      ErrMalformedImport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMapImporter(map[string]string{
+				"configs/app.libsonnet": "{app: 'demo'}",
+				"banner.txt":            "hello",
+			}, tt.scheme)
+
+			got, gotFoundAt, err := m.Import("main.jsonnet", tt.importedPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+			assert.Equal(t, tt.wantFoundAt, gotFoundAt)
+		})
+	}
+}
+
+func TestMapImporter_Evaluate(t *testing.T) {
+	files := map[string]string{
+		"lib.libsonnet": "{name: 'lib', banner: importstr 'mem://banner.txt'}",
+		"banner.txt":    "hello",
+	}
+	m := NewMapImporter(files, "mem")
+	// the map is copied; later changes of it do not matter
+	files["lib.libsonnet"] = "{}"
+
+	evaluate := func() (string, error) {
+		vm := jsonnet.MakeVM()
+		vm.Importer(NewMultiImporter(m, NewFallbackFileImporter()))
+
+		return vm.EvaluateAnonymousSnippet("main.jsonnet", "import 'mem://lib.libsonnet'")
+	}
+
+	got, err := evaluate()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"name": "lib", "banner": "hello"}`, got)
+
+	m.Set("banner.txt", "bye")
+
+	got, err = evaluate()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"name": "lib", "banner": "bye"}`, got)
+
+	m.Set("lib.libsonnet", "import 'mem://missing.libsonnet'")
+
+	_, err = evaluate()
+	assert.ErrorContains(t, err, "'missing.libsonnet' is not part of the map of the 'mem' scheme")
+}
@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// RemoteCache is a disk cache for the contents of remote imports, which can
+// be shared by network importers. Each entry is keyed by the SHA-256 hash of
+// its URL and stores the content together with the ETag of the server. Entries
+// younger than the TTL will be served without a request; older ones will be
+// revalidated via `If-None-Match`. If the revalidation fails with a transient
+// error wrapping ErrRetryable, for example because the network is unreachable,
+// the stale entry will be served with a warning. Other errors, like a deleted
+// archive, will be returned.
+type RemoteCache struct {
+	fs     afero.Fs
+	dir    string
+	ttl    time.Duration
+	now    func() time.Time
+	logger *zap.Logger
+}
+
+// remoteCacheMeta is stored next to the content of each cache entry.
+type remoteCacheMeta struct {
+	URL       string    `json:"url"`
+	ETag      string    `json:"etag,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// RemoteDownload downloads the content of a URL for the RemoteCache. A not
+// empty etag is the ETag of the cached entry, which should be sent as
+// `If-None-Match` header. notModified signals a `304 Not Modified` response,
+// which keeps the cached content. Otherwise it returns the new content and
+// its ETag.
+type RemoteDownload func(etag string) (content []byte, newETag string, notModified bool, err error)
+
+// NewRemoteCache returns a RemoteCache storing its entries inside the given
+// directory, which will be created on demand. A TTL of 0 revalidates each
+// entry at every fetch.
+func NewRemoteCache(dir string, ttl time.Duration) *RemoteCache {
+	return &RemoteCache{
+		fs:     afero.NewOsFs(),
+		dir:    dir,
+		ttl:    ttl,
+		now:    time.Now,
+		logger: zap.New(nil),
+	}
+}
+
+// Logger sets the zap.Logger, which warns about served stale entries.
+func (c *RemoteCache) Logger(logger *zap.Logger) {
+	if logger != nil {
+		c.logger = logger
+	}
+}
+
+// Fetch returns the content of the URL either from the cache or via the
+// download function, whose result will be cached. Without a cache (nil) the
+// download function will be called directly.
+func (c *RemoteCache) Fetch(url string, download RemoteDownload) ([]byte, error) {
+	if c == nil {
+		content, _, _, err := download("")
+
+		return content, err
+	}
+
+	contentFile, metaFile := c.entryFiles(url)
+	meta, content, cached := c.read(contentFile, metaFile)
+
+	if cached && c.now().Sub(meta.FetchedAt) < c.ttl {
+		return content, nil
+	}
+
+	etag := ""
+	if cached {
+		etag = meta.ETag
+	}
+
+	fresh, newETag, notModified, err := download(etag)
+
+	switch {
+	case err != nil && cached && errors.Is(err, ErrRetryable):
+		// offline builds get the stale entry
+		c.logger.Warn("serving the stale cache entry, because the revalidation failed",
+			zap.String("url", url),
+			zap.Time("fetchedAt", meta.FetchedAt),
+			zap.Error(err),
+		)
+
+		return content, nil
+	case err != nil:
+		return nil, err
+	case notModified && cached:
+		meta.FetchedAt = c.now()
+
+		return content, c.writeMeta(metaFile, meta)
+	case notModified:
+		return nil, fmt.Errorf("the server reported '%s' as not modified, but it is not cached", url)
+	}
+
+	if err := c.fs.MkdirAll(c.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("while creating the cache directory '%s', error: %w", c.dir, err)
+	}
+
+	if err := afero.WriteFile(c.fs, contentFile, fresh, 0o644); err != nil {
+		return nil, fmt.Errorf("while caching '%s', error: %w", url, err)
+	}
+
+	return fresh, c.writeMeta(metaFile, remoteCacheMeta{URL: url, ETag: newETag, FetchedAt: c.now()})
+}
+
+// entryFiles returns the content and the meta file of the URL.
+func (c *RemoteCache) entryFiles(url string) (string, string) {
+	sum := sha256.Sum256([]byte(url))
+	key := filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+
+	return key, key + ".json"
+}
+
+// read returns the cached entry; cached is false for missing or unreadable
+// entries, which will be downloaded again.
+func (c *RemoteCache) read(contentFile, metaFile string) (remoteCacheMeta, []byte, bool) {
+	meta := remoteCacheMeta{}
+
+	data, err := afero.ReadFile(c.fs, metaFile)
+	if err != nil || json.Unmarshal(data, &meta) != nil {
+		return meta, nil, false
+	}
+
+	content, err := afero.ReadFile(c.fs, contentFile)
+	if err != nil {
+		return meta, nil, false
+	}
+
+	return meta, content, true
+}
+
+func (c *RemoteCache) writeMeta(metaFile string, meta remoteCacheMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("while marshaling the cache entry of '%s', error: %w", meta.URL, err)
+	}
+
+	if err := afero.WriteFile(c.fs, metaFile, data, 0o644); err != nil {
+		return fmt.Errorf("while caching '%s', error: %w", meta.URL, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRemoteCache_Fetch(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := NewRemoteCache("cache", time.Hour)
+	c.fs = afero.NewMemMapFs()
+	c.now = func() time.Time { return now }
+
+	core, logs := observer.New(zap.WarnLevel)
+	c.Logger(zap.New(core))
+
+	var (
+		calls    int
+		gotETags []string
+		offline  bool
+		deleted  bool
+		content  = "v1"
+		etag     = `"1"`
+	)
+	download := func(cachedETag string) ([]byte, string, bool, error) {
+		calls++
+		gotETags = append(gotETags, cachedETag)
+		if offline {
+			return nil, "", false, fmt.Errorf("%w: network is unreachable", ErrRetryable)
+		}
+		if deleted {
+			return nil, "", false, fmt.Errorf("%w: 404 Not Found", ErrFileNotFound)
+		}
+		if cachedETag == etag {
+			return nil, "", true, nil
+		}
+		return []byte(content), etag, false, nil
+	}
+
+	fetch := func() string {
+		t.Helper()
+		got, err := c.Fetch("https://example.com/lib.tar.gz", download)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		return string(got)
+	}
+
+	assert.Equal(t, "v1", fetch(), "first fetch downloads")
+	assert.Equal(t, "v1", fetch(), "fresh entry")
+	assert.Equal(t, 1, calls)
+
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, "v1", fetch(), "revalidated entry")
+	assert.Equal(t, []string{"", `"1"`}, gotETags)
+	assert.Equal(t, "v1", fetch(), "fresh again after the revalidation")
+	assert.Equal(t, 2, calls)
+
+	now = now.Add(2 * time.Hour)
+	content, etag = "v2", `"2"`
+	assert.Equal(t, "v2", fetch(), "changed content")
+
+	now = now.Add(2 * time.Hour)
+	offline = true
+	assert.Equal(t, "v2", fetch(), "stale entry while offline")
+	assert.Equal(t, 1, logs.FilterMessage("serving the stale cache entry, because the revalidation failed").Len())
+
+	// a deleted archive must not be served from the cache
+	offline, deleted = false, true
+	_, err := c.Fetch("https://example.com/lib.tar.gz", download)
+	assert.ErrorIs(t, err, ErrFileNotFound, "no stale entry for a deleted archive")
+	deleted, offline = false, true
+
+	_, err = c.Fetch("https://example.com/other.tar.gz", download)
+	assert.ErrorContains(t, err, "network is unreachable", "offline without entry")
+
+	var without *RemoteCache
+	offline = false
+	got, err := without.Fetch("https://example.com/lib.tar.gz", download)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(got), "without cache")
+}
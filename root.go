@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// validScheme matches the schemes allowed for root aliases (see RFC 3986).
+var validScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+
+// rootImporter imports files relative to a directory via its scheme, like
+// `shared://auth/policy.libsonnet` for the directory `/opt/jsonnet/shared`
+// (see MultiImporter.AddRootAlias).
+type rootImporter struct {
+	scheme string
+	dir    string
+	fs     afero.Fs
+	logger *zap.Logger
+	cache  contentsCache
+}
+
+// AddRootAlias registers an importer for the given scheme, which imports the
+// files relative to the given directory, like `import 'shared://auth/policy.libsonnet'`
+// for `m.AddRootAlias("shared", "/opt/jsonnet/shared")`. Paths outside of the
+// directory are not allowed. It returns an error, if another importer
+// (except the FallbackFileImporter) already handles the scheme or if the
+// directory does not exist.
+func (m *MultiImporter) AddRootAlias(scheme, dir string) error {
+	if !validScheme.MatchString(scheme) || scheme == "config" {
+		return fmt.Errorf("%w: '%s' is not a valid scheme for a root alias", ErrMalformedAlias, scheme)
+	}
+
+	for _, i := range m.importers {
+		if _, isFallback := i.(*FallbackFileImporter); !isFallback && i.CanHandle(scheme) {
+			return fmt.Errorf("%w: the scheme '%s' of the root alias is already handled by '%T'",
+				ErrMalformedAlias, scheme, i)
+		}
+	}
+
+	isDir, err := afero.DirExists(m.fs, dir)
+	if err != nil {
+		return fmt.Errorf("while checking the directory '%s' of the root alias '%s': %w", dir, scheme, err)
+	}
+
+	if !isDir {
+		return fmt.Errorf("%w: '%s' of the root alias '%s' is not an existing directory",
+			ErrFileNotFound, dir, scheme)
+	}
+
+	r := &rootImporter{
+		scheme: scheme,
+		dir:    filepath.Clean(dir),
+		fs:     m.fs,
+		logger: m.logger,
+	}
+	// must come before the FallbackFileImporter, which handles any prefix
+	m.importers = append([]Importer{r}, m.importers...)
+
+	return nil
+}
+
+// CanHandle returns true for the scheme of the root alias.
+func (r *rootImporter) CanHandle(prefix string) bool {
+	return prefix == r.scheme
+}
+
+// Logger can be used to set the zap.Logger for the rootImporter.
+func (r *rootImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		r.logger = logger
+	}
+}
+
+// Prefixa returns the scheme of the root alias.
+func (r *rootImporter) Prefixa() []string {
+	return []string{r.scheme}
+}
+
+// Import implements the go-jsonnet iterface method. It returns the content of
+// the file relative to the directory of the root alias.
+func (r *rootImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	logger := r.logger.Named("rootImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+	)
+
+	rel, ok := strings.CutPrefix(importedPath, r.scheme+"://")
+	if !ok || rel == "" {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: '%s', expected '%s://<path>'", ErrMalformedImport, importedPath, r.scheme)
+	}
+
+	rel = filepath.Clean(filepath.FromSlash(rel))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: '%s' points outside of the directory '%s' of the root alias",
+				ErrMalformedImport, importedPath, r.dir)
+	}
+
+	contents, foundAt, err := r.cache.readFile(r.fs, filepath.Join(r.dir, rel))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return jsonnet.MakeContents(""), "", fmt.Errorf("%w: '%s'", ErrFileNotFound, importedPath)
+		}
+
+		return jsonnet.MakeContents(""), "", fmt.Errorf("while reading '%s': %w", importedPath, err)
+	}
+
+	logger.Debug("returns", zap.String("foundAt", foundAt))
+
+	return contents, foundAt, nil
+}
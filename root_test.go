@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiImporter_AddRootAlias(t *testing.T) {
+	tests := []struct {
+		name        string
+		scheme      string
+		dir         string
+		wantErrType error
+	}{
+		{
+			name:   "existing directory",
+			scheme: "shared",
+			dir:    "opt/shared",
+		},
+		{
+			name:        "already registered scheme - should return error",
+			scheme:      "team",
+			dir:         "opt/shared",
+			wantErrType: ErrMalformedAlias,
+		},
+		{
+			name:        "scheme of the GlobImporter - should return error",
+			scheme:      "glob+",
+			dir:         "opt/shared",
+			wantErrType: ErrMalformedAlias,
+		},
+		{
+			name:        "invalid scheme - should return error",
+			scheme:      "1shared",
+			dir:         "opt/shared",
+			wantErrType: ErrMalformedAlias,
+		},
+		{
+			name:        "config scheme - should return error",
+			scheme:      "config",
+			dir:         "opt/shared",
+			wantErrType: ErrMalformedAlias,
+		},
+		{
+			name:        "missing directory - should return error",
+			scheme:      "shared",
+			dir:         "opt/missing",
+			wantErrType: ErrFileNotFound,
+		},
+		{
+			name:        "file instead of a directory - should return error",
+			scheme:      "shared",
+			dir:         "opt/shared/auth/policy.libsonnet",
+			wantErrType: ErrFileNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter()
+			m.fs = afero.NewMemMapFs()
+			if err := afero.WriteFile(m.fs, "opt/shared/auth/policy.libsonnet", []byte("{}"), 0o644); err != nil {
+				t.Errorf("afero.WriteFile() error = %v", err)
+				return
+			}
+			assert.NoError(t, m.AddRootAlias("team", "opt/shared"))
+
+			err := m.AddRootAlias(tt.scheme, tt.dir)
+			if tt.wantErrType != nil {
+				assert.ErrorIs(t, err, tt.wantErrType)
+				return
+			}
+			assert.NoError(t, err)
+			// the importer must come before the FallbackFileImporter
+			assert.True(t, m.importers[0].CanHandle(tt.scheme))
+		})
+	}
+}
+
+func TestRootImporter_Import(t *testing.T) {
+	m := NewMultiImporter()
+	m.fs = afero.NewMemMapFs()
+	if err := afero.WriteFile(m.fs, "opt/shared/auth/policy.libsonnet", []byte("{policy: true}"), 0o644); err != nil {
+		t.Errorf("afero.WriteFile() error = %v", err)
+		return
+	}
+	if err := m.AddRootAlias("shared", "opt/shared"); err != nil {
+		t.Errorf("MultiImporter.AddRootAlias() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         jsonnet.Contents
+		wantFoundAt  string
+		wantErrType  error
+	}{
+		{
+			name:         "file inside the directory",
+			importedPath: "shared://auth/policy.libsonnet",
+			want:         jsonnet.MakeContents("{policy: true}"),
+			wantFoundAt:  "opt/shared/auth/./policy.libsonnet",
+		},
+		{
+			name:         "missing file - should return error",
+			importedPath: "shared://auth/missing.libsonnet",
+			want:         jsonnet.MakeContents(""),
+			wantErrType:  ErrFileNotFound,
+		},
+		{
+			name:         "path outside of the directory - should return error",
+			importedPath: "shared://auth/../../secret.libsonnet",
+			want:         jsonnet.MakeContents(""),
+			wantErrType:  ErrMalformedImport,
+		},
+		{
+			name:         "missing path - should return error",
+			importedPath: "shared://",
+			want:         jsonnet.MakeContents(""),
+			wantErrType:  ErrMalformedImport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotFoundAt, err := m.importers[0].Import("main.jsonnet", tt.importedPath)
+			if tt.wantErrType != nil {
+				assert.ErrorIs(t, err, tt.wantErrType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want.String(), got.String())
+			assert.Equal(t, tt.wantFoundAt, gotFoundAt)
+		})
+	}
+}
+
+func TestMultiImporter_AddRootAliasEvaluate(t *testing.T) {
+	m := NewMultiImporter()
+	if err := m.AddRootAlias("vendor", "testdata/lock/vendor"); err != nil {
+		t.Errorf("MultiImporter.AddRootAlias() error = %v", err)
+		return
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	// the relative import inside the file will be resolved relative to it
+	got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", `{
+		aliased: import 'vendor://common@1.2.0/main.libsonnet',
+		plain: import 'testdata/lock/vendor/common@1.2.0/main.libsonnet',
+	}`)
+	if err != nil {
+		t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+		return
+	}
+	want := `{"name": "common", "helper": true}`
+	assert.JSONEq(t, `{"aliased": `+want+`, "plain": `+want+`}`, got)
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"go.uber.org/zap"
+)
+
+const (
+	stdinPrefix    = "stdin"
+	stdinStrPrefix = "stdin-str"
+)
+
+// StdinImporter imports the content of a reader (default: os.Stdin) via the
+// prefix `stdin://`, for example to pipe a payload into jsonnet. The reader
+// will be read only once, therefore all `stdin://` imports return the same
+// content. Use the prefix `stdin-str://` to get the content as string.
+// Example:
+//   - import 'stdin://'
+//   - import 'stdin-str://'
+type StdinImporter struct {
+	reader io.Reader
+	logger *zap.Logger
+	// read is true after the first read of the reader; the content and the
+	// error of this read will be returned for all imports.
+	read     bool
+	content  []byte
+	readErr  error
+	contents contentsCache
+}
+
+// NewStdinImporter returns a StdinImporter, which reads from os.Stdin.
+func NewStdinImporter() *StdinImporter {
+	return &StdinImporter{
+		reader: os.Stdin,
+		logger: zap.New(nil),
+	}
+}
+
+// SetReader replaces the reader of the StdinImporter; useful for tests.
+// It must be called before the first import.
+func (s *StdinImporter) SetReader(reader io.Reader) {
+	s.reader = reader
+}
+
+// CanHandle returns true for the `stdin` and `stdin-str` prefixa.
+func (s *StdinImporter) CanHandle(prefix string) bool {
+	return prefix == stdinPrefix || prefix == stdinStrPrefix
+}
+
+// Logger can be used to set the zap.Logger for the StdinImporter.
+func (s *StdinImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		s.logger = logger
+	}
+}
+
+// Prefixa returns the list of supported prefixa for this importer.
+func (s *StdinImporter) Prefixa() []string {
+	return []string{stdinPrefix, stdinStrPrefix}
+}
+
+// Import implements the go-jsonnet iterface method. It returns the content of
+// the reader either as is (`stdin://`) or as string (`stdin-str://`).
+func (s *StdinImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	logger := s.logger.Named("StdinImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+	)
+
+	prefix, rest, found := strings.Cut(importedPath, "://")
+	if !found || !s.CanHandle(prefix) || rest != "" {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: '%s', expected 'stdin://' or 'stdin-str://'", ErrMalformedImport, importedPath)
+	}
+
+	foundAt := prefix + "://"
+
+	contents, err := s.contents.load(foundAt, func() (jsonnet.Contents, error) {
+		content, err := s.readOnce()
+		if err != nil {
+			return jsonnet.Contents{}, err
+		}
+
+		if prefix == stdinStrPrefix {
+			if content, err = json.Marshal(string(content)); err != nil {
+				return jsonnet.Contents{}, fmt.Errorf("while converting stdin into a string: %w", err)
+			}
+		}
+
+		return jsonnet.MakeContentsRaw(content), nil
+	})
+	if err != nil {
+		return jsonnet.MakeContents(""), "", err
+	}
+
+	logger.Debug("returns", zap.Int("bytes", len(contents.Data())), zap.String("foundAt", foundAt))
+
+	return contents, foundAt, nil
+}
+
+// readOnce reads the whole reader at the first call and returns the same
+// content (or error) for all further calls.
+func (s *StdinImporter) readOnce() ([]byte, error) {
+	if !s.read {
+		s.read = true
+
+		if s.reader == nil {
+			s.readErr = errors.New("while reading stdin: no reader set")
+		} else if s.content, s.readErr = io.ReadAll(s.reader); s.readErr != nil {
+			s.readErr = fmt.Errorf("while reading stdin: %w", s.readErr)
+		}
+	}
+
+	return s.content, s.readErr
+}
@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStdinImporter_Import(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		importedPath string
+		want         string
+		wantFoundAt  string
+		wantErr      bool
+	}{
+		{
+			name:         "stdin",
+			input:        "{a: 1}",
+			importedPath: "stdin://",
+			want:         "{a: 1}",
+			wantFoundAt:  "stdin://",
+		},
+		{
+			name:         "stdin-str",
+			input:        "line 1\nline 'two'\n",
+			importedPath: "stdin-str://",
+			want:         `"line 1\nline 'two'\n"`,
+			wantFoundAt:  "stdin-str://",
+		},
+		{
+			name:         "path after the prefix - should return error",
+			input:        "{}",
+			importedPath: "stdin://file.jsonnet",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStdinImporter()
+			s.SetReader(strings.NewReader(tt.input))
+
+			got, gotFoundAt, err := s.Import("main.jsonnet", tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StdinImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+			assert.Equal(t, tt.wantFoundAt, gotFoundAt)
+		})
+	}
+}
+
+func TestStdinImporter_ImportReadOnce(t *testing.T) {
+	s := NewStdinImporter()
+	s.SetReader(strings.NewReader("{a: 1}"))
+
+	first, _, err := s.Import("main.jsonnet", "stdin://")
+	assert.NoError(t, err)
+
+	// the reader is consumed, but the content is cached
+	second, _, err := s.Import("other.jsonnet", "stdin://")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	str, _, err := s.Import("other.jsonnet", "stdin-str://")
+	assert.NoError(t, err)
+	assert.Equal(t, `"{a: 1}"`, str.String())
+}
+
+func TestStdinImporter_ImportReadError(t *testing.T) {
+	readErr := errors.New("broken pipe")
+
+	s := NewStdinImporter()
+	s.SetReader(iotest.ErrReader(readErr))
+
+	_, _, err := s.Import("main.jsonnet", "stdin://")
+	assert.ErrorIs(t, err, readErr)
+
+	// the error stays, because the stream cannot be read again
+	_, _, err = s.Import("main.jsonnet", "stdin-str://")
+	assert.ErrorIs(t, err, readErr)
+}
+
+func TestStdinImporter_MultiImporter(t *testing.T) {
+	s := NewStdinImporter()
+	s.SetReader(strings.NewReader(`{"replicas": 3}`))
+
+	m := NewMultiImporter(NewGlobImporter(), s, NewFallbackFileImporter())
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	got, err := vm.EvaluateAnonymousSnippet("main.jsonnet",
+		"local payload = import 'stdin://'; {replicas: payload.replicas, raw: importstr 'stdin://'}")
+	if err != nil {
+		t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+		return
+	}
+	assert.Equal(t, "{\n   \"raw\": \"{\\\"replicas\\\": 3}\",\n   \"replicas\": 3\n}\n", got)
+}
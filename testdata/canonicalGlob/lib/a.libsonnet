@@ -0,0 +1 @@
+{a: 1}
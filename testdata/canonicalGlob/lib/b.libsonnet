@@ -0,0 +1 @@
+{b: import 'a.libsonnet'}
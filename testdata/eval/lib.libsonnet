@@ -0,0 +1,3 @@
+{
+  sum: 1 + 1,
+}
@@ -0,0 +1 @@
+{ name: 'root-helper' }
@@ -0,0 +1 @@
+{ name: 'sub-helper' }
@@ -0,0 +1,2 @@
+// the relative import is resolved relative to this file
+{ host: (import 'helper.libsonnet').name }
@@ -0,0 +1 @@
+{ host: 'a' }
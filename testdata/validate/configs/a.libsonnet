@@ -0,0 +1,3 @@
+{
+  a: import '../lib/ok.libsonnet',
+}
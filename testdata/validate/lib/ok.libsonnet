@@ -0,0 +1,3 @@
+{
+  deep: import 'deeper/missing.libsonnet',
+}
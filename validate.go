@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"fmt"
+
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+	"github.com/google/go-jsonnet/toolutils"
+)
+
+// Validate checks that every import of the entry file and of all transitively
+// imported files can be resolved by one of the importers, without evaluating
+// the Jsonnet code. Each resolved `import` will be parsed to find its own
+// imports, while the contents of `importstr` and `importbin` will only be
+// resolved. Instead of failing on the first problem, like a missing file or an
+// empty glob result, all errors will be collected and returned; an empty list
+// means every import resolves.
+// Note: the imports run through the same Import method as during an
+// evaluation, but on a copy of the MultiImporter, so that `config://set`
+// imports take effect only during the validation and the validated contents
+// do not count against the byte limit of a later evaluation. The import graph
+// records the imports. Use a separate MultiImporter for the validation to
+// start with a clean import graph.
+func (m *MultiImporter) Validate(entryFile string) []error {
+	clone := *m
+	clone.totalBytes = 0
+	clone.countedBytes = nil
+
+	defer m.restoreAfter(&clone)()
+
+	// the entry file will be imported from "" like in vm.EvaluateFile
+	contents, foundAt, err := clone.Import("", entryFile)
+	if err != nil {
+		return []error{err}
+	}
+
+	v := &validation{importer: &clone, visited: map[string]bool{}}
+	v.walk(foundAt, contents)
+
+	return v.errs
+}
+
+// restoreAfter returns a function, which takes over the import graph state of
+// the given copy of the MultiImporter and restores the GlobImporters and the
+// loggers, which are shared with the copy and which `config://set` imports of
+// the copy can change.
+func (m *MultiImporter) restoreAfter(clone *MultiImporter) func() {
+	globs := map[*GlobImporter]GlobImporter{}
+	_ = m.eachGlob(func(g *GlobImporter) error {
+		globs[g] = *g
+
+		return nil
+	})
+
+	return func() {
+		// the copy shares the graph, but counts the imports on its own
+		m.importCounter = clone.importCounter
+		m.importDepths = clone.importDepths
+		m.files = clone.files
+
+		if clone.logger != m.logger {
+			m.Logger(m.logger)
+		}
+
+		for g, saved := range globs {
+			*g = saved
+		}
+	}
+}
+
+// validation stores the state of a single Validate run.
+type validation struct {
+	importer *MultiImporter
+	// visited stores the foundAt values of the already walked files.
+	visited map[string]bool
+	errs    []error
+}
+
+// walk parses the contents found at foundAt and imports each of its imports.
+// The contents of resolved `import` expressions will be walked as well.
+func (v *validation) walk(foundAt string, contents jsonnet.Contents) {
+	if v.visited[foundAt] {
+		return
+	}
+
+	v.visited[foundAt] = true
+
+	node, err := jsonnet.SnippetToAST(foundAt, contents.String())
+	if err != nil {
+		v.errs = append(v.errs, fmt.Errorf("while parsing '%s' for the validation, error: %w", foundAt, err))
+
+		return
+	}
+
+	for _, imp := range importsOf(node) {
+		contents, importedAt, err := v.importer.Import(foundAt, imp.path)
+		if err != nil {
+			v.errs = append(v.errs, fmt.Errorf("%s: %w", imp.loc.String(), err))
+
+			continue
+		}
+
+		if imp.parse {
+			v.walk(importedAt, contents)
+		}
+	}
+}
+
+// importExpr is an import expression found inside a Jsonnet AST.
+type importExpr struct {
+	path string
+	loc  ast.LocationRange
+	// parse is true for `import`, whose contents are Jsonnet code.
+	parse bool
+}
+
+// importsOf returns all import expressions of the given AST in the order of
+// their appearance.
+func importsOf(node ast.Node) []importExpr {
+	imports := []importExpr{}
+
+	var visit func(ast.Node)
+	visit = func(n ast.Node) {
+		switch i := n.(type) {
+		case *ast.Import:
+			imports = append(imports, importExpr{path: i.File.Value, loc: *i.Loc(), parse: true})
+		case *ast.ImportStr:
+			imports = append(imports, importExpr{path: i.File.Value, loc: *i.Loc()})
+		case *ast.ImportBin:
+			imports = append(imports, importExpr{path: i.File.Value, loc: *i.Loc()})
+		}
+
+		for _, child := range toolutils.Children(n) {
+			visit(child)
+		}
+	}
+	visit(node)
+
+	return imports
+}
@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiImporter_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		entryFile string
+		wantErrs  []error
+	}{
+		{
+			name:      "all imports resolve",
+			entryFile: "testdata/strict/main.jsonnet",
+			wantErrs:  []error{},
+		},
+		{
+			name:      "all errors of the entry file and the imported files",
+			entryFile: "testdata/validate/main.jsonnet",
+			wantErrs: []error{
+				ErrFileNotFound, // lib/ok.libsonnet -> deeper/missing.libsonnet
+				ErrFileNotFound, // lib/missing.libsonnet
+				ErrEmptyResult,  // glob+://nothing/*.libsonnet
+				ErrFileNotFound, // lib/missing.txt
+			},
+		},
+		{
+			name:      "missing entry file",
+			entryFile: "testdata/validate/missing.jsonnet",
+			wantErrs:  []error{ErrFileNotFound},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiImporter()
+
+			errs := m.Validate(tt.entryFile)
+			if !assert.Len(t, errs, len(tt.wantErrs)) {
+				return
+			}
+
+			for i, want := range tt.wantErrs {
+				assert.ErrorIs(t, errs[i], want)
+			}
+		})
+	}
+}
+
+func TestMultiImporter_ValidateRestoresConfig(t *testing.T) {
+	m := NewMultiImporter()
+	m.SetMaxTotalBytes(1 << 20)
+	logger := m.logger
+
+	errs := m.Validate("testdata/validateConfig/main.jsonnet")
+	assert.Empty(t, errs)
+
+	assert.False(t, m.ignoreImportCycles)
+	assert.Equal(t, 0, m.maxImportDepth)
+	assert.Empty(t, m.LogLevel())
+	assert.False(t, m.fallthroughOnError)
+	assert.False(t, m.hideGraphRoot)
+	assert.Same(t, logger, m.logger)
+
+	for _, i := range m.importers {
+		if g, ok := globImporterOf(i); ok {
+			assert.False(t, g.strictJPaths)
+		}
+	}
+
+	// the validated contents do not count against the byte limit
+	assert.Equal(t, int64(0), m.totalBytes)
+	assert.Empty(t, m.countedBytes)
+
+	// the import graph records the validated imports
+	assert.Positive(t, m.importCounter)
+	assert.Contains(t, m.files, "testdata/validateConfig/main.jsonnet")
+}
@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+)
+
+const yamlPrefix = "yaml"
+
+// YAMLImporter imports YAML files via the prefix `yaml://` and converts them
+// into JSON, which can be used directly in jsonnet. The `glob.yaml+://` prefix
+// of the GlobImporter generates such imports, therefore the YAMLImporter must
+// be added in front of the FallbackFileImporter inside a MultiImporter.
+// Example:
+//   - import 'yaml://configs/a.yaml'
+type YAMLImporter struct {
+	// JPaths stores extra search paths.
+	JPaths []string
+	// A FileSystem abstraction; useful for tests
+	fs     afero.Fs
+	logger *zap.Logger
+	cache  contentsCache
+}
+
+// NewYAMLImporter returns a YAMLImporter, which searches the YAML files
+// relative to the importing file and afterwards in the given JPaths.
+func NewYAMLImporter(jpaths ...string) *YAMLImporter {
+	return &YAMLImporter{
+		JPaths: jpaths,
+		fs:     afero.NewOsFs(),
+		logger: zap.New(nil),
+	}
+}
+
+// CanHandle returns true for the `yaml` prefix.
+func (y *YAMLImporter) CanHandle(prefix string) bool {
+	return prefix == yamlPrefix
+}
+
+// Logger can be used to set the zap.Logger for the YAMLImporter.
+func (y *YAMLImporter) Logger(logger *zap.Logger) {
+	if logger != nil {
+		y.logger = logger
+	}
+}
+
+// Prefixa returns the list of supported prefixa for this importer.
+func (y *YAMLImporter) Prefixa() []string {
+	return []string{yamlPrefix}
+}
+
+// Import implements the go-jsonnet iterface method. It reads the YAML file
+// behind the `yaml://` prefix and returns its content as JSON.
+func (y *YAMLImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	logger := y.logger.Named("YAMLImporter")
+	logger.Debug("Import()",
+		zap.String("importedFrom", importedFrom),
+		zap.String("importedPath", importedPath),
+	)
+
+	file, ok := strings.CutPrefix(importedPath, yamlPrefix+"://")
+	if !ok || file == "" {
+		return jsonnet.MakeContents(""), "",
+			fmt.Errorf("%w: '%s', expected 'yaml://<file>'", ErrMalformedImport, importedPath)
+	}
+
+	found, data, err := y.read(importedFrom, file)
+	if err != nil {
+		return jsonnet.MakeContents(""), "", err
+	}
+
+	foundAt := schemeFoundAt(yamlPrefix, found)
+
+	contents, err := y.cache.load(foundAt, func() (jsonnet.Contents, error) {
+		jsonData, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return jsonnet.Contents{}, fmt.Errorf("while converting the YAML file '%s' into JSON: %w", foundAt, err)
+		}
+
+		return jsonnet.MakeContentsRaw(jsonData), nil
+	})
+	if err != nil {
+		return jsonnet.MakeContents(""), "", err
+	}
+
+	logger.Debug("returns", zap.String("foundAt", foundAt))
+
+	return contents, foundAt, nil
+}
+
+// read searches the file relative to the importing file and afterwards in the
+// JPaths. It returns the path of the first existing file together with its
+// content.
+func (y *YAMLImporter) read(importedFrom, file string) (string, []byte, error) {
+	candidates := []string{file}
+	if !filepath.IsAbs(file) {
+		dir, _ := filepath.Split(importedFrom)
+		candidates = []string{filepath.Join(dir, file)}
+
+		for _, jpath := range y.JPaths {
+			candidates = append(candidates, filepath.Join(jpath, file))
+		}
+	}
+
+	for _, candidate := range candidates {
+		data, err := afero.ReadFile(y.fs, candidate)
+		if err == nil {
+			return candidate, data, nil
+		}
+
+		if !os.IsNotExist(err) {
+			return "", nil, fmt.Errorf("while reading the YAML file '%s': %w", candidate, err)
+		}
+	}
+
+	return "", nil, fmt.Errorf("%w: '%s', tried %s", ErrFileNotFound, file, strings.Join(candidates, ", "))
+}
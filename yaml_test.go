@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYAMLImporter_Import(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.yaml":   "a: 1\nlist:\n  - x\n  - z\n",
+		"vendor/b.yaml":    "b: true\n",
+		"configs/bad.yaml": "a: [1,\n",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name         string
+		importedFrom string
+		importedPath string
+		want         jsonnet.Contents
+		wantFoundAt  string
+		wantErr      bool
+	}{
+		{
+			name:         "relative to the importing file",
+			importedFrom: "configs/main.jsonnet",
+			importedPath: "yaml://a.yaml",
+			want:         jsonnet.MakeContents(`{"a":1,"list":["x","z"]}`),
+			wantFoundAt:  "yaml://configs/a.yaml",
+		},
+		{
+			name:         "inside the jpath",
+			importedFrom: "main.jsonnet",
+			importedPath: "yaml://b.yaml",
+			want:         jsonnet.MakeContents(`{"b":true}`),
+			wantFoundAt:  "yaml://vendor/b.yaml",
+		},
+		{
+			name:         "missing file - should return error",
+			importedFrom: "main.jsonnet",
+			importedPath: "yaml://missing.yaml",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      true,
+		},
+		{
+			name:         "malformed yaml - should return error",
+			importedFrom: "main.jsonnet",
+			importedPath: "yaml://configs/bad.yaml",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      true,
+		},
+		{
+			name:         "missing file name - should return error",
+			importedFrom: "main.jsonnet",
+			importedPath: "yaml://",
+			want:         jsonnet.MakeContents(""),
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			y := NewYAMLImporter("vendor")
+			y.fs = fs
+
+			got, gotFoundAt, err := y.Import(tt.importedFrom, tt.importedPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("YAMLImporter.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want.String(), got.String())
+			assert.Equal(t, tt.wantFoundAt, gotFoundAt)
+		})
+	}
+}
+
+func TestYAMLImporter_ImportCached(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "a.yaml", []byte("a: 1\n"), 0o644); err != nil {
+		t.Errorf("afero.WriteFile() error = %v", err)
+		return
+	}
+
+	y := NewYAMLImporter()
+	y.fs = fs
+
+	first, _, err := y.Import("main.jsonnet", "yaml://a.yaml")
+	assert.NoError(t, err)
+
+	// go-jsonnet expects the same contents for the same foundAt value
+	second, _, err := y.Import("other.jsonnet", "yaml://a.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestYAMLImporter_GlobYAMLPlus(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	testFiles := map[string]string{
+		"configs/a.yaml": "name: a\nreplicas: 1\n",
+		"configs/b.yaml": "replicas: 3\n",
+	}
+	for file, cnt := range testFiles {
+		if err := afero.WriteFile(fs, file, []byte(cnt), 0o644); err != nil {
+			t.Errorf("afero.WriteFile() error = %v", err)
+			return
+		}
+	}
+
+	g := NewGlobImporter()
+	g.fs = fs
+	y := NewYAMLImporter()
+	y.fs = fs
+
+	m := NewMultiImporter(g, y, NewFallbackFileImporter())
+	m.fs = afero.NewMemMapFs()
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(m)
+
+	got, err := vm.EvaluateAnonymousSnippet("main.jsonnet", "import 'glob.yaml+://configs/*.yaml'")
+	if err != nil {
+		t.Errorf("vm.EvaluateAnonymousSnippet() error = %v", err)
+		return
+	}
+	assert.Equal(t, "{\n   \"name\": \"a\",\n   \"replicas\": 3\n}\n", got)
+}